@@ -4,7 +4,10 @@ import (
 	"cleaning-app-backend/internal/config"
 	"cleaning-app-backend/internal/database"
 	"cleaning-app-backend/internal/handlers"
+	"cleaning-app-backend/internal/jobs"
 	"cleaning-app-backend/internal/middleware"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/validation"
 	"log"
 	"time"
 
@@ -42,6 +45,23 @@ func main() {
 		time.Sleep(5 * time.Second)
 	}
 
+	// Enforce the validate tags of the request models
+	if err := validation.Register(); err != nil {
+		log.Fatal("Failed to set up request validation:", err)
+	}
+
+	// Background jobs: extend recurring bookings, mark past-due invoices
+	// overdue and bill late fees, expire unanswered quotes
+	if err := jobs.StartSeriesJob(config); err != nil {
+		log.Fatal("Failed to start the series job:", err)
+	}
+	if err := jobs.StartInvoiceJob(config); err != nil {
+		log.Fatal("Failed to start the invoice job:", err)
+	}
+	if err := jobs.StartQuoteJob(config); err != nil {
+		log.Fatal("Failed to start the quote job:", err)
+	}
+
 	// Set up Gin router
 	r := gin.Default()
 
@@ -56,15 +76,40 @@ func main() {
 		public.POST("/auth/register", handlers.Register)
 		public.POST("/auth/login", handlers.Login)
 		public.POST("/auth/refresh", handlers.RefreshToken)
+		public.POST("/auth/logout", handlers.Logout)
+		public.POST("/auth/password/forgot", handlers.ForgotPassword)
+		public.POST("/auth/password/verify", handlers.VerifyPasswordResetToken)
+		public.POST("/auth/password/reset", handlers.ResetPassword)
+		public.POST("/auth/verify-email", handlers.VerifyEmail)
 		
 		// Public services (no auth required)
 		public.GET("/services", handlers.GetServices)
+		public.GET("/services/categories", handlers.GetServiceCategories)
+		public.GET("/services/add-ons", handlers.GetAddOns)
+		public.GET("/services/:id/add-ons", handlers.GetServiceAddOns)
 		
 		// Guest booking and quotes (no auth required)
 		public.POST("/guest/booking", handlers.CreateGuestBooking)
-		public.GET("/guest/booking/:id", handlers.GetGuestBooking)
+		public.POST("/guest/booking/:id/link", handlers.ResendGuestLink)
 		public.POST("/quote", handlers.RequestQuote)
 		public.GET("/quote/estimate", handlers.GetQuoteEstimate)
+
+		// Guest portal, authorized by the signed link in the confirmation email
+		public.GET("/guest/portal/booking", handlers.GetGuestPortalBooking)
+		public.PUT("/guest/portal/booking/reschedule", handlers.RescheduleGuestPortalBooking)
+		public.POST("/guest/portal/booking/cancel", handlers.CancelGuestPortalBooking)
+		public.GET("/guest/portal/invoice", handlers.GetGuestPortalInvoice)
+		public.GET("/guest/portal/invoice/pdf", handlers.GetGuestPortalInvoicePDF)
+		public.POST("/guest/portal/invoice/pay", handlers.PayGuestPortalInvoice)
+		public.GET("/guest/portal/invoices", handlers.GetGuestPortalInvoices)
+		public.GET("/guest/portal/invoices/:id", handlers.GetGuestPortalInvoice)
+		public.GET("/guest/portal/invoices/:id/pdf", handlers.GetGuestPortalInvoicePDF)
+		public.POST("/guest/portal/invoices/:id/pay", handlers.PayGuestPortalInvoice)
+
+		// Quote links, authorized by the token in the emailed quote
+		public.GET("/quote/portal", handlers.GetQuotePortalQuote)
+		public.POST("/quote/portal/accept", handlers.AcceptQuotePortalQuote)
+		public.POST("/quote/portal/reject", handlers.RejectQuotePortalQuote)
 		
 		// Contact and support (no auth required)
 		public.POST("/contact", handlers.SubmitContactMessage)
@@ -72,6 +117,8 @@ func main() {
 		
 		// Available slots for booking (no auth required)
 		public.GET("/available-slots", handlers.GetAvailableSlots)
+		public.GET("/business-hours", handlers.GetBusinessHours)
+		public.GET("/cancellation-policy", handlers.GetCancellationPolicy)
 	}
 
 	// Protected routes
@@ -80,11 +127,37 @@ func main() {
 	{
 		// User routes
 		protected.GET("/auth/me", handlers.GetProfile)
+		protected.POST("/auth/verify-email/resend", handlers.ResendEmailVerification)
+		protected.POST("/auth/claim-guest-history", handlers.ClaimGuestHistory)
+		protected.GET("/quotes", handlers.GetMyQuotes)
+		protected.GET("/invoices", handlers.GetMyInvoices)
+		protected.GET("/invoices/:id/pdf", handlers.GetMyInvoicePDF)
+		protected.GET("/credit-notes/:id/pdf", handlers.GetMyCreditNotePDF)
+
+		// Saved service properties (clients)
+		properties := protected.Group("/properties")
+		properties.Use(middleware.RoleMiddleware(models.RoleClient))
+		{
+			properties.GET("", handlers.GetMyProperties)
+			properties.POST("", handlers.CreateMyProperty)
+			properties.PUT("/:id", handlers.UpdateMyProperty)
+			properties.DELETE("/:id", handlers.DeleteMyProperty)
+		}
 
 		// Service routes (authenticated users can view, only admins can modify)
 		protected.POST("/services", middleware.AdminMiddleware(), handlers.CreateService)
 		protected.PUT("/services/:id", middleware.AdminMiddleware(), handlers.UpdateService)
-		protected.DELETE("/services/:id", middleware.AdminMiddleware(), handlers.DeleteService)
+		protected.POST("/services/:id/archive", middleware.AdminMiddleware(), handlers.ArchiveService)
+		protected.POST("/services/:id/unarchive", middleware.AdminMiddleware(), handlers.UnarchiveService)
+		protected.GET("/services/:id/pricing", middleware.AdminMiddleware(), handlers.GetServicePricing)
+		protected.PUT("/services/:id/pricing", middleware.AdminMiddleware(), handlers.UpdateServicePricing)
+		protected.PUT("/services/:id/add-ons", middleware.AdminMiddleware(), handlers.SetServiceAddOns)
+		protected.POST("/services/categories", middleware.AdminMiddleware(), handlers.CreateServiceCategory)
+		protected.PUT("/services/categories/:id", middleware.AdminMiddleware(), handlers.UpdateServiceCategory)
+		protected.GET("/services/all", middleware.AdminMiddleware(), handlers.GetAllServices)
+		protected.GET("/services/add-ons/all", middleware.AdminMiddleware(), handlers.GetAllAddOns)
+		protected.POST("/services/add-ons", middleware.AdminMiddleware(), handlers.CreateAddOn)
+		protected.PUT("/services/add-ons/:id", middleware.AdminMiddleware(), handlers.UpdateAddOn)
 
 		// Booking routes (authenticated users)
 		protected.GET("/bookings", handlers.GetBookings)
@@ -92,6 +165,25 @@ func main() {
 		protected.POST("/bookings", handlers.CreateBooking)
 		protected.PUT("/bookings/:id", handlers.UpdateBooking)
 		protected.DELETE("/bookings/:id", handlers.CancelBooking)
+		protected.PUT("/bookings/:id/reschedule", handlers.RescheduleMyBooking)
+
+		// Recurring bookings
+		protected.GET("/series", handlers.GetSeriesList)
+		protected.POST("/series", handlers.CreateSeries)
+		protected.GET("/series/:id", handlers.GetSeries)
+		protected.PUT("/series/:id", handlers.UpdateSeries)
+		protected.DELETE("/series/:id", handlers.CancelSeries)
+		protected.POST("/series/:id/occurrences/:booking_id/skip", handlers.SkipOccurrence)
+		protected.PUT("/series/:id/occurrences/:booking_id", handlers.RescheduleOccurrence)
+
+		// Cleaner (field staff) routes - only jobs assigned to the cleaner
+		cleaner := protected.Group("/cleaner")
+		cleaner.Use(middleware.RoleMiddleware(models.RoleCleaner))
+		{
+			cleaner.GET("/jobs", handlers.GetMyJobs)
+			cleaner.GET("/jobs/:id", handlers.GetMyJob)
+			cleaner.PUT("/jobs/:id/status", handlers.UpdateMyJobStatus)
+		}
 
 		// Admin routes
 		admin := protected.Group("/admin")
@@ -100,36 +192,104 @@ func main() {
 			// Booking management
 			admin.GET("/bookings", handlers.GetAllBookings)
 			admin.PUT("/bookings/:id", handlers.AdminUpdateBooking)
+			admin.GET("/bookings/:id/history", handlers.GetBookingHistory)
 			admin.PUT("/bookings/:id/reschedule", handlers.RescheduleBooking)
+			admin.POST("/series/generate", handlers.GenerateSeriesOccurrences)
+			admin.GET("/bookings/:id/assignees", handlers.GetBookingAssignees)
+			admin.PUT("/bookings/:id/assignees", handlers.AssignBookingCleaners)
+			admin.POST("/bookings/:id/assignees", handlers.AddBookingCleaners)
+			admin.DELETE("/bookings/:id/assignees/:user_id", handlers.RemoveBookingCleaner)
+
+			// Guest history of client accounts
+			admin.GET("/users/:id/guest-claims", handlers.GetGuestClaims)
+			admin.POST("/users/:id/merge-guest-history", handlers.MergeGuestHistory)
+
+			// Customers
+			admin.GET("/customers", handlers.SearchCustomers)
+			admin.POST("/customers", handlers.CreateCustomer)
+			admin.GET("/customers/:id", handlers.GetCustomer)
+			admin.PUT("/customers/:id", handlers.UpdateCustomer)
+			admin.GET("/customers/:id/timeline", handlers.GetCustomerTimeline)
+			admin.GET("/customers/:id/credit", handlers.GetCustomerCredit)
+			admin.POST("/customers/:id/merge", handlers.MergeCustomer)
+			admin.POST("/customers/:id/contacts", handlers.AddCustomerContact)
+			admin.DELETE("/customers/:id/contacts/:contact_id", handlers.DeleteCustomerContact)
+			admin.POST("/customers/:id/properties", handlers.AddCustomerProperty)
+			admin.PUT("/customers/:id/properties/:property_id", handlers.UpdateCustomerProperty)
+			admin.DELETE("/customers/:id/properties/:property_id", handlers.DeleteCustomerProperty)
+			admin.POST("/customers/:id/notes", handlers.AddCustomerNote)
+
+			// Staff management
+			admin.GET("/cleaners", handlers.GetCleaners)
+			admin.POST("/cleaners", handlers.CreateCleaner)
+			admin.GET("/crews", handlers.GetCrews)
+			admin.POST("/crews", handlers.CreateCrew)
+			admin.PUT("/crews/:id", handlers.UpdateCrew)
+			admin.DELETE("/crews/:id", handlers.DeleteCrew)
+			admin.PUT("/crews/:id/members", handlers.SetCrewMembers)
 			
 			// Calendar and scheduling
 			admin.GET("/calendar/events", handlers.GetCalendarEvents)
 			admin.GET("/calendar/day/:date", handlers.GetDaySchedule)
 			admin.GET("/calendar/stats", handlers.GetBookingStats)
+
+			// Business hours, holidays and blackout dates
+			admin.GET("/schedule/hours", handlers.GetBusinessHours)
+			admin.PUT("/schedule/hours", handlers.UpdateBusinessHours)
+			admin.GET("/schedule/exceptions", handlers.GetScheduleExceptions)
+			admin.POST("/schedule/exceptions", handlers.CreateScheduleException)
+			admin.PUT("/schedule/exceptions/:id", handlers.UpdateScheduleException)
+			admin.DELETE("/schedule/exceptions/:id", handlers.DeleteScheduleException)
+
+			// Cancellation window and late cancellation / no-show fees
+			admin.GET("/cancellation-policy", handlers.GetCancellationPolicy)
+			admin.PUT("/cancellation-policy", handlers.UpdateCancellationPolicy)
 			
+			// Promo codes
+			admin.GET("/promo-codes", handlers.GetPromoCodes)
+			admin.POST("/promo-codes", handlers.CreatePromoCode)
+			admin.GET("/promo-codes/:id", handlers.GetPromoCode)
+			admin.PUT("/promo-codes/:id", handlers.UpdatePromoCode)
+
 			// Quote management
 			admin.GET("/quotes", handlers.GetQuotes)
 			admin.PUT("/quotes/:id", handlers.UpdateQuote)
+			admin.POST("/quotes/:id/send", handlers.SendQuote)
 			
 			// Contact message management
 			admin.GET("/messages", handlers.GetContactMessages)
 			admin.PUT("/messages/:id", handlers.UpdateContactMessage)
 			
 			// FAQ management
+			admin.GET("/faq", handlers.GetAllFAQs)
 			admin.POST("/faq", handlers.CreateFAQ)
 			admin.PUT("/faq/:id", handlers.UpdateFAQ)
 			admin.DELETE("/faq/:id", handlers.DeleteFAQ)
+			admin.POST("/faq/:id/restore", handlers.RestoreFAQ)
 			
 			// Invoice management (fixed)
 			admin.GET("/invoices", handlers.SimpleGetAllInvoices)
 			admin.GET("/invoices/:id", handlers.SimpleGetInvoice)
+			admin.GET("/invoices/:id/pdf", handlers.GetInvoicePDF)
 			admin.POST("/invoices", handlers.CreateInvoice)
 			admin.POST("/invoices/from-booking/:booking_id", handlers.SimpleGenerateInvoiceFromBooking)
 			admin.POST("/invoices/custom", handlers.SimpleCreateCustomInvoice)
 			admin.PUT("/invoices/:id", handlers.UpdateInvoice)
 			admin.PUT("/invoices/:id/mark-paid", handlers.SimpleMarkAsPaid)
+			admin.GET("/invoices/:id/payments", handlers.GetInvoicePayments)
+			admin.POST("/invoices/:id/payments", handlers.RecordInvoicePayment)
+			admin.POST("/invoices/:id/void", handlers.VoidInvoice)
+			admin.POST("/invoices/:id/credit-notes", handlers.CreateCreditNote)
+			admin.GET("/credit-notes/:id", handlers.GetCreditNote)
+			admin.GET("/credit-notes/:id/pdf", handlers.GetCreditNotePDF)
+			admin.POST("/payments/:id/refunds", handlers.RecordRefund)
 			admin.DELETE("/invoices/:id", handlers.SimpleDeleteInvoice)
+			admin.POST("/invoices/:id/restore", handlers.SimpleRestoreInvoice)
 			admin.GET("/invoices/date-range", handlers.SimpleGetInvoicesByDateRange)
+
+			// Document numbering
+			admin.GET("/numbering", handlers.GetNumberingSchemes)
+			admin.PUT("/numbering/:type", handlers.UpdateNumberingScheme)
 			
 			// Reports and Analytics
 			admin.GET("/reports", handlers.SimpleGetReportsData)
@@ -16,6 +16,48 @@ type Config struct {
 	DBSSLMode  string `mapstructure:"DB_SSL_MODE"`
 	JWTSecret  string `mapstructure:"JWT_SECRET"`
 	JWTExpiry  string `mapstructure:"JWT_EXPIRY"`
+
+	RefreshTokenExpiry string `mapstructure:"REFRESH_TOKEN_EXPIRY"`
+
+	// Links in outgoing email point at the frontend
+	AppURL string `mapstructure:"APP_URL"`
+
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     string `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	SMTPFrom     string `mapstructure:"SMTP_FROM"`
+
+	PasswordResetExpiry     string `mapstructure:"PASSWORD_RESET_EXPIRY"`
+	EmailVerificationExpiry string `mapstructure:"EMAIL_VERIFICATION_EXPIRY"`
+
+	// How long the link in a guest booking confirmation keeps working
+	GuestLinkExpiry string `mapstructure:"GUEST_LINK_EXPIRY"`
+
+	// How many days a sent quote can be accepted for
+	QuoteExpiryDays int `mapstructure:"QUOTE_EXPIRY_DAYS"`
+
+	// Printed on every invoice
+	FloridaTaxID string `mapstructure:"FLORIDA_TAX_ID"`
+
+	// Online invoice payments are disabled unless a gateway is configured
+	PaymentGateway  string `mapstructure:"PAYMENT_GATEWAY"`
+	StripeSecretKey string `mapstructure:"STRIPE_SECRET_KEY"`
+
+	// "release" in production
+	GinMode string `mapstructure:"GIN_MODE"`
+
+	// How often recurring booking series are extended
+	SeriesJobInterval string `mapstructure:"SERIES_JOB_INTERVAL"`
+
+	// How often past-due invoices are marked overdue and billed late fees
+	InvoiceJobInterval string `mapstructure:"INVOICE_JOB_INTERVAL"`
+
+	// How often sent quotes past their expiry are marked expired
+	QuoteJobInterval string `mapstructure:"QUOTE_JOB_INTERVAL"`
+
+	// Late fee per month on past due amounts, in percent; 0 bills none
+	LateFeePercent float64 `mapstructure:"LATE_FEE_PERCENT"`
 }
 
 func LoadConfig() (config Config, err error) {
@@ -29,6 +71,18 @@ func LoadConfig() (config Config, err error) {
 	config.DBSSLMode = "require"
 	config.JWTSecret = "supersecretkeyfordevelopment"
 	config.JWTExpiry = "24h"
+	config.RefreshTokenExpiry = "168h" // 7 days
+	config.AppURL = "http://localhost:3000"
+	config.SMTPPort = "587"
+	config.SMTPFrom = "no-reply@premierprime.org"
+	config.PasswordResetExpiry = "1h"
+	config.EmailVerificationExpiry = "48h"
+	config.GuestLinkExpiry = "2160h" // 90 days
+	config.QuoteExpiryDays = 30
+	config.FloridaTaxID = "92-396658"
+	config.InvoiceJobInterval = "24h"
+	config.SeriesJobInterval = "24h"
+	config.QuoteJobInterval = "1h"
 	
 	viper.AutomaticEnv() // Use environment variables
 	
@@ -60,6 +114,63 @@ func LoadConfig() (config Config, err error) {
 	if expiry := viper.GetString("JWT_EXPIRY"); expiry != "" {
 		config.JWTExpiry = expiry
 	}
+	if expiry := viper.GetString("REFRESH_TOKEN_EXPIRY"); expiry != "" {
+		config.RefreshTokenExpiry = expiry
+	}
+	if appURL := viper.GetString("APP_URL"); appURL != "" {
+		config.AppURL = appURL
+	}
+	if smtpHost := viper.GetString("SMTP_HOST"); smtpHost != "" {
+		config.SMTPHost = smtpHost
+	}
+	if smtpPort := viper.GetString("SMTP_PORT"); smtpPort != "" {
+		config.SMTPPort = smtpPort
+	}
+	if smtpUser := viper.GetString("SMTP_USERNAME"); smtpUser != "" {
+		config.SMTPUsername = smtpUser
+	}
+	if smtpPassword := viper.GetString("SMTP_PASSWORD"); smtpPassword != "" {
+		config.SMTPPassword = smtpPassword
+	}
+	if smtpFrom := viper.GetString("SMTP_FROM"); smtpFrom != "" {
+		config.SMTPFrom = smtpFrom
+	}
+	if expiry := viper.GetString("PASSWORD_RESET_EXPIRY"); expiry != "" {
+		config.PasswordResetExpiry = expiry
+	}
+	if expiry := viper.GetString("EMAIL_VERIFICATION_EXPIRY"); expiry != "" {
+		config.EmailVerificationExpiry = expiry
+	}
+	if expiry := viper.GetString("GUEST_LINK_EXPIRY"); expiry != "" {
+		config.GuestLinkExpiry = expiry
+	}
+	if days := viper.GetInt("QUOTE_EXPIRY_DAYS"); days > 0 {
+		config.QuoteExpiryDays = days
+	}
+	if taxID := viper.GetString("FLORIDA_TAX_ID"); taxID != "" {
+		config.FloridaTaxID = taxID
+	}
+	if gateway := viper.GetString("PAYMENT_GATEWAY"); gateway != "" {
+		config.PaymentGateway = gateway
+	}
+	if key := viper.GetString("STRIPE_SECRET_KEY"); key != "" {
+		config.StripeSecretKey = key
+	}
+	if mode := viper.GetString("GIN_MODE"); mode != "" {
+		config.GinMode = mode
+	}
+	if interval := viper.GetString("SERIES_JOB_INTERVAL"); interval != "" {
+		config.SeriesJobInterval = interval
+	}
+	if interval := viper.GetString("INVOICE_JOB_INTERVAL"); interval != "" {
+		config.InvoiceJobInterval = interval
+	}
+	if interval := viper.GetString("QUOTE_JOB_INTERVAL"); interval != "" {
+		config.QuoteJobInterval = interval
+	}
+	if percent := viper.GetFloat64("LATE_FEE_PERCENT"); percent > 0 {
+		config.LateFeePercent = percent
+	}
 	
 	return config, nil
 }
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+	"cleaning-app-backend/internal/services"
+)
+
+// ForgotPassword mails a password reset link. The response is the same whether
+// or not the email belongs to an account.
+func ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return
+	}
+
+	accountService := services.NewAccountService(config)
+	if err := accountService.RequestPasswordReset(req.Email); err != nil {
+		log.Printf("Error requesting password reset for %s: %v", req.Email, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for that email, a password reset link has been sent."})
+}
+
+// VerifyPasswordResetToken reports whether a reset token is still usable
+func VerifyPasswordResetToken(c *gin.Context) {
+	var req models.TokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return
+	}
+
+	accountService := services.NewAccountService(config)
+	if err := accountService.VerifyPasswordResetToken(req.Token); err != nil {
+		if err.Error() == "invalid or expired token" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "valid": false})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
+
+// ResetPassword sets a new password using a reset token
+func ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if len(req.Password) < 6 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at least 6 characters"})
+		return
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return
+	}
+
+	accountService := services.NewAccountService(config)
+	if err := accountService.ResetPassword(req.Token, req.Password); err != nil {
+		if err.Error() == "invalid or expired token" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset. Please log in with your new password."})
+}
+
+// VerifyEmail confirms a user's email address using a verification token
+func VerifyEmail(c *gin.Context) {
+	var req models.TokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return
+	}
+
+	accountService := services.NewAccountService(config)
+	userID, err := accountService.VerifyEmail(req.Token)
+	if err != nil {
+		if err.Error() == "invalid or expired token" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	// Guest bookings made with the now verified email move into the account.
+	// The email is verified either way, so a failed claim is only logged; the
+	// client can claim again later.
+	response := gin.H{"message": "Email verified successfully"}
+	claim, err := services.NewGuestClaimService().ClaimVerifiedEmail(userID)
+	if err != nil {
+		if err.Error() != "user is not a client" {
+			log.Printf("Failed to claim guest history for user %d: %v", userID, err)
+		}
+	} else {
+		response["guest_history"] = claim
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ClaimGuestHistory links guest bookings, quotes and invoices made with the
+// current user's verified email to their account
+func ClaimGuestHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	claim, err := services.NewGuestClaimService().ClaimVerifiedEmail(userID.(int))
+	if err != nil {
+		switch err.Error() {
+		case "email not verified":
+			c.JSON(http.StatusForbidden, gin.H{"error": "Verify your email address before claiming guest bookings"})
+		case "user is not a client":
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only client accounts can claim guest bookings"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim guest history"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"guest_history": claim})
+}
+
+// ResendEmailVerification mails a new verification link to the current user
+func ResendEmailVerification(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return
+	}
+
+	accountService := services.NewAccountService(config)
+	if err := accountService.SendEmailVerification(userID.(int)); err != nil {
+		if err.Error() == "email already verified" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// GetMyQuotes lists the current client's quotes, including claimed ones
+func GetMyQuotes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	quotes, err := services.NewQuoteService().GetQuotesByUserID(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quotes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quotes": quotes})
+}
+
+// GetMyInvoices lists the current client's invoices, including claimed ones
+func GetMyInvoices(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	invoices, err := invoiceService.GetInvoicesByUserID(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invoices": invoices})
+}
+
+// GetMyInvoicePDF downloads one of the client's invoices
+func GetMyInvoicePDF(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	invoice, doc, err := invoiceService.ClientInvoicePDF(id, c.GetInt("user_id"))
+	if err != nil {
+		if err.Error() == "invoice not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+			return
+		}
+		log.Printf("Failed to render invoice %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render invoice"})
+		return
+	}
+
+	writeInvoicePDF(c, invoice, doc)
+}
+
+// GetMyCreditNotePDF downloads a credit note of one of the client's invoices
+func GetMyCreditNotePDF(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credit note ID"})
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	note, doc, err := invoiceService.ClientCreditNotePDF(id, c.GetInt("user_id"))
+	if err != nil {
+		if err.Error() == "credit note not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credit note not found"})
+			return
+		}
+		log.Printf("Failed to render credit note %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render credit note"})
+		return
+	}
+
+	writeCreditNotePDF(c, note, doc)
+}
@@ -30,34 +30,82 @@ func AdminUpdateBooking(c *gin.Context) {
 	}
 
 	var req models.AdminBookingUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	// Validate status if provided
-	if req.Status != "" {
-		validStatuses := map[string]bool{
-			"pending":     true,
-			"confirmed":   true,
-			"in_progress": true,
-			"completed":   true,
-			"cancelled":   true,
+	bookingService := services.NewBookingService()
+	fee, err := bookingService.AdminUpdateBooking(id, &req, statusActor(c))
+	if err != nil {
+		if conflictErr, ok := err.(*services.AssignmentConflictError); ok {
+			c.JSON(http.StatusConflict, gin.H{"error": conflictErr.Error(), "conflicts": conflictErr.Conflicts})
+			return
 		}
-		if !validStatuses[req.Status] {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		switch err.Error() {
+		case "invalid status transition":
+			c.JSON(http.StatusConflict, gin.H{"error": "Booking cannot change to " + req.Status + " from its current status"})
+			return
+		case "time slot unavailable", "booking can no longer be rescheduled", "invalid date format. Use YYYY-MM-DD",
+			"invalid time format. Use HH:MM", "cannot reschedule into the past", "outside business hours", "closed on this date":
+			writeRescheduleError(c, err)
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking", "details": err.Error()})
+		return
 	}
 
-	bookingService := services.NewBookingService()
-	err = bookingService.AdminUpdateBooking(id, &req)
+	response := gin.H{"message": "Booking updated successfully"}
+	if fee != nil {
+		response["no_show_fee"] = fee
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// MergeGuestHistory links the guest bookings, quotes and invoices made with
+// an email to a client account, for clients who booked with another address
+func MergeGuestHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.GuestMergeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claim, err := services.NewGuestClaimService().MergeGuestHistory(id, req.Email, c.GetInt("user_id"))
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "user is not a client":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Guest history can only be merged into client accounts"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge guest history"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"guest_history": claim})
+}
+
+// GetGuestClaims lists the guest history claimed by or merged into an account
+func GetGuestClaims(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	claims, err := services.NewGuestClaimService().GetClaims(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guest claims"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Booking updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"claims": claims})
 }
 
 // Quote management handlers
@@ -80,20 +128,19 @@ func UpdateQuote(c *gin.Context) {
 	}
 
 	var req struct {
-		EstimatedPrice float64 `json:"estimated_price"`
-		Status         string  `json:"status" validate:"oneof=pending sent accepted rejected"`
+		EstimatedPrice float64 `json:"estimated_price" validate:"gte=0"`
+		Status         string  `json:"status" validate:"required,oneof=pending sent accepted rejected expired"`
 		AdminNotes     string  `json:"admin_notes"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	quoteService := services.NewQuoteService()
 	err = quoteService.UpdateQuote(id, req.EstimatedPrice, req.Status, req.AdminNotes)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update quote"})
+		writeQuoteError(c, err, "Failed to update quote")
 		return
 	}
 
@@ -120,8 +167,7 @@ func UpdateContactMessage(c *gin.Context) {
 	}
 
 	var req models.ContactMessageUpdate
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -138,8 +184,7 @@ func UpdateContactMessage(c *gin.Context) {
 // FAQ management handlers
 func CreateFAQ(c *gin.Context) {
 	var req models.FAQRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -161,8 +206,7 @@ func UpdateFAQ(c *gin.Context) {
 	}
 
 	var req models.FAQRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -176,6 +220,22 @@ func UpdateFAQ(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "FAQ updated successfully"})
 }
 
+// GetAllFAQs lists FAQs for admins. Archived FAQs are left out unless
+// include_archived=true.
+func GetAllFAQs(c *gin.Context) {
+	includeArchived := c.Query("include_archived") == "true"
+
+	contactService := services.NewContactService()
+	faqs, err := contactService.GetAllFAQs(includeArchived)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve FAQs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"faqs": faqs})
+}
+
+// DeleteFAQ archives an FAQ; RestoreFAQ brings it back
 func DeleteFAQ(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -184,11 +244,36 @@ func DeleteFAQ(c *gin.Context) {
 	}
 
 	contactService := services.NewContactService()
-	err = contactService.DeleteFAQ(id)
+	err = contactService.ArchiveFAQ(id)
+	if err != nil {
+		writeFAQError(c, err, "Failed to delete FAQ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "FAQ archived successfully"})
+}
+
+func RestoreFAQ(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid FAQ ID"})
+		return
+	}
+
+	contactService := services.NewContactService()
+	err = contactService.RestoreFAQ(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete FAQ"})
+		writeFAQError(c, err, "Failed to restore FAQ")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "FAQ deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "FAQ restored successfully"})
+}
+
+func writeFAQError(c *gin.Context, err error, fallback string) {
+	if err.Error() == "FAQ not found" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "FAQ not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
 }
\ No newline at end of file
@@ -1,19 +1,18 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"cleaning-app-backend/internal/config"
 	"cleaning-app-backend/internal/models"
 	"cleaning-app-backend/internal/services"
-	"cleaning-app-backend/internal/utils"
 )
 
 func Register(c *gin.Context) {
 	var req models.UserRegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -34,30 +33,30 @@ func Register(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
 		return
 	}
-	
-	accessToken, err := utils.GenerateToken(user.ID, user.Role, config)
+
+	tokenService := services.NewTokenService(config)
+	tokens, err := tokenService.IssueTokens(user.ID, user.Role)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user.ID, config)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
-		return
+	// The account is usable right away; verification only confirms the address
+	accountService := services.NewAccountService(config)
+	if err := accountService.SendEmailVerification(user.ID); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"user":          user,
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
 	})
 }
 
 func Login(c *gin.Context) {
 	var req models.UserLoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -74,30 +73,80 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
 		return
 	}
-	
-	accessToken, err := utils.GenerateToken(user.ID, user.Role, config)
+
+	tokenService := services.NewTokenService(config)
+	tokens, err := tokenService.IssueTokens(user.ID, user.Role)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user.ID, config)
+	c.JSON(http.StatusOK, gin.H{
+		"user":          user,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+	})
+}
+
+// RefreshToken rotates a refresh token and returns a fresh token pair
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return
+	}
+
+	tokenService := services.NewTokenService(config)
+	tokens, err := tokenService.RefreshTokens(req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		switch err.Error() {
+		case "invalid refresh token", "refresh token expired", "refresh token reuse detected":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"user":          user,
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
 	})
 }
 
-func RefreshToken(c *gin.Context) {
-	// Note: In a real application, you would store refresh tokens in a secure store
-	// and implement proper refresh token rotation
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "Refresh token functionality not fully implemented in this example"})
+// Logout revokes the refresh token family the given token belongs to
+func Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return
+	}
+
+	tokenService := services.NewTokenService(config)
+	if err := tokenService.RevokeFamily(req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
 func GetProfile(c *gin.Context) {
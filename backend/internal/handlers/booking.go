@@ -52,8 +52,7 @@ func GetBooking(c *gin.Context) {
 
 func CreateBooking(c *gin.Context) {
 	var req models.BookingRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -69,9 +68,23 @@ func CreateBooking(c *gin.Context) {
 	bookingService := services.NewBookingService()
 	booking, err := bookingService.CreateBooking(&req)
 	if err != nil {
-		if err.Error() == "service not found" {
+		if isPricingError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		switch err.Error() {
+		case "service not found", "outside business hours", "closed on this date", "invalid time format. Use HH:MM", "invalid date format. Use YYYY-MM-DD":
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
+		case "property not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+			return
+		case "square meters required":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The property has no size on file; send square_meters"})
+			return
+		case "time slot unavailable":
+			c.JSON(http.StatusConflict, gin.H{"error": "This time slot is no longer available. Please choose another time."})
+			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create booking"})
 		return
@@ -87,16 +100,8 @@ func UpdateBooking(c *gin.Context) {
 		return
 	}
 
-	// Check if booking belongs to user
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
-		return
-	}
-
 	var req models.BookingUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -106,49 +111,124 @@ func UpdateBooking(c *gin.Context) {
 		return
 	}
 
-	// In a real application, we would check if booking belongs to user
-	// But for this example, we'll proceed with the update
+	bookingService := services.NewBookingService()
+	fee, err := bookingService.CancelBooking(id, statusActor(c), req.Reason, false)
+	if err != nil {
+		writeStatusChangeError(c, err, "Failed to update booking")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking updated successfully", "cancellation_fee": fee})
+}
 
-	booking := &models.Booking{
-		ID:     id,
-		Status: req.Status,
+func CancelBooking(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
 	}
 
+	// Admins cancelling on the customer's behalf may waive the fee
+	waiveFee := c.Query("waive_fee") == "true"
+
 	bookingService := services.NewBookingService()
-	err = bookingService.UpdateBooking(booking, id, userID.(int))
+	fee, err := bookingService.CancelBooking(id, statusActor(c), c.Query("reason"), waiveFee)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking"})
+		writeStatusChangeError(c, err, "Failed to cancel booking")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Booking updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully", "cancellation_fee": fee})
 }
 
-func CancelBooking(c *gin.Context) {
+// RescheduleMyBooking moves one of the client's own bookings to a new slot
+func RescheduleMyBooking(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
 		return
 	}
 
-	// Get user ID from context to verify booking ownership
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+	var req models.BookingRescheduleRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	booking := &models.Booking{
-		ID:     id,
-		Status: "cancelled",
+	bookingService := services.NewBookingService()
+	if err := bookingService.RescheduleBooking(id, &req, statusActor(c)); err != nil {
+		writeRescheduleError(c, err)
+		return
 	}
 
-	bookingService := services.NewBookingService()
-	err = bookingService.UpdateBooking(booking, id, userID.(int))
+	c.JSON(http.StatusOK, gin.H{"message": "Booking rescheduled successfully"})
+}
+
+func writeRescheduleError(c *gin.Context, err error) {
+	if conflictErr, ok := err.(*services.AssignmentConflictError); ok {
+		c.JSON(http.StatusConflict, gin.H{"error": conflictErr.Error(), "conflicts": conflictErr.Conflicts})
+		return
+	}
+
+	switch err.Error() {
+	case "booking not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+	case "time slot unavailable":
+		c.JSON(http.StatusConflict, gin.H{"error": "This time slot is no longer available. Please choose another time."})
+	case "too late to reschedule":
+		c.JSON(http.StatusConflict, gin.H{"error": "It is too late to reschedule this booking online. Please contact us."})
+	case "booking can no longer be rescheduled":
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending and confirmed bookings can be rescheduled"})
+	case "invalid date format. Use YYYY-MM-DD", "invalid time format. Use HH:MM", "cannot reschedule into the past",
+		"outside business hours", "closed on this date":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule booking"})
+	}
+}
+
+// statusActor identifies the authenticated user for the booking status history
+func statusActor(c *gin.Context) models.StatusActor {
+	actor := models.StatusActor{Role: c.GetString("user_role")}
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(int)
+		actor.UserID = &id
+	}
+	return actor
+}
+
+func writeStatusChangeError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "booking not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+	case "invalid status transition":
+		c.JSON(http.StatusConflict, gin.H{"error": "Booking cannot change to this status from its current status"})
+	case "invalid status":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GetBookingHistory returns every status change and reschedule of a booking
+func GetBookingHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	statusService := services.NewBookingStatusService()
+	history, err := statusService.GetHistory(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve booking history"})
+		return
+	}
+
+	reschedules, err := services.NewBookingService().GetReschedules(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel booking"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve booking history"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully"})
+	c.JSON(http.StatusOK, gin.H{"history": history, "reschedules": reschedules})
 }
@@ -43,8 +43,13 @@ func GetCalendarEvents(c *gin.Context) {
 		endDate = startDate.AddDate(0, 1, -1) // Last day of the month
 	}
 
+	filter, ok := parseCalendarFilter(c)
+	if !ok {
+		return
+	}
+
 	calendarService := services.NewCalendarService()
-	events, err := calendarService.GetCalendarEvents(startDate, endDate)
+	events, err := calendarService.GetCalendarEvents(startDate, endDate, filter)
 	if err != nil {
 		log.Printf("Error retrieving calendar events for %s to %s: %v", startDateStr, endDateStr, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve calendar events"})
@@ -54,6 +59,31 @@ func GetCalendarEvents(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"events": events})
 }
 
+// parseCalendarFilter reads the optional assignee_id and crew_id query
+// parameters. It writes the error response itself and reports false on bad input.
+func parseCalendarFilter(c *gin.Context) (services.CalendarFilter, bool) {
+	var filter services.CalendarFilter
+	var err error
+
+	if assigneeID := c.Query("assignee_id"); assigneeID != "" {
+		filter.AssigneeID, err = strconv.Atoi(assigneeID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignee ID"})
+			return filter, false
+		}
+	}
+
+	if crewID := c.Query("crew_id"); crewID != "" {
+		filter.CrewID, err = strconv.Atoi(crewID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crew ID"})
+			return filter, false
+		}
+	}
+
+	return filter, true
+}
+
 // GetDaySchedule returns detailed schedule for a specific day
 func GetDaySchedule(c *gin.Context) {
 	dateStr := c.Param("date")
@@ -63,8 +93,13 @@ func GetDaySchedule(c *gin.Context) {
 		return
 	}
 
+	filter, ok := parseCalendarFilter(c)
+	if !ok {
+		return
+	}
+
 	calendarService := services.NewCalendarService()
-	schedule, err := calendarService.GetDaySchedule(date)
+	schedule, err := calendarService.GetDaySchedule(date, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve day schedule"})
 		return
@@ -100,9 +135,22 @@ func GetAvailableSlots(c *gin.Context) {
 		}
 	}
 
+	var squareMeters float64
+	if squareMetersStr := c.Query("square_meters"); squareMetersStr != "" {
+		squareMeters, err = strconv.ParseFloat(squareMetersStr, 64)
+		if err != nil || squareMeters <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid square meters"})
+			return
+		}
+	}
+
 	calendarService := services.NewCalendarService()
-	slots, err := calendarService.GetAvailableSlots(date, serviceID)
+	slots, err := calendarService.GetAvailableSlots(date, serviceID, squareMeters)
 	if err != nil {
+		if err.Error() == "service not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Service not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve available slots"})
 		return
 	}
@@ -136,20 +184,19 @@ func RescheduleBooking(c *gin.Context) {
 	}
 
 	var req struct {
-		NewDate string `json:"new_date" validate:"required"`
-		NewTime string `json:"new_time" validate:"required"`
+		NewDate string `json:"new_date" validate:"required,date,notpast"`
+		NewTime string `json:"new_time" validate:"required,clock"`
 		Reason  string `json:"reason"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	calendarService := services.NewCalendarService()
-	err = calendarService.RescheduleBooking(bookingID, req.NewDate, req.NewTime, req.Reason)
+	err = calendarService.RescheduleBooking(bookingID, req.NewDate, req.NewTime, req.Reason, statusActor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule booking"})
+		writeRescheduleError(c, err)
 		return
 	}
 
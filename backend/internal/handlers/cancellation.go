@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCancellationPolicy returns the free cancellation window and the fees, so
+// customers can see them before booking or cancelling
+func GetCancellationPolicy(c *gin.Context) {
+	policy, err := services.NewCancellationService().GetPolicy()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cancellation policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
+
+// UpdateCancellationPolicy replaces the cancellation policy. Bookings
+// cancelled afterwards are charged under the new terms.
+func UpdateCancellationPolicy(c *gin.Context) {
+	var req models.CancellationPolicyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	policy, err := services.NewCancellationService().UpdatePolicy(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
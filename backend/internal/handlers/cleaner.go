@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyJobs returns the jobs assigned to the current cleaner for a day (default today)
+func GetMyJobs(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	date := time.Now()
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	cleanerService := services.NewCleanerService()
+	jobs, err := cleanerService.GetJobsForDate(userID.(int), date)
+	if err != nil {
+		log.Printf("Error retrieving jobs for cleaner %d: %v", userID.(int), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"date": date.Format("2006-01-02"), "jobs": jobs})
+}
+
+// GetMyJob returns the details of a job assigned to the current cleaner
+func GetMyJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	cleanerService := services.NewCleanerService()
+	job, err := cleanerService.GetJob(userID.(int), id)
+	if err != nil {
+		if err.Error() == "job not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// UpdateMyJobStatus lets a cleaner start or complete an assigned job
+func UpdateMyJobStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.CleanerJobStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	cleanerService := services.NewCleanerService()
+	job, err := cleanerService.UpdateJobStatus(userID.(int), id, req.Status)
+	if err != nil {
+		switch err.Error() {
+		case "invalid status":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be in_progress or completed"})
+		case "job not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		case "invalid status transition":
+			c.JSON(http.StatusConflict, gin.H{"error": "Job cannot move to " + req.Status + " from its current status"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update job status"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// GetCleaners lists staff accounts with the cleaner role
+func GetCleaners(c *gin.Context) {
+	cleanerService := services.NewCleanerService()
+	cleaners, err := cleanerService.GetCleaners()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cleaners"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cleaners": cleaners})
+}
+
+// CreateCleaner creates a staff login with the cleaner role
+func CreateCleaner(c *gin.Context) {
+	var req models.CleanerCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	cleanerService := services.NewCleanerService()
+	cleaner, err := cleanerService.CreateCleaner(&req)
+	if err != nil {
+		if err.Error() == "user with this email already exists" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cleaner"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"cleaner": cleaner})
+}
+
+// GetBookingAssignees lists the cleaners assigned to a booking
+func GetBookingAssignees(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	cleanerService := services.NewCleanerService()
+	assignees, err := cleanerService.GetAssignees(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve assignees"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assignees": assignees})
+}
+
+// AssignBookingCleaners sets which cleaners work a booking, replacing any
+// previous assignment
+func AssignBookingCleaners(c *gin.Context) {
+	updateBookingAssignees(c, false)
+}
+
+// AddBookingCleaners assigns more cleaners to a booking
+func AddBookingCleaners(c *gin.Context) {
+	updateBookingAssignees(c, true)
+}
+
+func updateBookingAssignees(c *gin.Context, add bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.BookingAssignmentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	cleanerService := services.NewCleanerService()
+	var assignees []models.Assignee
+	if add {
+		assignees, err = cleanerService.AddCleaners(id, &req, adminID.(int))
+	} else {
+		assignees, err = cleanerService.AssignCleaners(id, &req, adminID.(int))
+	}
+	if err != nil {
+		if conflictErr, ok := err.(*services.AssignmentConflictError); ok {
+			c.JSON(http.StatusConflict, gin.H{"error": conflictErr.Error(), "conflicts": conflictErr.Conflicts})
+			return
+		}
+		switch err.Error() {
+		case "booking not found", "crew not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "assignees must be cleaners", "crew is inactive", "no assignees given":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Error assigning cleaners to booking %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign cleaners"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cleaners assigned successfully", "assignees": assignees})
+}
+
+// RemoveBookingCleaner unassigns a cleaner from a booking
+func RemoveBookingCleaner(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	cleanerService := services.NewCleanerService()
+	if err := cleanerService.RemoveCleaner(id, userID); err != nil {
+		if err.Error() == "assignment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove cleaner"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cleaner removed from booking"})
+}
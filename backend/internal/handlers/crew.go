@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetCrews(c *gin.Context) {
+	crewService := services.NewCrewService()
+	crews, err := crewService.GetAllCrews()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve crews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"crews": crews})
+}
+
+func CreateCrew(c *gin.Context) {
+	var req models.CrewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Crew name is required"})
+		return
+	}
+
+	crewService := services.NewCrewService()
+	crew, err := crewService.CreateCrew(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"crew": crew})
+}
+
+func UpdateCrew(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crew ID"})
+		return
+	}
+
+	var req models.CrewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Crew name is required"})
+		return
+	}
+
+	crewService := services.NewCrewService()
+	crew, err := crewService.UpdateCrew(id, &req)
+	if err != nil {
+		if err.Error() == "crew not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Crew not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"crew": crew})
+}
+
+func DeleteCrew(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crew ID"})
+		return
+	}
+
+	crewService := services.NewCrewService()
+	if err := crewService.DeleteCrew(id); err != nil {
+		if err.Error() == "crew not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Crew not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete crew"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Crew deleted successfully"})
+}
+
+// SetCrewMembers replaces the cleaners in a crew
+func SetCrewMembers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crew ID"})
+		return
+	}
+
+	var req models.CrewMembersRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	crewService := services.NewCrewService()
+	crew, err := crewService.SetCrewMembers(id, req.UserIDs)
+	if err != nil {
+		switch err.Error() {
+		case "crew not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Crew not found"})
+		case "crew members must be cleaners":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"crew": crew})
+}
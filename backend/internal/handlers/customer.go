@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchCustomers lists customers matching ?q (name, company, email or phone
+// of the customer or one of its contacts) and ?tag
+func SearchCustomers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	customers, total, err := services.NewCustomerService().SearchCustomers(c.Query("q"), c.Query("tag"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search customers", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"customers": customers,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	})
+}
+
+func GetCustomer(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+
+	customer, err := services.NewCustomerService().GetCustomer(id)
+	if err != nil {
+		writeCustomerError(c, err, "Failed to retrieve customer")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"customer": customer})
+}
+
+func CreateCustomer(c *gin.Context) {
+	var req models.CustomerRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	customer, err := services.NewCustomerService().CreateCustomer(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"customer": customer})
+}
+
+func UpdateCustomer(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomerRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	customer, err := services.NewCustomerService().UpdateCustomer(id, &req)
+	if err != nil {
+		writeCustomerError(c, err, "Failed to update customer")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"customer": customer})
+}
+
+func AddCustomerContact(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomerContactRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	contact, err := services.NewCustomerService().AddContact(id, &req)
+	if err != nil {
+		writeCustomerError(c, err, "Failed to add contact")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"contact": contact})
+}
+
+func DeleteCustomerContact(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+	contactID, err := strconv.Atoi(c.Param("contact_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	if err := services.NewCustomerService().DeleteContact(id, contactID); err != nil {
+		writeCustomerError(c, err, "Failed to delete contact")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contact deleted successfully"})
+}
+
+func AddCustomerProperty(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomerPropertyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	property, err := services.NewPropertyService().AddProperty(id, &req)
+	if err != nil {
+		writeCustomerError(c, err, "Failed to add property")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"property": property})
+}
+
+func UpdateCustomerProperty(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+	propertyID, err := strconv.Atoi(c.Param("property_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid property ID"})
+		return
+	}
+
+	var req models.CustomerPropertyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	property, err := services.NewPropertyService().UpdateProperty(id, propertyID, &req)
+	if err != nil {
+		writeCustomerError(c, err, "Failed to update property")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"property": property})
+}
+
+func DeleteCustomerProperty(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+	propertyID, err := strconv.Atoi(c.Param("property_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid property ID"})
+		return
+	}
+
+	if err := services.NewPropertyService().DeleteProperty(id, propertyID); err != nil {
+		writeCustomerError(c, err, "Failed to delete property")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Property deleted successfully"})
+}
+
+func AddCustomerNote(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomerNoteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	note, err := services.NewCustomerService().AddNote(id, req.Body, c.GetInt("user_id"))
+	if err != nil {
+		writeCustomerError(c, err, "Failed to add note")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"note": note})
+}
+
+// GetCustomerTimeline lists everything that happened with a customer, newest
+// first: bookings and their status changes, quotes, invoices, payments,
+// messages and notes
+func GetCustomerTimeline(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	events, err := services.NewCustomerService().GetTimeline(id, limit)
+	if err != nil {
+		writeCustomerError(c, err, "Failed to retrieve timeline")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"timeline": events})
+}
+
+// GetCustomerCredit returns a customer's credit from overpaid invoices and
+// how it was earned and spent
+func GetCustomerCredit(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+
+	entries, balance, err := services.NewCustomerService().GetCredit(id)
+	if err != nil {
+		writeCustomerError(c, err, "Failed to retrieve credit")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credit": balance, "entries": entries})
+}
+
+// MergeCustomer folds a duplicate customer into the one in the URL
+func MergeCustomer(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomerMergeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	customer, err := services.NewCustomerService().MergeCustomers(id, req.DuplicateID, c.GetInt("user_id"))
+	if err != nil {
+		writeCustomerError(c, err, "Failed to merge customers")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Customers merged successfully", "customer": customer})
+}
+
+func customerID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeCustomerError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "customer not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+	case "contact not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+	case "property not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+	case "cannot merge a customer into itself":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge a customer into itself"})
+	case "customer already merged":
+		c.JSON(http.StatusConflict, gin.H{"error": "Customer has been merged into another customer"})
+	case "both customers have accounts":
+		c.JSON(http.StatusConflict, gin.H{"error": "Both customers have client accounts and cannot be merged"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback, "details": err.Error()})
+	}
+}
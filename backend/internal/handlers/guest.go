@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 
+	"cleaning-app-backend/internal/config"
 	"cleaning-app-backend/internal/models"
 	"cleaning-app-backend/internal/services"
 
@@ -13,14 +15,25 @@ import (
 // CreateGuestBooking allows guests to book without registration
 func CreateGuestBooking(c *gin.Context) {
 	var req models.GuestBookingRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	bookingService := services.NewBookingService()
 	booking, err := bookingService.CreateGuestBooking(&req)
 	if err != nil {
+		if isPricingError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		switch err.Error() {
+		case "service not found", "outside business hours", "closed on this date", "invalid time format. Use HH:MM", "invalid date format. Use YYYY-MM-DD":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case "time slot unavailable":
+			c.JSON(http.StatusConflict, gin.H{"error": "This time slot is no longer available. Please choose another time."})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create booking",
 			"details": err.Error(),
@@ -28,47 +41,72 @@ func CreateGuestBooking(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	// The booking stands even if the confirmation can't be sent; the guest
+	// can ask for the link again
+	response := gin.H{
 		"booking": booking,
 		"message": "Booking created successfully! You will receive a confirmation email shortly.",
-	})
+	}
+	config, err := config.LoadConfig()
+	if err != nil {
+		log.Printf("Failed to load config for booking %d confirmation: %v", booking.ID, err)
+	} else {
+		portalService := services.NewGuestPortalService(config)
+		if err := portalService.SendConfirmation(booking); err != nil {
+			log.Printf("Failed to send confirmation for booking %d: %v", booking.ID, err)
+		}
+		if token, err := portalService.IssueToken(booking.ID, booking.GuestEmail); err == nil {
+			response["access_token"] = token
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
 }
 
-// GetGuestBooking allows guests to check their booking status using email and booking ID
-func GetGuestBooking(c *gin.Context) {
+// ResendGuestLink mails a new portal link to the guest who made a booking.
+// The response is the same whether or not the email matches, so it can't be
+// used to find bookings.
+func ResendGuestLink(c *gin.Context) {
 	bookingID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
 		return
 	}
 
-	email := c.Query("email")
-	if email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email parameter is required"})
+	var req models.GuestLinkRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	bookingService := services.NewBookingService()
-	booking, err := bookingService.GetGuestBooking(bookingID, email)
+	config, err := config.LoadConfig()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"booking": booking})
+	if err := services.NewGuestPortalService(config).ResendLink(bookingID, req.Email); err != nil {
+		log.Printf("Failed to send guest link for booking %d: %v", bookingID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the email matches the booking, a new link is on its way."})
 }
 
 // RequestQuote allows anyone to request a quote without booking
 func RequestQuote(c *gin.Context) {
 	var req models.QuoteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	quoteService := services.NewQuoteService()
 	quote, err := quoteService.CreateQuote(&req)
 	if err != nil {
+		if isPricingError(err) || err.Error() == "service not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create quote request",
 			"details": err.Error(),
@@ -82,24 +120,20 @@ func RequestQuote(c *gin.Context) {
 	})
 }
 
-// GetQuoteEstimate provides instant price estimation
+// GetQuoteEstimate provides instant price estimation, itemized
 func GetQuoteEstimate(c *gin.Context) {
-	serviceID, err := strconv.Atoi(c.Query("service_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
-		return
-	}
-
-	squareMetersStr := c.Query("square_meters")
-	squareMeters, err := strconv.ParseFloat(squareMetersStr, 64)
-	if err != nil || squareMeters <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid square meters"})
+	var req models.EstimateRequest
+	if !bindQuery(c, &req) {
 		return
 	}
 
 	quoteService := services.NewQuoteService()
-	estimate, err := quoteService.GetInstantEstimate(serviceID, squareMeters)
+	breakdown, err := quoteService.GetInstantEstimate(&req)
 	if err != nil {
+		if isPricingError(err) || err.Error() == "service not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to calculate estimate",
 			"details": err.Error(),
@@ -108,7 +142,8 @@ func GetQuoteEstimate(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"estimate": estimate,
+		"estimate":  breakdown.Total,
+		"breakdown": breakdown,
 		"note": "This is an instant estimate. Final price may vary based on specific requirements.",
 	})
 }
@@ -116,8 +151,7 @@ func GetQuoteEstimate(c *gin.Context) {
 // SubmitContactMessage allows anyone to submit questions or feedback
 func SubmitContactMessage(c *gin.Context) {
 	var req models.ContactMessageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
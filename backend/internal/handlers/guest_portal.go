@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/payments"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The guest portal token travels in a header rather than the URL so it stays
+// out of access logs
+const guestTokenHeader = "X-Guest-Token"
+
+// guestPortal authenticates a guest portal request and returns the booking its
+// token grants access to. It writes the error response when it returns false.
+func guestPortal(c *gin.Context) (*services.GuestPortalService, *models.Booking, bool) {
+	token := c.GetHeader(guestTokenHeader)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": guestTokenHeader + " header required"})
+		return nil, nil, false
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return nil, nil, false
+	}
+
+	portalService := services.NewGuestPortalService(config)
+	booking, err := portalService.Authenticate(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "This link is invalid or has expired. Request a new one to manage your booking."})
+		return nil, nil, false
+	}
+
+	return portalService, booking, true
+}
+
+// GetGuestPortalBooking shows the guest their booking
+func GetGuestPortalBooking(c *gin.Context) {
+	portalService, booking, ok := guestPortal(c)
+	if !ok {
+		return
+	}
+
+	bookingResp, err := portalService.GetBooking(booking)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve booking"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"booking": bookingResp})
+}
+
+// RescheduleGuestPortalBooking moves the guest's booking to a new slot
+func RescheduleGuestPortalBooking(c *gin.Context) {
+	portalService, booking, ok := guestPortal(c)
+	if !ok {
+		return
+	}
+
+	var req models.BookingRescheduleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := portalService.Reschedule(booking, &req); err != nil {
+		writeRescheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking rescheduled successfully"})
+}
+
+// CancelGuestPortalBooking cancels the guest's booking. A late cancellation
+// is charged like any other.
+func CancelGuestPortalBooking(c *gin.Context) {
+	portalService, booking, ok := guestPortal(c)
+	if !ok {
+		return
+	}
+
+	fee, err := portalService.Cancel(booking, c.Query("reason"))
+	if err != nil {
+		writeStatusChangeError(c, err, "Failed to cancel booking")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully", "cancellation_fee": fee})
+}
+
+// guestPortalInvoice resolves the invoice a guest portal request is about:
+// the one named by the :id parameter, which must be billed for the guest's
+// booking, or else the booking's own invoice. It writes the error response
+// when it returns false.
+func guestPortalInvoice(c *gin.Context, portalService *services.GuestPortalService, booking *models.Booking) (*models.InvoiceResponse, bool) {
+	var invoice *models.InvoiceResponse
+	var err error
+	if idStr := c.Param("id"); idStr != "" {
+		id, convErr := strconv.Atoi(idStr)
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+			return nil, false
+		}
+		invoice, err = portalService.GetBookingInvoice(booking, id)
+	} else {
+		invoice, err = portalService.GetInvoice(booking)
+	}
+
+	if err != nil {
+		if err.Error() == "invoice not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No invoice has been issued for this booking yet"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invoice"})
+		return nil, false
+	}
+	return invoice, true
+}
+
+// GetGuestPortalInvoices lists every invoice billed for the guest's booking:
+// its own and any cancellation, no-show or late-fee invoices
+func GetGuestPortalInvoices(c *gin.Context) {
+	portalService, booking, ok := guestPortal(c)
+	if !ok {
+		return
+	}
+
+	invoices, err := portalService.GetInvoices(booking)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invoices": invoices})
+}
+
+// GetGuestPortalInvoice returns an invoice of the guest's booking, its own
+// unless another is named
+func GetGuestPortalInvoice(c *gin.Context) {
+	portalService, booking, ok := guestPortal(c)
+	if !ok {
+		return
+	}
+
+	invoice, ok := guestPortalInvoice(c, portalService, booking)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invoice": invoice})
+}
+
+// GetGuestPortalInvoicePDF downloads an invoice of the guest's booking
+func GetGuestPortalInvoicePDF(c *gin.Context) {
+	portalService, booking, ok := guestPortal(c)
+	if !ok {
+		return
+	}
+
+	invoice, ok := guestPortalInvoice(c, portalService, booking)
+	if !ok {
+		return
+	}
+
+	invoice, doc, err := portalService.InvoicePDF(invoice)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render invoice"})
+		return
+	}
+
+	writeInvoicePDF(c, invoice, doc)
+}
+
+// PayGuestPortalInvoice pays an invoice of the guest's booking online
+func PayGuestPortalInvoice(c *gin.Context) {
+	portalService, booking, ok := guestPortal(c)
+	if !ok {
+		return
+	}
+
+	var req models.InvoicePaymentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	invoice, ok := guestPortalInvoice(c, portalService, booking)
+	if !ok {
+		return
+	}
+
+	invoice, err := portalService.PayInvoice(booking, invoice, req.PaymentToken)
+	if err != nil {
+		switch err.Error() {
+		case payments.ErrUnavailable.Error():
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Online payment is not available. Please contact us to pay this invoice."})
+		case "invoice is not payable":
+			c.JSON(http.StatusConflict, gin.H{"error": "This invoice is not open for payment"})
+		case "payment failed":
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "The payment was declined. Please try another payment method."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invoice paid successfully", "invoice": invoice})
+}
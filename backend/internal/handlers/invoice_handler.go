@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -16,8 +18,7 @@ import (
 // CreateInvoice creates a new invoice from a booking
 func CreateInvoice(c *gin.Context) {
 	var request models.InvoiceCreateRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+	if !bindJSON(c, &request) {
 		return
 	}
 
@@ -28,8 +29,12 @@ func CreateInvoice(c *gin.Context) {
 
 	invoice, err := invoiceService.CreateInvoiceFromBooking(request.BookingID, &request)
 	if err != nil {
-		if err.Error() == "invoice already exists for booking ID" {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		switch err.Error() {
+		case "booking not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+			return
+		case "invoice already exists for booking":
+			c.JSON(http.StatusConflict, gin.H{"error": "Invoice already exists for this booking"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invoice", "details": err.Error()})
@@ -123,8 +128,7 @@ func UpdateInvoice(c *gin.Context) {
 	}
 
 	var request models.InvoiceUpdateRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+	if !bindJSON(c, &request) {
 		return
 	}
 
@@ -135,11 +139,7 @@ func UpdateInvoice(c *gin.Context) {
 
 	err = invoiceService.UpdateInvoice(id, &request)
 	if err != nil {
-		if err.Error() == "invoice not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoice", "details": err.Error()})
+		writeInvoiceError(c, err, "Failed to update invoice")
 		return
 	}
 
@@ -160,8 +160,7 @@ func MarkAsPaid(c *gin.Context) {
 		PaymentReference string `json:"payment_reference"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+	if !bindJSON(c, &request) {
 		return
 	}
 
@@ -172,18 +171,218 @@ func MarkAsPaid(c *gin.Context) {
 
 	err = invoiceService.MarkAsPaid(id, request.PaymentMethod, request.PaymentReference)
 	if err != nil {
-		if err.Error() == "invoice not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+		writeInvoiceError(c, err, "Failed to mark invoice as paid")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invoice marked as paid successfully"})
+}
+
+// GetInvoicePayments lists the payments received for an invoice
+func GetInvoicePayments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	payments, err := invoiceService.GetPayments(id)
+	if err != nil {
+		writeInvoiceError(c, err, "Failed to get payments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payments": payments})
+}
+
+// RecordInvoicePayment records a payment of an invoice. An amount over the
+// balance goes to the customer's credit.
+func RecordInvoicePayment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	var request models.PaymentRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	adminID := c.GetInt("user_id")
+	payment, err := invoiceService.RecordPayment(id, &request, &adminID)
+	if err != nil {
+		writeInvoiceError(c, err, "Failed to record payment")
+		return
+	}
+	invoice, err := invoiceService.GetInvoice(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invoice", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"payment": payment, "invoice": invoice})
+}
+
+// VoidInvoice voids an invoice that should not have been issued. The
+// invoice is kept, marked void with the reason.
+func VoidInvoice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	var request models.VoidRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	if err := invoiceService.VoidInvoice(id, request.Reason); err != nil {
+		writeInvoiceError(c, err, "Failed to void invoice")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invoice voided successfully"})
+}
+
+// CreateCreditNote issues a credit note correcting an invoice, in full or
+// for the given lines
+func CreateCreditNote(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	var request models.CreditNoteRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	adminID := c.GetInt("user_id")
+	note, err := invoiceService.CreateCreditNote(id, &request, &adminID)
+	if err != nil {
+		writeInvoiceError(c, err, "Failed to create credit note")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"credit_note": note})
+}
+
+// GetCreditNote retrieves a credit note with its lines
+func GetCreditNote(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credit note ID"})
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	note, err := invoiceService.GetCreditNote(id)
+	if err != nil {
+		writeInvoiceError(c, err, "Failed to get credit note")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credit_note": note})
+}
+
+// GetCreditNotePDF downloads a credit note as a PDF
+func GetCreditNotePDF(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credit note ID"})
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	note, doc, err := invoiceService.CreditNotePDF(id)
+	if err != nil {
+		if err.Error() == "credit note not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credit note not found"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark invoice as paid", "details": err.Error()})
+		log.Printf("Failed to render credit note %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render credit note"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Invoice marked as paid successfully"})
+	writeCreditNotePDF(c, note, doc)
+}
+
+func writeCreditNotePDF(c *gin.Context, note *models.CreditNote, doc *models.CreditNoteDocument) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, note.CreditNoteNumber))
+	c.Header("X-Document-SHA256", doc.SHA256)
+	c.Data(http.StatusOK, "application/pdf", doc.PDF)
 }
 
-// DeleteInvoice deletes an invoice
+// RecordRefund records money given back from a payment
+func RecordRefund(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	var request models.RefundRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	adminID := c.GetInt("user_id")
+	refund, err := invoiceService.RecordRefund(id, &request, &adminID)
+	if err != nil {
+		writeInvoiceError(c, err, "Failed to record refund")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"refund": refund})
+}
+
+func writeInvoiceError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "invoice not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+	case "invoice is not payable":
+		c.JSON(http.StatusConflict, gin.H{"error": "This invoice is not open for payment"})
+	case "invoice has no balance due":
+		c.JSON(http.StatusConflict, gin.H{"error": "This invoice has no balance due"})
+	case "invoice has no customer to credit":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The payment is more than the balance due and the invoice has no customer to credit"})
+	case "credit payment exceeds balance due":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A payment from credit cannot be more than the balance due"})
+	case "insufficient credit":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The customer does not have enough credit"})
+	case "payment not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+	case "refund exceeds payment":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The refund is more than what is left of the payment"})
+	case "credit note not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit note not found"})
+	case "credit exceeds invoice":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The credit note is more than what is left of the invoice to credit"})
+	case "invoice is fully credited":
+		c.JSON(http.StatusConflict, gin.H{"error": "This invoice has been credited in full"})
+	case "invoice is void":
+		c.JSON(http.StatusConflict, gin.H{"error": "This invoice is void"})
+	case "invoice has payments or credit notes":
+		c.JSON(http.StatusConflict, gin.H{"error": "An invoice with payments or credit notes can't be voided; issue a credit note instead"})
+	case "invoice is issued":
+		c.JSON(http.StatusConflict, gin.H{"error": "An issued invoice can't be changed; issue a credit note to correct it"})
+	case "invoice status can't change":
+		c.JSON(http.StatusConflict, gin.H{"error": "The invoice can't change to this status"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback, "details": err.Error()})
+	}
+}
+
+// DeleteInvoice archives an invoice
 func DeleteInvoice(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -197,7 +396,7 @@ func DeleteInvoice(c *gin.Context) {
 	bookingRepo := &repositories.BookingRepository{}
 	invoiceService := services.NewInvoiceService(invoiceRepo, bookingRepo)
 
-	err = invoiceService.DeleteInvoice(id)
+	err = invoiceService.ArchiveInvoice(id)
 	if err != nil {
 		if err.Error() == "invoice not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
@@ -219,9 +418,9 @@ func GenerateInvoiceFromBooking(c *gin.Context) {
 		return
 	}
 
-	var request models.InvoiceCreateRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+	// The booking comes from the URL and doesn't have to be repeated in the body
+	request := models.InvoiceCreateRequest{BookingID: bookingID}
+	if !bindJSON(c, &request) {
 		return
 	}
 
@@ -284,4 +483,35 @@ func GetInvoicesByDateRange(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"invoices": invoices})
-}
\ No newline at end of file
+}
+// GetInvoicePDF downloads an invoice as a PDF
+func GetInvoicePDF(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	invoice, doc, err := invoiceService.InvoicePDF(id)
+	if err != nil {
+		if err.Error() == "invoice not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+			return
+		}
+		log.Printf("Failed to render invoice %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render invoice"})
+		return
+	}
+
+	writeInvoicePDF(c, invoice, doc)
+}
+
+// writeInvoicePDF sends a stored invoice PDF as a download named after the
+// invoice number
+func writeInvoicePDF(c *gin.Context, invoice *models.InvoiceResponse, doc *models.InvoiceDocument) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, invoice.InvoiceNumber))
+	c.Header("X-Document-Version", strconv.Itoa(doc.Version))
+	c.Header("X-Document-SHA256", doc.SHA256)
+	c.Data(http.StatusOK, "application/pdf", doc.PDF)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNumberingSchemes lists how each type of document is numbered
+func GetNumberingSchemes(c *gin.Context) {
+	schemes, err := services.NewNumberingService().GetSchemes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve numbering schemes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schemes": schemes})
+}
+
+// UpdateNumberingScheme sets the format and reset of a document type's
+// numbers
+func UpdateNumberingScheme(c *gin.Context) {
+	var req models.NumberingSchemeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	scheme, err := services.NewNumberingService().UpdateScheme(c.Param("type"), &req)
+	if err != nil {
+		switch err.Error() {
+		case "numbering scheme not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Numbering scheme not found"})
+		case "failed to save numbering scheme":
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save numbering scheme"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scheme": scheme})
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isPricingError reports whether the job couldn't be priced because of what
+// was asked for, which is the client's mistake rather than ours
+func isPricingError(err error) bool {
+	switch err.Error() {
+	case "square meters must be greater than 0", "condition must be standard, heavy or extreme", "frequency must be weekly, biweekly or monthly":
+		return true
+	}
+	return strings.HasPrefix(err.Error(), "add-on not available for this service: ") || strings.HasPrefix(err.Error(), "promo code ")
+}
+
+// GetAddOns lists the add-ons that can be booked
+func GetAddOns(c *gin.Context) {
+	addOns, err := services.NewPricingService().GetAddOns(true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve add-ons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"add_ons": addOns})
+}
+
+// GetAllAddOns lists every add-on, including inactive ones
+func GetAllAddOns(c *gin.Context) {
+	addOns, err := services.NewPricingService().GetAddOns(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve add-ons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"add_ons": addOns})
+}
+
+func CreateAddOn(c *gin.Context) {
+	var req models.AddOnRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	addOn, err := services.NewPricingService().CreateAddOn(&req)
+	if err != nil {
+		writeAddOnError(c, err, "Failed to create add-on")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"add_on": addOn})
+}
+
+func UpdateAddOn(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid add-on ID"})
+		return
+	}
+
+	var req models.AddOnRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	addOn, err := services.NewPricingService().UpdateAddOn(id, &req)
+	if err != nil {
+		writeAddOnError(c, err, "Failed to update add-on")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"add_on": addOn})
+}
+
+func writeAddOnError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "add-on not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Add-on not found"})
+	case "add-on code already in use":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GetServicePricing returns the pricing rules of a service
+func GetServicePricing(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	pricing, err := services.NewPricingService().GetServicePricing(id)
+	if err != nil {
+		writeServicePricingError(c, err, "Failed to retrieve pricing")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pricing": pricing})
+}
+
+// UpdateServicePricing replaces the pricing rules of a service. Bookings
+// already made keep the price they were made at.
+func UpdateServicePricing(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	var req models.ServicePricingRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	pricing, err := services.NewPricingService().UpdateServicePricing(id, &req)
+	if err != nil {
+		writeServicePricingError(c, err, "Failed to update pricing")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pricing": pricing})
+}
+
+func writeServicePricingError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "service not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+	case "size tiers must have different sizes":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetPromoCodes(c *gin.Context) {
+	promos, err := services.NewPromoService().GetPromoCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve promo codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promo_codes": promos})
+}
+
+// GetPromoCode returns a promo code with the bookings it was used on
+func GetPromoCode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code ID"})
+		return
+	}
+
+	promo, err := services.NewPromoService().GetPromoCode(id)
+	if err != nil {
+		writePromoCodeError(c, err, "Failed to retrieve promo code")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promo_code": promo})
+}
+
+func CreatePromoCode(c *gin.Context) {
+	var req models.PromoCodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	promo, err := services.NewPromoService().CreatePromoCode(&req)
+	if err != nil {
+		writePromoCodeError(c, err, "Failed to create promo code")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"promo_code": promo})
+}
+
+// UpdatePromoCode changes a promo code. Bookings already made with it keep
+// their discount.
+func UpdatePromoCode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code ID"})
+		return
+	}
+
+	var req models.PromoCodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	promo, err := services.NewPromoService().UpdatePromoCode(id, &req)
+	if err != nil {
+		writePromoCodeError(c, err, "Failed to update promo code")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promo_code": promo})
+}
+
+func writePromoCodeError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "promo code not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promo code not found"})
+	case "promo code already in use":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "percentage discount must be at most 100", "ends_on must not be before starts_on":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyProperties lists the current client's saved properties
+func GetMyProperties(c *gin.Context) {
+	customerID, ok := myCustomerID(c)
+	if !ok {
+		return
+	}
+
+	properties, err := services.NewPropertyService().GetProperties(customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve properties"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"properties": properties})
+}
+
+func CreateMyProperty(c *gin.Context) {
+	customerID, ok := myCustomerID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomerPropertyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	property, err := services.NewPropertyService().AddProperty(customerID, &req)
+	if err != nil {
+		writeCustomerError(c, err, "Failed to add property")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"property": property})
+}
+
+func UpdateMyProperty(c *gin.Context) {
+	customerID, ok := myCustomerID(c)
+	if !ok {
+		return
+	}
+	propertyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid property ID"})
+		return
+	}
+
+	var req models.CustomerPropertyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	property, err := services.NewPropertyService().UpdateProperty(customerID, propertyID, &req)
+	if err != nil {
+		writeCustomerError(c, err, "Failed to update property")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"property": property})
+}
+
+func DeleteMyProperty(c *gin.Context) {
+	customerID, ok := myCustomerID(c)
+	if !ok {
+		return
+	}
+	propertyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid property ID"})
+		return
+	}
+
+	if err := services.NewPropertyService().DeleteProperty(customerID, propertyID); err != nil {
+		writeCustomerError(c, err, "Failed to delete property")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Property deleted successfully"})
+}
+
+// myCustomerID returns the customer record of the current client
+func myCustomerID(c *gin.Context) (int, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return 0, false
+	}
+
+	customerID, err := services.NewCustomerService().ForUser(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load customer record"})
+		return 0, false
+	}
+	return customerID, true
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Like the guest portal token, the quote token travels in a header to stay
+// out of access logs
+const quoteTokenHeader = "X-Quote-Token"
+
+// quotePortal authenticates a quote link request and returns the quote its
+// token was issued for. It writes the error response when it returns false.
+func quotePortal(c *gin.Context) (*services.QuotePortalService, *models.Quote, bool) {
+	token := c.GetHeader(quoteTokenHeader)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": quoteTokenHeader + " header required"})
+		return nil, nil, false
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return nil, nil, false
+	}
+
+	portalService := services.NewQuotePortalService(config)
+	quote, err := portalService.Authenticate(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "This link is invalid. Please contact us for a new quote."})
+		return nil, nil, false
+	}
+
+	return portalService, quote, true
+}
+
+// SendQuote emails the customer a link to their quote and marks it sent.
+// Sending again mails a new link and restarts the expiry.
+func SendQuote(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quote ID"})
+		return
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return
+	}
+
+	quote, err := services.NewQuotePortalService(config).Send(id)
+	if err != nil {
+		log.Printf("Failed to send quote %d: %v", id, err)
+		writeQuoteError(c, err, "Failed to send quote")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quote": quote, "message": "Quote sent successfully"})
+}
+
+// GetQuotePortalQuote shows the customer the quote they were sent
+func GetQuotePortalQuote(c *gin.Context) {
+	portalService, quote, ok := quotePortal(c)
+	if !ok {
+		return
+	}
+
+	quoteResp, err := portalService.GetQuote(quote)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quote"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quote": quoteResp})
+}
+
+// AcceptQuotePortalQuote books the quoted job in the slot the customer picked
+func AcceptQuotePortalQuote(c *gin.Context) {
+	portalService, quote, ok := quotePortal(c)
+	if !ok {
+		return
+	}
+
+	var req models.QuoteAcceptRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	booking, err := portalService.Accept(quote, &req)
+	if err != nil {
+		if isPricingError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		switch err.Error() {
+		case "service not found":
+			c.JSON(http.StatusConflict, gin.H{"error": "This service is no longer offered. Please contact us."})
+		case "outside business hours", "closed on this date", "invalid time format. Use HH:MM", "invalid date format. Use YYYY-MM-DD":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "time slot unavailable":
+			c.JSON(http.StatusConflict, gin.H{"error": "This time slot is no longer available. Please choose another time."})
+		default:
+			writeQuoteError(c, err, "Failed to accept quote")
+		}
+		return
+	}
+
+	response := gin.H{
+		"booking": booking,
+		"message": "Quote accepted! Your booking has been created.",
+	}
+	// Guests manage the new booking through the guest portal like any other
+	if booking.IsGuestBooking {
+		config, err := config.LoadConfig()
+		if err != nil {
+			log.Printf("Failed to load config for booking %d confirmation: %v", booking.ID, err)
+		} else {
+			guestPortal := services.NewGuestPortalService(config)
+			if err := guestPortal.SendConfirmation(booking); err != nil {
+				log.Printf("Failed to send confirmation for booking %d: %v", booking.ID, err)
+			}
+			if token, err := guestPortal.IssueToken(booking.ID, booking.GuestEmail); err == nil {
+				response["access_token"] = token
+			}
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// RejectQuotePortalQuote records that the customer declined the quote
+func RejectQuotePortalQuote(c *gin.Context) {
+	portalService, quote, ok := quotePortal(c)
+	if !ok {
+		return
+	}
+
+	var req models.QuoteRejectRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := portalService.Reject(quote, req.Reason); err != nil {
+		writeQuoteError(c, err, "Failed to reject quote")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quote declined. Thank you for letting us know."})
+}
+
+func writeQuoteError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "quote not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quote not found"})
+	case "quote already accepted":
+		c.JSON(http.StatusConflict, gin.H{"error": "This quote has already been accepted"})
+	case "quote is no longer open":
+		c.JSON(http.StatusConflict, gin.H{"error": "This quote has already been answered or has expired"})
+	case "invalid quote status change":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quotes are marked sent by sending them and accepted by the customer"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
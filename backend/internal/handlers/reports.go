@@ -58,7 +58,7 @@ func GetReportsData(c *gin.Context) {
 		FROM invoices i
 		JOIN bookings b ON i.booking_id = b.id
 		JOIN services s ON b.service_id = s.id
-		WHERE b.scheduled_date >= ? AND b.scheduled_date <= ?
+		WHERE b.scheduled_date >= ? AND b.scheduled_date <= ? AND i.archived_at IS NULL
 	`
 
 	// Add client filter if specified
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBusinessHours returns the weekly hours and the closures and special hours
+// of the next 90 days, so the booking form can grey out closed days
+func GetBusinessHours(c *gin.Context) {
+	schedulingService := services.NewSchedulingService()
+	hours, err := schedulingService.GetBusinessHours()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve business hours"})
+		return
+	}
+
+	today := time.Now()
+	exceptions, err := schedulingService.GetScheduleExceptions(today, today.AddDate(0, 0, 90))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule exceptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hours": hours, "exceptions": exceptions})
+}
+
+// UpdateBusinessHours sets the weekly opening hours
+func UpdateBusinessHours(c *gin.Context) {
+	var req models.BusinessHoursRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	schedulingService := services.NewSchedulingService()
+	hours, err := schedulingService.UpdateBusinessHours(req.Hours)
+	if err != nil {
+		if err.Error() == "failed to save business hours" {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hours": hours})
+}
+
+// GetScheduleExceptions lists holidays, blackout dates and special hours.
+// Defaults to the coming year.
+func GetScheduleExceptions(c *gin.Context) {
+	from := time.Now()
+	to := from.AddDate(1, 0, 0)
+	var err error
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err = time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		to, err = time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	schedulingService := services.NewSchedulingService()
+	exceptions, err := schedulingService.GetScheduleExceptions(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule exceptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exceptions": exceptions})
+}
+
+func CreateScheduleException(c *gin.Context) {
+	var req models.ScheduleExceptionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	schedulingService := services.NewSchedulingService()
+	exception, err := schedulingService.CreateScheduleException(&req)
+	if err != nil {
+		if err.Error() == "failed to create schedule exception" {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"exception": exception})
+}
+
+func UpdateScheduleException(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule exception ID"})
+		return
+	}
+
+	var req models.ScheduleExceptionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	schedulingService := services.NewSchedulingService()
+	exception, err := schedulingService.UpdateScheduleException(id, &req)
+	if err != nil {
+		switch err.Error() {
+		case "schedule exception not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule exception not found"})
+		case "failed to update schedule exception":
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exception": exception})
+}
+
+func DeleteScheduleException(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule exception ID"})
+		return
+	}
+
+	schedulingService := services.NewSchedulingService()
+	if err := schedulingService.DeleteScheduleException(id); err != nil {
+		if err.Error() == "schedule exception not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule exception not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule exception"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule exception deleted successfully"})
+}
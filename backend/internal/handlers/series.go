@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seriesActor returns whose series the request may touch: the caller's own,
+// or 0 for admins, who may manage every series
+func seriesActor(c *gin.Context) (int, bool) {
+	if role, _ := c.Get("user_role"); role == models.RoleAdmin {
+		return 0, true
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return 0, false
+	}
+	return userID.(int), true
+}
+
+func writeSeriesError(c *gin.Context, err error, fallback string) {
+	if conflictErr, ok := err.(*services.AssignmentConflictError); ok {
+		c.JSON(http.StatusConflict, gin.H{"error": conflictErr.Error(), "conflicts": conflictErr.Conflicts})
+		return
+	}
+
+	switch err.Error() {
+	case "series not found", "occurrence not found", "property not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "time slot unavailable", "series is cancelled", "occurrence can no longer be changed", "invalid status transition",
+		"booking can no longer be rescheduled", "too late to reschedule":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "failed to create series":
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	default:
+		// Validation errors from the service are safe to show
+		if isSeriesValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Series request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func isSeriesValidationError(err error) bool {
+	switch err.Error() {
+	case "client not found", "service not found", "frequency must be weekly, biweekly or monthly",
+		"address is required", "square meters must be greater than 0", "invalid time format. Use HH:MM",
+		"invalid date format. Use YYYY-MM-DD", "start date must not be in the past",
+		"end date must not be before start date", "only future occurrences can be changed",
+		"cannot reschedule into the past", "outside business hours", "closed on this date":
+		return true
+	}
+	return false
+}
+
+// CreateSeries starts a recurring booking
+func CreateSeries(c *gin.Context) {
+	userID, ok := seriesActor(c)
+	if !ok {
+		return
+	}
+
+	var req models.SeriesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	seriesService := services.NewSeriesService()
+	series, err := seriesService.CreateSeries(&req, userID)
+	if err != nil {
+		writeSeriesError(c, err, "Failed to create recurring booking")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"series": series})
+}
+
+func GetSeriesList(c *gin.Context) {
+	userID, ok := seriesActor(c)
+	if !ok {
+		return
+	}
+
+	seriesService := services.NewSeriesService()
+	series, err := seriesService.ListSeries(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve recurring bookings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// GetSeries returns a series with its upcoming occurrences
+func GetSeries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	userID, ok := seriesActor(c)
+	if !ok {
+		return
+	}
+
+	seriesService := services.NewSeriesService()
+	series, err := seriesService.GetSeries(id, userID)
+	if err != nil {
+		writeSeriesError(c, err, "Failed to retrieve recurring booking")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// UpdateSeries edits an occurrence and all following ones
+func UpdateSeries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	userID, ok := seriesActor(c)
+	if !ok {
+		return
+	}
+
+	var req models.SeriesUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	seriesService := services.NewSeriesService()
+	series, err := seriesService.UpdateFollowing(id, userID, &req, statusActor(c))
+	if err != nil {
+		writeSeriesError(c, err, "Failed to update recurring booking")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// CancelSeries stops a series and cancels its future occurrences
+func CancelSeries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	userID, ok := seriesActor(c)
+	if !ok {
+		return
+	}
+
+	seriesService := services.NewSeriesService()
+	cancelled, err := seriesService.CancelSeries(id, userID, statusActor(c))
+	if err != nil {
+		writeSeriesError(c, err, "Failed to cancel recurring booking")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring booking cancelled", "cancelled_occurrences": cancelled})
+}
+
+// SkipOccurrence skips one occurrence of a series
+func SkipOccurrence(c *gin.Context) {
+	id, bookingID, ok := parseOccurrenceParams(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := seriesActor(c)
+	if !ok {
+		return
+	}
+
+	seriesService := services.NewSeriesService()
+	if err := seriesService.SkipOccurrence(id, bookingID, userID, statusActor(c)); err != nil {
+		writeSeriesError(c, err, "Failed to skip occurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Occurrence skipped"})
+}
+
+// RescheduleOccurrence moves one occurrence of a series
+func RescheduleOccurrence(c *gin.Context) {
+	id, bookingID, ok := parseOccurrenceParams(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := seriesActor(c)
+	if !ok {
+		return
+	}
+
+	var req models.OccurrenceRescheduleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	seriesService := services.NewSeriesService()
+	if err := seriesService.RescheduleOccurrence(id, bookingID, userID, &req, statusActor(c)); err != nil {
+		writeSeriesError(c, err, "Failed to reschedule occurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Occurrence rescheduled"})
+}
+
+func parseOccurrenceParams(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return 0, 0, false
+	}
+
+	bookingID, err := strconv.Atoi(c.Param("booking_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid occurrence ID"})
+		return 0, 0, false
+	}
+
+	return id, bookingID, true
+}
+
+// GenerateSeriesOccurrences extends all active series to the booking horizon
+func GenerateSeriesOccurrences(c *gin.Context) {
+	seriesService := services.NewSeriesService()
+	created, err := seriesService.GenerateAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate occurrences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Occurrences generated", "created": created})
+}
@@ -11,7 +11,19 @@ import (
 
 func GetServices(c *gin.Context) {
 	serviceService := services.NewServiceService()
-	services, err := serviceService.GetAllServices()
+	services, err := serviceService.GetAllServices(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve services"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"services": services})
+}
+
+// GetAllServices lists the whole catalog, archived services included
+func GetAllServices(c *gin.Context) {
+	serviceService := services.NewServiceService()
+	services, err := serviceService.GetAllServices(true)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve services"})
 		return
@@ -22,15 +34,14 @@ func GetServices(c *gin.Context) {
 
 func CreateService(c *gin.Context) {
 	var req models.ServiceRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	serviceService := services.NewServiceService()
 	service, err := serviceService.CreateService(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service"})
+		writeServiceError(c, err, "Failed to create service")
 		return
 	}
 
@@ -45,30 +56,40 @@ func UpdateService(c *gin.Context) {
 	}
 
 	var req models.ServiceRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	service := &models.Service{
-		Name:        req.Name,
-		Description: req.Description,
-		BasePrice:   req.BasePrice,
-		Duration:    req.Duration,
-		ServiceType: req.ServiceType,
+	serviceService := services.NewServiceService()
+	err = serviceService.UpdateService(id, &req)
+	if err != nil {
+		writeServiceError(c, err, "Failed to update service")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service updated successfully"})
+}
+
+// ArchiveService takes a service out of the catalog without touching its
+// bookings
+func ArchiveService(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
 	}
 
 	serviceService := services.NewServiceService()
-	err = serviceService.UpdateService(service, id)
+	err = serviceService.ArchiveService(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service"})
+		writeServiceError(c, err, "Failed to archive service")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Service updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Service archived successfully"})
 }
 
-func DeleteService(c *gin.Context) {
+func UnarchiveService(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
@@ -76,11 +97,115 @@ func DeleteService(c *gin.Context) {
 	}
 
 	serviceService := services.NewServiceService()
-	err = serviceService.DeleteService(id)
+	err = serviceService.UnarchiveService(id)
+	if err != nil {
+		writeServiceError(c, err, "Failed to unarchive service")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service unarchived successfully"})
+}
+
+// GetServiceAddOns lists the add-ons that can be booked with a service
+func GetServiceAddOns(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	addOns, err := services.NewServiceService().GetServiceAddOns(id)
+	if err != nil {
+		writeServiceError(c, err, "Failed to retrieve add-ons")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"add_ons": addOns})
+}
+
+// SetServiceAddOns replaces the add-ons that can be booked with a service
+func SetServiceAddOns(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	var req models.ServiceAddOnsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	addOns, err := services.NewServiceService().SetServiceAddOns(id, req.AddOnIDs)
+	if err != nil {
+		writeServiceError(c, err, "Failed to update add-ons")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"add_ons": addOns})
+}
+
+func GetServiceCategories(c *gin.Context) {
+	categories, err := services.NewServiceService().GetCategories()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+func CreateServiceCategory(c *gin.Context) {
+	var req models.ServiceCategoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	category, err := services.NewServiceService().CreateCategory(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete service"})
+		writeServiceError(c, err, "Failed to create category")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"category": category})
+}
+
+func UpdateServiceCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var req models.ServiceCategoryRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Service deleted successfully"})
-}
\ No newline at end of file
+	category, err := services.NewServiceService().UpdateCategory(id, &req)
+	if err != nil && err.Error() == "category not found" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+	if err != nil {
+		writeServiceError(c, err, "Failed to update category")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"category": category})
+}
+
+func writeServiceError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "service not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+	case "category not found":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+	case "add-on not found":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Add-on not found"})
+	case "category slug already in use":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
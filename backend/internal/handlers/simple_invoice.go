@@ -3,19 +3,26 @@ package handlers
 import (
 	"database/sql"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
+	"cleaning-app-backend/internal/config"
 	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+	"cleaning-app-backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
-// SimpleGetAllInvoices retrieves all invoices with pagination using direct SQL
+// SimpleGetAllInvoices retrieves all invoices with pagination using direct SQL.
+// Archived invoices are left out unless include_archived=true.
 func SimpleGetAllInvoices(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	status := c.Query("status")
+	includeArchived := c.Query("include_archived") == "true"
 
 	if page < 1 {
 		page = 1
@@ -35,22 +42,24 @@ func SimpleGetAllInvoices(c *gin.Context) {
 		       i.subtotal, i.tax_amount, i.total_amount,
 		       i.status, COALESCE(i.payment_method, '') as payment_method, i.payment_date, 
 		       COALESCE(i.payment_reference, '') as payment_reference,
-		       i.created_at, b.scheduled_date as service_date, s.name as service_name
+		       i.created_at, i.archived_at, b.scheduled_date as service_date, s.name as service_name,
+		       ib.amount_paid, ib.amount_credited, ib.balance_due
 		FROM invoices i
 		JOIN bookings b ON i.booking_id = b.id
 		JOIN services s ON b.service_id = s.id
+		JOIN invoice_balances ib ON ib.invoice_id = i.id
 	`
 
 	countQuery := `SELECT COUNT(*) FROM invoices i
 		JOIN bookings b ON i.booking_id = b.id
 		JOIN services s ON b.service_id = s.id`
 
-	var whereClause string
-	var args []interface{}
-	argIndex := 1
+	whereClause := " WHERE (i.archived_at IS NULL OR $1)"
+	args := []interface{}{includeArchived}
+	argIndex := 2
 
 	if status != "" {
-		whereClause = fmt.Sprintf(" WHERE i.status = $%d", argIndex)
+		whereClause += fmt.Sprintf(" AND i.status = $%d", argIndex)
 		args = append(args, status)
 		argIndex++
 	}
@@ -89,10 +98,10 @@ func SimpleGetAllInvoices(c *gin.Context) {
 		var invoiceNumber, customerName, customerEmail, customerPhone string
 		var billingAddress, billingCity, billingState, billingZipCode string
 		var serviceAddress, serviceCity, serviceState, serviceZipCode string
-		var subtotal, taxAmount, totalAmount float64
+		var subtotal, taxAmount, totalAmount, amountPaid, amountCredited, balanceDue float64
 		var status, paymentMethod, paymentReference, serviceName string
 		var issueDate, dueDate, createdAt, serviceDate time.Time
-		var paymentDate *time.Time
+		var paymentDate, archivedAt *time.Time
 
 		err := rows.Scan(
 			&id, &bookingID, &invoiceNumber, &issueDate, &dueDate,
@@ -101,7 +110,7 @@ func SimpleGetAllInvoices(c *gin.Context) {
 			&serviceAddress, &serviceCity, &serviceState, &serviceZipCode,
 			&subtotal, &taxAmount, &totalAmount,
 			&status, &paymentMethod, &paymentDate, &paymentReference,
-			&createdAt, &serviceDate, &serviceName,
+			&createdAt, &archivedAt, &serviceDate, &serviceName, &amountPaid, &amountCredited, &balanceDue,
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan invoice", "details": err.Error()})
@@ -132,8 +141,12 @@ func SimpleGetAllInvoices(c *gin.Context) {
 		invoice["payment_date"] = paymentDate
 		invoice["payment_reference"] = paymentReference
 		invoice["created_at"] = createdAt
+		invoice["archived_at"] = archivedAt
 		invoice["service_date"] = serviceDate
 		invoice["service_name"] = serviceName
+		invoice["amount_paid"] = amountPaid
+		invoice["amount_credited"] = amountCredited
+		invoice["balance_due"] = balanceDue
 
 		invoices = append(invoices, invoice)
 	}
@@ -166,7 +179,7 @@ func SimpleGetInvoice(c *gin.Context) {
 		       i.subtotal, i.tax_amount, i.total_amount,
 		       i.status, COALESCE(i.payment_method, '') as payment_method, i.payment_date, 
 		       COALESCE(i.payment_reference, '') as payment_reference,
-		       i.created_at, b.scheduled_date as service_date, s.name as service_name,
+		       i.created_at, i.archived_at, b.scheduled_date as service_date, s.name as service_name,
 		       COALESCE(i.notes, '') as notes, COALESCE(i.terms, '') as terms
 		FROM invoices i
 		JOIN bookings b ON i.booking_id = b.id
@@ -181,7 +194,7 @@ func SimpleGetInvoice(c *gin.Context) {
 	var subtotal, taxAmount, totalAmount float64
 	var status, paymentMethod, paymentReference, serviceName string
 	var issueDate, dueDate, createdAt, serviceDate time.Time
-	var paymentDate *time.Time
+	var paymentDate, archivedAt *time.Time
 	var notes, terms string
 
 	err = database.DB.QueryRow(query, id).Scan(
@@ -191,7 +204,7 @@ func SimpleGetInvoice(c *gin.Context) {
 		&serviceAddress, &serviceCity, &serviceState, &serviceZipCode,
 		&subtotal, &taxAmount, &totalAmount,
 		&status, &paymentMethod, &paymentDate, &paymentReference,
-		&createdAt, &serviceDate, &serviceName, &notes, &terms,
+		&createdAt, &archivedAt, &serviceDate, &serviceName, &notes, &terms,
 	)
 
 	if err != nil {
@@ -226,15 +239,34 @@ func SimpleGetInvoice(c *gin.Context) {
 	invoice["payment_date"] = paymentDate
 	invoice["payment_reference"] = paymentReference
 	invoice["created_at"] = createdAt
+	invoice["archived_at"] = archivedAt
 	invoice["service_date"] = serviceDate
 	invoice["service_name"] = serviceName
 	invoice["notes"] = notes
 	invoice["terms"] = terms
 
+	// Payments, refunds and credit notes, and the balance they leave
+	ledger, err := repositories.NewInvoiceRepository(database.DB).GetInvoiceByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payments", "details": err.Error()})
+		return
+	}
+	invoice["payments"] = ledger.Payments
+	invoice["credit_notes"] = ledger.CreditNotes
+	invoice["amount_paid"] = ledger.AmountPaid
+	invoice["amount_credited"] = ledger.AmountCredited
+	invoice["balance_due"] = ledger.BalanceDue
+	invoice["voided_at"] = ledger.VoidedAt
+	invoice["void_reason"] = ledger.VoidReason
+	invoice["kind"] = ledger.Kind
+	invoice["parent_invoice_id"] = ledger.ParentInvoiceID
+	invoice["late_fee_invoices"] = ledger.LateFeeInvoices
+
 	c.JSON(http.StatusOK, gin.H{"invoice": invoice})
 }
 
-// SimpleGenerateInvoiceFromBooking creates an invoice from a booking using direct SQL
+// SimpleGenerateInvoiceFromBooking creates an invoice from a booking,
+// itemized and taxed the way the booking was priced
 func SimpleGenerateInvoiceFromBooking(c *gin.Context) {
 	bookingIDStr := c.Param("booking_id")
 	bookingID, err := strconv.Atoi(bookingIDStr)
@@ -243,114 +275,28 @@ func SimpleGenerateInvoiceFromBooking(c *gin.Context) {
 		return
 	}
 
-	// Check if invoice already exists
-	var existingID int
-	err = database.DB.QueryRow("SELECT id FROM invoices WHERE booking_id = $1", bookingID).Scan(&existingID)
-	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Invoice already exists for this booking"})
-		return
-	}
-
-	// Get booking details
-	bookingQuery := `
-		SELECT b.id, b.total_price, b.address, 
-		       COALESCE(u.first_name || ' ' || u.last_name, b.guest_name) as customer_name,
-		       COALESCE(u.email, b.guest_email) as customer_email,
-		       COALESCE(u.phone, b.guest_phone) as customer_phone,
-		       COALESCE(b.billing_address, b.address) as billing_address,
-		       COALESCE(b.billing_city, 'Miami') as billing_city,
-		       COALESCE(b.billing_state, 'FL') as billing_state,
-		       COALESCE(b.billing_zip_code, '33101') as billing_zip_code
-		FROM bookings b
-		LEFT JOIN users u ON b.user_id = u.id
-		WHERE b.id = $1
-	`
-
-	var booking struct {
-		ID             int
-		TotalPrice     float64
-		Address        string
-		CustomerName   string
-		CustomerEmail  string
-		CustomerPhone  string
-		BillingAddress string
-		BillingCity    string
-		BillingState   string
-		BillingZipCode string
-	}
-
-	err = database.DB.QueryRow(bookingQuery, bookingID).Scan(
-		&booking.ID, &booking.TotalPrice, &booking.Address,
-		&booking.CustomerName, &booking.CustomerEmail, &booking.CustomerPhone,
-		&booking.BillingAddress, &booking.BillingCity, &booking.BillingState, &booking.BillingZipCode,
-	)
-
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	invoice, err := invoiceService.CreateInvoiceFromBooking(bookingID, &models.InvoiceCreateRequest{BookingID: bookingID})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
-		return
-	}
-
-	// Generate invoice number
-	var maxNum int
-	database.DB.QueryRow("SELECT COALESCE(MAX(CAST(SUBSTRING(invoice_number FROM 'PP-INV-[0-9]{4}-[0-9]{2}-([0-9]+)') AS INTEGER)), 0) FROM invoices WHERE invoice_number LIKE 'PP-INV-2025-%'").Scan(&maxNum)
-	invoiceNumber := fmt.Sprintf("PP-INV-2025-11-%03d", maxNum+1)
-
-	// Calculate tax - booking.TotalPrice is the final amount (tax-inclusive)
-	taxRate := 0.07
-	totalAmount := booking.TotalPrice
-	// Calculate backwards: if total includes 7% tax, then total = subtotal * 1.07
-	// Therefore: subtotal = total / 1.07
-	subtotal := totalAmount / 1.07
-	taxAmount := totalAmount - subtotal
-
-	// Create invoice
-	insertQuery := `
-		INSERT INTO invoices (
-			booking_id, invoice_number, issue_date, due_date,
-			customer_name, customer_email, customer_phone,
-			billing_address, billing_city, billing_state, billing_zip_code, billing_country,
-			service_address, service_city, service_state, service_zip_code,
-			subtotal, tax_rate, tax_amount, total_amount,
-			status, florida_tax_id, tax_exempt, terms
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
-			$17, $18, $19, $20, $21, $22, $23, $24
-		) RETURNING id
-	`
-
-	now := time.Now()
-	dueDate := now.AddDate(0, 0, 30) // 30 days from now
-
-	var invoiceID int
-	err = database.DB.QueryRow(insertQuery,
-		bookingID, invoiceNumber, now, dueDate,
-		booking.CustomerName, booking.CustomerEmail, booking.CustomerPhone,
-		booking.BillingAddress, booking.BillingCity, booking.BillingState, booking.BillingZipCode, "United States",
-		booking.Address, "Miami", "FL", "33101",
-		subtotal, taxRate, taxAmount, totalAmount,
-		"pending", "92-396658", false, "Payment due within 30 days of invoice date. Late payments subject to 1.5% monthly service charge.",
-	).Scan(&invoiceID)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invoice", "details": err.Error()})
+		switch err.Error() {
+		case "booking not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		case "invoice already exists for booking":
+			c.JSON(http.StatusConflict, gin.H{"error": "Invoice already exists for this booking"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invoice", "details": err.Error()})
+		}
 		return
 	}
 
-	// Update the booking to link the invoice
-	_, err = database.DB.Exec("UPDATE bookings SET invoice_id = $1 WHERE id = $2", invoiceID, bookingID)
-	if err != nil {
-		// Log the error but don't fail the request since the invoice was created
-		fmt.Printf("Warning: Failed to update booking with invoice_id: %v\n", err)
-	}
-
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Invoice created successfully",
-		"invoice_id": invoiceID,
-		"invoice_number": invoiceNumber,
+		"invoice_id": invoice.ID,
+		"invoice_number": invoice.InvoiceNumber,
 	})
 }
 
-// SimpleMarkAsPaid marks an invoice as paid using direct SQL
+// SimpleMarkAsPaid records a payment of an invoice's balance
 func SimpleMarkAsPaid(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -364,34 +310,36 @@ func SimpleMarkAsPaid(c *gin.Context) {
 		PaymentReference string `json:"payment_reference"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	query := `
-		UPDATE invoices 
-		SET status = 'paid', payment_method = $1, payment_reference = $2, payment_date = $3, updated_at = $4
-		WHERE id = $5
-	`
-
-	result, err := database.DB.Exec(query, request.PaymentMethod, request.PaymentReference, time.Now(), time.Now(), id)
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+	adminID := c.GetInt("user_id")
+	_, err = invoiceService.RecordPayment(id, &models.PaymentRequest{
+		Method:    request.PaymentMethod,
+		Reference: request.PaymentReference,
+	}, &adminID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoice"})
-		return
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+		writeInvoiceError(c, err, "Failed to update invoice")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Invoice marked as paid successfully"})
 }
 
-// SimpleDeleteInvoice deletes an invoice using direct SQL
+// SimpleDeleteInvoice archives an invoice. Invoices are financial records we
+// have to retain, so they are never physically deleted.
 func SimpleDeleteInvoice(c *gin.Context) {
+	setInvoiceArchived(c, true, "Invoice archived successfully")
+}
+
+// SimpleRestoreInvoice brings an archived invoice back
+func SimpleRestoreInvoice(c *gin.Context) {
+	setInvoiceArchived(c, false, "Invoice restored successfully")
+}
+
+func setInvoiceArchived(c *gin.Context, archived bool, message string) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -399,9 +347,13 @@ func SimpleDeleteInvoice(c *gin.Context) {
 		return
 	}
 
-	result, err := database.DB.Exec("DELETE FROM invoices WHERE id = $1", id)
+	result, err := database.DB.Exec(
+		`UPDATE invoices SET archived_at = CASE WHEN $2 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END,
+		 updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		id, archived,
+	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete invoice"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoice"})
 		return
 	}
 
@@ -411,7 +363,7 @@ func SimpleDeleteInvoice(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Invoice deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": message})
 }
 
 // SimpleGetInvoicesByDateRange gets invoices by date range using direct SQL
@@ -428,11 +380,13 @@ func SimpleGetInvoicesByDateRange(c *gin.Context) {
 		SELECT i.id, i.invoice_number, i.issue_date, i.due_date,
 		       i.customer_name, i.customer_email, i.subtotal, i.tax_amount, i.total_amount,
 		       i.status, i.payment_method, i.payment_date,
-		       b.scheduled_date as service_date, s.name as service_name
+		       b.scheduled_date as service_date, s.name as service_name,
+		       ib.amount_paid, ib.amount_credited, ib.balance_due
 		FROM invoices i
 		JOIN bookings b ON i.booking_id = b.id
 		JOIN services s ON b.service_id = s.id
-		WHERE b.scheduled_date >= $1 AND b.scheduled_date <= $2
+		JOIN invoice_balances ib ON ib.invoice_id = i.id
+		WHERE b.scheduled_date >= $1 AND b.scheduled_date <= $2 AND i.archived_at IS NULL
 		ORDER BY b.scheduled_date DESC
 	`
 
@@ -448,14 +402,14 @@ func SimpleGetInvoicesByDateRange(c *gin.Context) {
 		var invoice map[string]interface{} = make(map[string]interface{})
 		var id int
 		var invoiceNumber, customerName, customerEmail, status, paymentMethod, serviceName string
-		var subtotal, taxAmount, totalAmount float64
+		var subtotal, taxAmount, totalAmount, amountPaid, amountCredited, balanceDue float64
 		var issueDate, dueDate, serviceDate time.Time
 		var paymentDate *time.Time
 
 		err := rows.Scan(
 			&id, &invoiceNumber, &issueDate, &dueDate,
 			&customerName, &customerEmail, &subtotal, &taxAmount, &totalAmount,
-			&status, &paymentMethod, &paymentDate, &serviceDate, &serviceName,
+			&status, &paymentMethod, &paymentDate, &serviceDate, &serviceName, &amountPaid, &amountCredited, &balanceDue,
 		)
 		if err != nil {
 			continue
@@ -475,6 +429,9 @@ func SimpleGetInvoicesByDateRange(c *gin.Context) {
 		invoice["payment_date"] = paymentDate
 		invoice["service_date"] = serviceDate
 		invoice["service_name"] = serviceName
+		invoice["amount_paid"] = amountPaid
+		invoice["amount_credited"] = amountCredited
+		invoice["balance_due"] = balanceDue
 
 		invoices = append(invoices, invoice)
 	}
@@ -485,30 +442,27 @@ func SimpleGetInvoicesByDateRange(c *gin.Context) {
 // SimpleCreateCustomInvoice creates a custom invoice without requiring a booking
 func SimpleCreateCustomInvoice(c *gin.Context) {
 	var request struct {
-		CustomerName      string    `json:"customer_name" binding:"required"`
-		CustomerEmail     string    `json:"customer_email"`
+		CustomerName      string    `json:"customer_name" validate:"required"`
+		CustomerEmail     string    `json:"customer_email" validate:"omitempty,email"`
 		CustomerPhone     string    `json:"customer_phone"`
-		BillingAddress    string    `json:"billing_address" binding:"required"`
-		BillingCity       string    `json:"billing_city" binding:"required"`
-		BillingState      string    `json:"billing_state" binding:"required"`
-		BillingZipCode    string    `json:"billing_zip_code" binding:"required"`
+		BillingAddress    string    `json:"billing_address" validate:"required"`
+		BillingCity       string    `json:"billing_city" validate:"required"`
+		BillingState      string    `json:"billing_state" validate:"required"`
+		BillingZipCode    string    `json:"billing_zip_code" validate:"required"`
 		ServiceAddress    string    `json:"service_address"`
 		ServiceCity       string    `json:"service_city"`
 		ServiceState      string    `json:"service_state"`
 		ServiceZipCode    string    `json:"service_zip_code"`
-		ServiceName       string    `json:"service_name" binding:"required"`
-		ServiceDate       string    `json:"service_date" binding:"required"`
-		Subtotal          float64   `json:"subtotal" binding:"required,gt=0"`
+		ServiceName       string    `json:"service_name" validate:"required"`
+		ServiceDate       string    `json:"service_date" validate:"required,date"`
+		Subtotal          float64   `json:"subtotal" validate:"required,gt=0"`
 		TaxExempt         bool      `json:"tax_exempt"`
 		TaxExemptReason   string    `json:"tax_exempt_reason"`
 		Notes             string    `json:"notes"`
 		DueDays           int       `json:"due_days"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		// Log the actual error for debugging
-		fmt.Printf("Validation error: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+	if !bindJSON(c, &request) {
 		return
 	}
 
@@ -536,11 +490,6 @@ func SimpleCreateCustomInvoice(c *gin.Context) {
 		return
 	}
 
-	// Generate invoice number
-	var maxNum int
-	database.DB.QueryRow("SELECT COALESCE(MAX(CAST(SUBSTRING(invoice_number FROM 'PP-INV-[0-9]{4}-[0-9]{2}-([0-9]+)') AS INTEGER)), 0) FROM invoices WHERE invoice_number LIKE 'PP-INV-2025-%'").Scan(&maxNum)
-	invoiceNumber := fmt.Sprintf("PP-INV-2025-11-%03d", maxNum+1)
-
 	// Calculate tax - the Subtotal field now contains the TOTAL (tax-inclusive)
 	taxRate := 0.07
 	var subtotal, taxAmount, totalAmount float64
@@ -564,18 +513,34 @@ func SimpleCreateCustomInvoice(c *gin.Context) {
 		INSERT INTO bookings (
 			service_id, user_id, guest_name, guest_email, guest_phone,
 			scheduled_date, scheduled_time, address, square_meters,
-			total_price, status, special_instructions, is_custom_invoice
+			total_price, status, special_instructions, is_custom_invoice, customer_id
 		) VALUES (
 			1, NULL, $1, $2, $3, $4, '00:00', $5, 0,
-			$6, 'completed', $7, true
+			$6, 'completed', $7, true, $8
 		) RETURNING id
 	`
 
+	// Custom invoices are often the only record of a customer, so file them
+	var customerID *int
+	if id, err := services.NewCustomerService().ForContact(request.CustomerName, request.CustomerEmail, request.CustomerPhone); err == nil {
+		customerID = &id
+	} else {
+		log.Printf("Failed to link customer for custom invoice to %s: %v", request.CustomerEmail, err)
+	}
+
+	// The booking record, the invoice and its number are stored together
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invoice"})
+		return
+	}
+	defer tx.Rollback()
+
 	var bookingID int
-	err = database.DB.QueryRow(bookingQuery,
+	err = tx.QueryRow(bookingQuery,
 		request.CustomerName, request.CustomerEmail, request.CustomerPhone,
 		serviceDate, request.ServiceAddress,
-		subtotal, "Custom invoice - "+request.ServiceName,
+		subtotal, "Custom invoice - "+request.ServiceName, customerID,
 	).Scan(&bookingID)
 
 	if err != nil {
@@ -591,10 +556,11 @@ func SimpleCreateCustomInvoice(c *gin.Context) {
 			billing_address, billing_city, billing_state, billing_zip_code, billing_country,
 			service_address, service_city, service_state, service_zip_code,
 			subtotal, tax_rate, tax_amount, total_amount,
-			status, florida_tax_id, tax_exempt, tax_exempt_reason, notes, terms
+			status, florida_tax_id, tax_exempt, tax_exempt_reason, notes, terms, customer_id
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
-			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26
+			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26,
+			(SELECT customer_id FROM bookings WHERE id = $1)
 		) RETURNING id
 	`
 
@@ -602,16 +568,39 @@ func SimpleCreateCustomInvoice(c *gin.Context) {
 	dueDate := now.AddDate(0, 0, request.DueDays)
 	terms := fmt.Sprintf("Payment due within %d days of invoice date. Late payments subject to 1.5%% monthly service charge.", request.DueDays)
 
+	invoiceNumber, err := repositories.NextDocumentNumber(tx, models.DocumentTypeInvoice, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to number invoice", "details": err.Error()})
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+		return
+	}
+
 	var invoiceID int
-	err = database.DB.QueryRow(insertQuery,
+	err = tx.QueryRow(insertQuery,
 		bookingID, invoiceNumber, now, dueDate,
 		request.CustomerName, request.CustomerEmail, request.CustomerPhone,
 		request.BillingAddress, request.BillingCity, request.BillingState, request.BillingZipCode, "United States",
 		request.ServiceAddress, request.ServiceCity, request.ServiceState, request.ServiceZipCode,
 		subtotal, taxRate, taxAmount, totalAmount,
-		"pending", "92-396658", request.TaxExempt, request.TaxExemptReason, request.Notes, terms,
+		"pending", cfg.FloridaTaxID, request.TaxExempt, request.TaxExemptReason, request.Notes, terms,
 	).Scan(&invoiceID)
 
+	// The service is the invoice's one line
+	if err == nil {
+		_, err = tx.Exec(
+			`INSERT INTO invoice_items (invoice_id, description, quantity, unit_price, total_price, taxable)
+			 VALUES ($1, $2, 1, $3, $3, $4)`,
+			invoiceID, fmt.Sprintf("%s (%s)", request.ServiceName, serviceDate.Format("January 2, 2006")), subtotal, !request.TaxExempt,
+		)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invoice", "details": err.Error()})
 		return
@@ -1,10 +1,11 @@
 package handlers
 
 import (
-	"net/http"
-	"time"
 	"cleaning-app-backend/internal/database"
 	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"time"
 )
 
 // SimpleGetReportsData - Simple reports handler that works
@@ -57,7 +58,7 @@ func SimpleGetReportsData(c *gin.Context) {
 		var squareMeters int
 		var scheduledDate, scheduledTime, createdAt time.Time
 
-		err := bookingRows.Scan(&id, &serviceName, &scheduledDate, &scheduledTime, &status, 
+		err := bookingRows.Scan(&id, &serviceName, &scheduledDate, &scheduledTime, &status,
 			&totalPrice, &squareMeters, &address, &specialInstructions, &customerName, &createdAt)
 		if err != nil {
 			continue // Skip problematic rows
@@ -86,11 +87,12 @@ func SimpleGetReportsData(c *gin.Context) {
 		SELECT i.id, i.invoice_number, s.name as service_name, b.scheduled_date as service_date,
 		       i.subtotal, i.tax_amount, i.total_amount, i.status,
 		       i.created_at, i.payment_date, COALESCE(i.payment_method, '') as payment_method,
-		       i.customer_name
+		       i.customer_name, ib.amount_paid, ib.amount_credited, ib.balance_due
 		FROM invoices i
 		JOIN bookings b ON i.booking_id = b.id
 		JOIN services s ON b.service_id = s.id
-		WHERE b.scheduled_date >= $1 AND b.scheduled_date <= $2
+		JOIN invoice_balances ib ON ib.invoice_id = i.id
+		WHERE b.scheduled_date >= $1 AND b.scheduled_date <= $2 AND i.archived_at IS NULL
 	`
 
 	var invoiceArgs []interface{}
@@ -110,18 +112,20 @@ func SimpleGetReportsData(c *gin.Context) {
 	defer invoiceRows.Close()
 
 	var invoices []map[string]interface{}
-	var totalInvoiced, totalPaid, totalPending, totalOverdue float64
+	var totalInvoiced, taxInvoiced, totalPaid, totalPending, totalOverdue float64
+	var partiallyPaid, voided int
 
 	for invoiceRows.Next() {
 		var invoice = make(map[string]interface{})
 		var id int
 		var invoiceNumber, serviceName, status, paymentMethod, customerName string
-		var subtotal, taxAmount, totalAmount float64
+		var subtotal, taxAmount, totalAmount, amountPaid, amountCredited, balanceDue float64
 		var createdAt, serviceDate time.Time
 		var paymentDate *time.Time
 
 		err := invoiceRows.Scan(&id, &invoiceNumber, &serviceName, &serviceDate,
-			&subtotal, &taxAmount, &totalAmount, &status, &createdAt, &paymentDate, &paymentMethod, &customerName)
+			&subtotal, &taxAmount, &totalAmount, &status, &createdAt, &paymentDate, &paymentMethod, &customerName,
+			&amountPaid, &amountCredited, &balanceDue)
 		if err != nil {
 			continue // Skip problematic rows
 		}
@@ -138,40 +142,278 @@ func SimpleGetReportsData(c *gin.Context) {
 		invoice["payment_date"] = paymentDate
 		invoice["payment_method"] = paymentMethod
 		invoice["customer_name"] = customerName
+		invoice["amount_paid"] = amountPaid
+		invoice["amount_credited"] = amountCredited
+		invoice["balance_due"] = balanceDue
 
 		invoices = append(invoices, invoice)
+
+		// Void invoices were never owed
+		if status == "void" || status == "cancelled" {
+			voided++
+			continue
+		}
 		totalInvoiced += totalAmount
+		taxInvoiced += taxAmount
 
+		// Payments count whatever the status; what they and credit notes
+		// leave owing is pending or overdue
+		totalPaid += amountPaid
 		switch status {
-		case "paid":
-			totalPaid += totalAmount
-		case "pending":
-			totalPending += totalAmount
+		case "pending", "partially_paid":
+			totalPending += math.Max(balanceDue, 0)
 		case "overdue":
-			totalOverdue += totalAmount
+			totalOverdue += math.Max(balanceDue, 0)
 		}
+		if status == "partially_paid" {
+			partiallyPaid++
+		}
+	}
+
+	// Credit notes of the invoices above, netted out of what was invoiced
+	creditNoteQuery := `
+		SELECT cn.id, cn.credit_note_number, cn.invoice_id, i.invoice_number, i.customer_name,
+		       cn.issue_date, cn.reason, cn.subtotal, cn.tax_amount, cn.total_amount
+		FROM credit_notes cn
+		JOIN invoices i ON cn.invoice_id = i.id
+		JOIN bookings b ON i.booking_id = b.id
+		WHERE b.scheduled_date >= $1 AND b.scheduled_date <= $2 AND i.archived_at IS NULL
+	`
+
+	var creditNoteArgs []interface{}
+	creditNoteArgs = append(creditNoteArgs, startDate, endDate)
+
+	if client != "" {
+		creditNoteQuery += " AND i.customer_name ILIKE $3"
+		creditNoteArgs = append(creditNoteArgs, "%"+client+"%")
+	}
+	creditNoteQuery += " ORDER BY cn.issue_date DESC, cn.id DESC"
+
+	creditNoteRows, err := database.DB.Query(creditNoteQuery, creditNoteArgs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credit notes", "details": err.Error()})
+		return
+	}
+	defer creditNoteRows.Close()
+
+	creditNotes := []map[string]interface{}{}
+	var creditedSubtotal, creditedTax, creditedTotal float64
+
+	for creditNoteRows.Next() {
+		var id, invoiceID int
+		var number, invoiceNumber, customerName, reason string
+		var subtotal, taxAmount, totalAmount float64
+		var issueDate time.Time
+
+		err := creditNoteRows.Scan(&id, &number, &invoiceID, &invoiceNumber, &customerName,
+			&issueDate, &reason, &subtotal, &taxAmount, &totalAmount)
+		if err != nil {
+			continue // Skip problematic rows
+		}
+
+		creditNotes = append(creditNotes, map[string]interface{}{
+			"id":                 id,
+			"credit_note_number": number,
+			"invoice_id":         invoiceID,
+			"invoice_number":     invoiceNumber,
+			"customer_name":      customerName,
+			"issue_date":         issueDate,
+			"reason":             reason,
+			"subtotal":           subtotal,
+			"tax_amount":         taxAmount,
+			"total_amount":       totalAmount,
+		})
+		creditedSubtotal += subtotal
+		creditedTax += taxAmount
+		creditedTotal += totalAmount
+	}
+
+	// Revenue is net of what was credited back
+	totalRevenue -= creditedSubtotal
+
+	// Payments received in the date range, whichever invoice they paid
+	paymentQuery := `
+		SELECT p.id, p.invoice_id, i.invoice_number, i.customer_name, p.amount, p.credited,
+		       p.method, p.reference, p.paid_at
+		FROM payments p
+		JOIN invoices i ON p.invoice_id = i.id
+		WHERE p.paid_at::date >= $1 AND p.paid_at::date <= $2
+	`
+
+	var paymentArgs []interface{}
+	paymentArgs = append(paymentArgs, startDate, endDate)
+
+	if client != "" {
+		paymentQuery += " AND i.customer_name ILIKE $3"
+		paymentArgs = append(paymentArgs, "%"+client+"%")
+	}
+	paymentQuery += " ORDER BY p.paid_at DESC, p.id DESC"
+
+	paymentRows, err := database.DB.Query(paymentQuery, paymentArgs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payments", "details": err.Error()})
+		return
+	}
+	defer paymentRows.Close()
+
+	payments := []map[string]interface{}{}
+	var totalCollected, totalCredited float64
+
+	for paymentRows.Next() {
+		var id, invoiceID int
+		var invoiceNumber, customerName, method, reference string
+		var amount, credited float64
+		var paidAt time.Time
+
+		err := paymentRows.Scan(&id, &invoiceID, &invoiceNumber, &customerName, &amount, &credited,
+			&method, &reference, &paidAt)
+		if err != nil {
+			continue // Skip problematic rows
+		}
+
+		payments = append(payments, map[string]interface{}{
+			"id":             id,
+			"invoice_id":     invoiceID,
+			"invoice_number": invoiceNumber,
+			"customer_name":  customerName,
+			"amount":         amount,
+			"credited":       credited,
+			"method":         method,
+			"reference":      reference,
+			"paid_at":        paidAt,
+		})
+		totalCollected += amount
+		totalCredited += credited
+	}
+
+	// Refunds given in the date range
+	refundQuery := `
+		SELECT r.id, r.payment_id, p.invoice_id, i.invoice_number, i.customer_name, r.amount,
+		       r.method, r.reference, r.reason, r.refunded_at
+		FROM refunds r
+		JOIN payments p ON r.payment_id = p.id
+		JOIN invoices i ON p.invoice_id = i.id
+		WHERE r.refunded_at::date >= $1 AND r.refunded_at::date <= $2
+	`
+
+	var refundArgs []interface{}
+	refundArgs = append(refundArgs, startDate, endDate)
+
+	if client != "" {
+		refundQuery += " AND i.customer_name ILIKE $3"
+		refundArgs = append(refundArgs, "%"+client+"%")
+	}
+	refundQuery += " ORDER BY r.refunded_at DESC, r.id DESC"
+
+	refundRows, err := database.DB.Query(refundQuery, refundArgs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds", "details": err.Error()})
+		return
+	}
+	defer refundRows.Close()
+
+	refunds := []map[string]interface{}{}
+	var totalRefunded float64
+
+	for refundRows.Next() {
+		var id, paymentID, invoiceID int
+		var invoiceNumber, customerName, method, reference, reason string
+		var amount float64
+		var refundedAt time.Time
+
+		err := refundRows.Scan(&id, &paymentID, &invoiceID, &invoiceNumber, &customerName, &amount,
+			&method, &reference, &reason, &refundedAt)
+		if err != nil {
+			continue // Skip problematic rows
+		}
+
+		refunds = append(refunds, map[string]interface{}{
+			"id":             id,
+			"payment_id":     paymentID,
+			"invoice_id":     invoiceID,
+			"invoice_number": invoiceNumber,
+			"customer_name":  customerName,
+			"amount":         amount,
+			"method":         method,
+			"reference":      reference,
+			"reason":         reason,
+			"refunded_at":    refundedAt,
+		})
+		totalRefunded += amount
+	}
+
+	// Quotes requested in the date range and how many of those sent were
+	// accepted. Sent quotes past their expiry count as expired even if the
+	// quote job hasn't marked them yet.
+	quoteQuery := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE q.sent_at IS NOT NULL),
+		       COUNT(*) FILTER (WHERE q.status = 'accepted'),
+		       COUNT(*) FILTER (WHERE q.status = 'rejected'),
+		       COUNT(*) FILTER (WHERE q.status = 'expired' OR (q.status = 'sent' AND q.expires_at <= CURRENT_TIMESTAMP))
+		FROM quotes q
+		WHERE q.created_at::date >= $1 AND q.created_at::date <= $2
+	`
+
+	var quoteArgs []interface{}
+	quoteArgs = append(quoteArgs, startDate, endDate)
+
+	if client != "" {
+		quoteQuery += " AND q.contact_name ILIKE $3"
+		quoteArgs = append(quoteArgs, "%"+client+"%")
+	}
+
+	var totalQuotes, quotesSent, quotesAccepted, quotesRejected, quotesExpired int
+	err = database.DB.QueryRow(quoteQuery, quoteArgs...).Scan(&totalQuotes, &quotesSent, &quotesAccepted, &quotesRejected, &quotesExpired)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quotes", "details": err.Error()})
+		return
 	}
 
 	// Calculate analytics
 	analytics := map[string]interface{}{
-		"total_bookings":    len(bookings),
-		"total_invoices":    len(invoices),
-		"total_revenue":     totalRevenue,
-		"total_invoiced":    totalInvoiced,
-		"total_paid":        totalPaid,
-		"total_pending":     totalPending,
-		"total_overdue":     totalOverdue,
-		"collection_rate":   0.0,
+		"total_bookings":        len(bookings),
+		"total_invoices":        len(invoices),
+		"total_revenue":         totalRevenue,
+		"total_invoiced":        totalInvoiced,
+		"total_credit_notes":    creditedTotal,
+		"net_invoiced":          totalInvoiced - creditedTotal,
+		"tax_invoiced":          taxInvoiced,
+		"tax_credited":          creditedTax,
+		"tax_collected":         taxInvoiced - creditedTax,
+		"voided_invoices":       voided,
+		"total_paid":            totalPaid,
+		"total_pending":         totalPending,
+		"total_overdue":         totalOverdue,
+		"partially_paid":        partiallyPaid,
+		"total_payments":        len(payments),
+		"total_collected":       totalCollected,
+		"total_credited":        totalCredited,
+		"total_refunded":        totalRefunded,
+		"net_collected":         totalCollected - totalRefunded,
+		"collection_rate":       0.0,
+		"total_quotes":          totalQuotes,
+		"quotes_sent":           quotesSent,
+		"quotes_accepted":       quotesAccepted,
+		"quotes_rejected":       quotesRejected,
+		"quotes_expired":        quotesExpired,
+		"quote_conversion_rate": 0.0,
 	}
 
-	if totalInvoiced > 0 {
-		analytics["collection_rate"] = (totalPaid / totalInvoiced) * 100
+	if totalInvoiced-creditedTotal > 0 {
+		analytics["collection_rate"] = (totalPaid / (totalInvoiced - creditedTotal)) * 100
+	}
+	if quotesSent > 0 {
+		analytics["quote_conversion_rate"] = float64(quotesAccepted) / float64(quotesSent) * 100
 	}
 
 	response := map[string]interface{}{
-		"bookings":  bookings,
-		"invoices":  invoices,
-		"analytics": analytics,
+		"bookings":     bookings,
+		"invoices":     invoices,
+		"payments":     payments,
+		"credit_notes": creditNotes,
+		"refunds":      refunds,
+		"analytics":    analytics,
 		"filters": map[string]interface{}{
 			"start_date": startDate,
 			"end_date":   endDate,
@@ -180,4 +422,4 @@ func SimpleGetReportsData(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, response)
-}
\ No newline at end of file
+}
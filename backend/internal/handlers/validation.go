@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"cleaning-app-backend/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindJSON decodes and validates the request body. On failure it responds
+// with 400, listing the rejected fields where possible, and returns false.
+func bindJSON(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+
+	if fields := validation.Errors(err); fields != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": fields})
+	} else if errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body is required"})
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+	}
+	return false
+}
+
+// bindQuery decodes and validates the query string like bindJSON does the body
+func bindQuery(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindQuery(req)
+	if err == nil {
+		return true
+	}
+
+	if fields := validation.Errors(err); fields != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": fields})
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+	}
+	return false
+}
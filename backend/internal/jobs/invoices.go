@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/repositories"
+	"cleaning-app-backend/internal/services"
+)
+
+// StartInvoiceJob marks past-due invoices overdue and, when LATE_FEE_PERCENT
+// is set, bills their late fees, every INVOICE_JOB_INTERVAL. Running it again
+// changes nothing until another day or month passes, so restarts and
+// replicas are safe.
+func StartInvoiceJob(cfg config.Config) error {
+	return every(cfg.InvoiceJobInterval, repositories.JobInvoiceOverdue, "Invoice job", func() {
+		runInvoiceJob(cfg)
+	})
+}
+
+func runInvoiceJob(cfg config.Config) {
+	invoiceService := services.NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+
+	marked, err := invoiceService.MarkOverdue()
+	if err != nil {
+		log.Printf("Invoice job: %v", err)
+		return
+	}
+	if marked > 0 {
+		log.Printf("Invoice job: marked %d invoices overdue", marked)
+	}
+
+	if cfg.LateFeePercent <= 0 {
+		return
+	}
+	billed, err := invoiceService.BillLateFees(cfg.LateFeePercent/100, time.Now())
+	for _, invoice := range billed {
+		log.Printf("Invoice job: billed late fees of %.2f on invoice %s (%s)", invoice.TotalAmount, invoice.InvoiceNumber, invoice.Notes)
+	}
+	if err != nil {
+		log.Printf("Invoice job: %v", err)
+	}
+}
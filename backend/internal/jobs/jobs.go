@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"cleaning-app-backend/internal/repositories"
+)
+
+// every runs a job in the background at startup and then at every interval.
+// Each run holds the job's lock, so only one API instance runs it at a time;
+// a run that finds the lock taken is skipped.
+func every(interval string, job int, name string, run func()) error {
+	period, err := time.ParseDuration(interval)
+	if err != nil {
+		return err
+	}
+
+	runLocked := func() {
+		unlock, ok, err := (&repositories.JobRepository{}).TryLock(job)
+		if err != nil {
+			log.Printf("%s: failed to take lock: %v", name, err)
+			return
+		}
+		if !ok {
+			log.Printf("%s: already running on another instance", name)
+			return
+		}
+		defer unlock()
+		run()
+	}
+
+	go func() {
+		runLocked()
+		for range time.Tick(period) {
+			runLocked()
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,19 @@
+package jobs
+
+import (
+	"log"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// StartQuoteJob marks sent quotes left unanswered past their expiry expired
+// every QUOTE_JOB_INTERVAL, rather than only when someone opens or lists
+// them
+func StartQuoteJob(cfg config.Config) error {
+	return every(cfg.QuoteJobInterval, repositories.JobQuoteExpiry, "Quote job", func() {
+		if err := (&repositories.QuoteRepository{}).ExpireQuotes(); err != nil {
+			log.Printf("Quote job: %v", err)
+		}
+	})
+}
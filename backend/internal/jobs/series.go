@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"log"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/repositories"
+	"cleaning-app-backend/internal/services"
+)
+
+// StartSeriesJob extends every recurring booking series up to the generation
+// horizon every SERIES_JOB_INTERVAL. Occurrences already generated are left
+// alone, so restarts and replicas are safe.
+func StartSeriesJob(cfg config.Config) error {
+	return every(cfg.SeriesJobInterval, repositories.JobSeriesGeneration, "Series job", func() {
+		created, err := services.NewSeriesService().GenerateAll()
+		if err != nil {
+			log.Printf("Series job: %v", err)
+			return
+		}
+		if created > 0 {
+			log.Printf("Series job: created %d occurrences", created)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+
+	"cleaning-app-backend/internal/config"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers outgoing email. Implementations must be safe to call from
+// request handlers.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// NewSender returns an SMTP sender when SMTP_HOST is configured and a sender
+// that only logs messages otherwise. Point SMTP_HOST/SMTP_PORT at a local
+// catcher such as MailHog (localhost:1025) to inspect mail in development.
+func NewSender(cfg config.Config) Sender {
+	if cfg.SMTPHost == "" {
+		return &LogSender{}
+	}
+	return &SMTPSender{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	}
+}
+
+// SMTPSender sends mail through an SMTP server. Authentication is skipped when
+// no username is configured.
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	headers := []string{
+		"From: " + s.From,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + msg.Body
+
+	addr := fmt.Sprintf("%s:%s", s.Host, s.Port)
+	return smtp.SendMail(addr, auth, s.From, []string{msg.To}, []byte(body))
+}
+
+// LogSender writes messages to the application log instead of sending them.
+type LogSender struct{}
+
+func (s *LogSender) Send(msg Message) error {
+	log.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
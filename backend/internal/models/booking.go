@@ -14,8 +14,14 @@ type Booking struct {
 	SquareMeters        float64   `json:"square_meters" db:"square_meters" validate:"required,gt=0"`
 	SpecialInstructions string    `json:"special_instructions" db:"special_instructions"`
 	TotalPrice          float64   `json:"total_price" db:"total_price" validate:"required,gt=0"`
-	Status              string    `json:"status" db:"status" validate:"required,oneof=pending confirmed in_progress completed cancelled"`
+	DurationHours       float64   `json:"duration_hours" db:"duration_hours"`
+	Status              string    `json:"status" db:"status" validate:"required,oneof=pending confirmed in_progress completed cancelled skipped no_show"`
 	InvoiceID           *int      `json:"invoice_id" db:"invoice_id"` // Link to invoice if one exists
+	SeriesID            *int       `json:"series_id" db:"series_id"`             // Recurring series the booking belongs to
+	OccurrenceDate      *time.Time `json:"occurrence_date" db:"occurrence_date"` // Date the series planned it for
+	CustomerID          *int       `json:"customer_id" db:"customer_id"`
+	PropertyID          *int       `json:"property_id" db:"property_id"`
+	PriceBreakdown      *PriceBreakdown `json:"price_breakdown" db:"price_breakdown"`
 	
 	// Guest booking information
 	GuestName           string    `json:"guest_name" db:"guest_name"`
@@ -37,29 +43,40 @@ type Booking struct {
 type BookingRequest struct {
 	UserID              *int    `json:"user_id"` // Made nullable for guest bookings
 	ServiceID           int     `json:"service_id" validate:"required"`
-	ScheduledDate       string  `json:"scheduled_date" validate:"required"`
-	ScheduledTime       string  `json:"scheduled_time" validate:"required"`
-	Address             string  `json:"address" validate:"required"`
-	SquareMeters        float64 `json:"square_meters" validate:"required,gt=0"`
+	ScheduledDate       string  `json:"scheduled_date" validate:"required,date,notpast"`
+	ScheduledTime       string  `json:"scheduled_time" validate:"required,clock"`
+	PropertyID          *int    `json:"property_id" validate:"omitempty,gt=0"` // a saved property instead of address and size
+	Address             string  `json:"address" validate:"required_without=PropertyID"`
+	SquareMeters        float64 `json:"square_meters" validate:"required_without=PropertyID,omitempty,gt=0"`
 	SpecialInstructions string  `json:"special_instructions"`
+	Bedrooms            *int     `json:"bedrooms" validate:"omitempty,gte=0"`   // defaults to the saved property's
+	Bathrooms           *float64 `json:"bathrooms" validate:"omitempty,gte=0"` // defaults to the saved property's
+	AddOns              []string `json:"add_ons"`
+	Condition           string   `json:"condition" validate:"omitempty,oneof=standard heavy extreme"`
+	PromoCode           string   `json:"promo_code"`
 	
 	// Guest booking fields
 	GuestName           string  `json:"guest_name"`
-	GuestEmail          string  `json:"guest_email"`
+	GuestEmail          string  `json:"guest_email" validate:"omitempty,email"`
 	GuestPhone          string  `json:"guest_phone"`
 }
 
 type GuestBookingRequest struct {
 	ServiceID           int     `json:"service_id" validate:"required"`
-	ScheduledDate       string  `json:"scheduled_date" validate:"required"`
-	ScheduledTime       string  `json:"scheduled_time" validate:"required"`
+	ScheduledDate       string  `json:"scheduled_date" validate:"required,date,notpast"`
+	ScheduledTime       string  `json:"scheduled_time" validate:"required,clock"`
 	Address             string  `json:"address" validate:"required"`
 	SquareMeters        float64 `json:"square_meters" validate:"required,gt=0"`
 	SpecialInstructions string  `json:"special_instructions"`
+	Bedrooms            int      `json:"bedrooms" validate:"gte=0"`
+	Bathrooms           float64  `json:"bathrooms" validate:"gte=0"`
+	AddOns              []string `json:"add_ons"`
+	Condition           string   `json:"condition" validate:"omitempty,oneof=standard heavy extreme"`
+	PromoCode           string   `json:"promo_code"`
 	GuestName           string  `json:"guest_name" validate:"required"`
 	GuestEmail          string  `json:"guest_email" validate:"required,email"`
 	GuestPhone          string  `json:"guest_phone" validate:"required"`
-	TotalPrice          float64 `json:"total_price" validate:"required,gt=0"`
+	TotalPrice          float64 `json:"total_price"` // ignored, the price is calculated from the service
 	
 	// Billing Address Information  
 	BillingAddress      string  `json:"billing_address" validate:"required"`
@@ -80,8 +97,12 @@ type BookingResponse struct {
 	SquareMeters        float64   `json:"square_meters"`
 	SpecialInstructions string    `json:"special_instructions"`
 	TotalPrice          float64   `json:"total_price"`
+	DurationHours       float64   `json:"duration_hours"`
 	Status              string    `json:"status"`
 	InvoiceID           *int      `json:"invoice_id,omitempty"` // Link to invoice if one exists
+	SeriesID            *int      `json:"series_id,omitempty"`
+	PropertyID          *int      `json:"property_id,omitempty"`
+	PriceBreakdown      *PriceBreakdown `json:"price_breakdown,omitempty"`
 	GuestName           string    `json:"guest_name,omitempty"`
 	GuestEmail          string    `json:"guest_email,omitempty"`
 	GuestPhone          string    `json:"guest_phone,omitempty"`
@@ -95,26 +116,86 @@ type BookingResponse struct {
 }
 
 type BookingUpdateRequest struct {
-	Status string `json:"status" validate:"required,oneof=pending confirmed in_progress completed cancelled"`
+	Status string `json:"status" validate:"required,oneof=pending confirmed in_progress completed cancelled skipped no_show"`
+	Reason string `json:"reason"`
 }
 
 type AdminBookingUpdateRequest struct {
-	ScheduledDate       *string  `json:"scheduled_date"`
-	ScheduledTime       *string  `json:"scheduled_time"`
+	ScheduledDate       *string  `json:"scheduled_date" validate:"omitempty,date"`
+	ScheduledTime       *string  `json:"scheduled_time" validate:"omitempty,clock"`
 	Address             *string  `json:"address"`
-	SquareMeters        *float64 `json:"square_meters"`
+	SquareMeters        *float64 `json:"square_meters" validate:"omitempty,gt=0"`
 	SpecialInstructions *string  `json:"special_instructions"`
-	TotalPrice          *float64 `json:"total_price"`
-	Status              string   `json:"status" validate:"oneof=pending confirmed in_progress completed cancelled"`
+	TotalPrice          *float64 `json:"total_price" validate:"omitempty,gte=0"`
+	Status              string   `json:"status" validate:"omitempty,oneof=pending confirmed in_progress completed cancelled skipped no_show"`
+	StatusReason        string   `json:"status_reason"`
+}
+
+// StatusActor identifies who changes a booking's status. UserID is nil for
+// guests and the system.
+type StatusActor struct {
+	UserID *int
+	Role   string
+}
+
+// Actor roles recorded in the status history besides the user roles
+const (
+	ActorGuest  = "guest"
+	ActorSystem = "system"
+)
+
+type BookingStatusChange struct {
+	ID            int       `json:"id" db:"id"`
+	BookingID     int       `json:"booking_id" db:"booking_id"`
+	FromStatus    *string   `json:"from_status" db:"from_status"`
+	ToStatus      string    `json:"to_status" db:"to_status"`
+	ChangedBy     *int      `json:"changed_by" db:"changed_by"`
+	ChangedByName string    `json:"changed_by_name,omitempty"`
+	ActorRole     string    `json:"actor_role" db:"actor_role"`
+	Reason        string    `json:"reason" db:"reason"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+type BookingRescheduleRequest struct {
+	ScheduledDate string `json:"scheduled_date" validate:"required,date,notpast"`
+	ScheduledTime string `json:"scheduled_time" validate:"required,clock"`
+	Reason        string `json:"reason"`
+}
+
+// GuestLinkRequest asks for a new guest portal link, which is only mailed if
+// the email matches the booking
+type GuestLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// BookingReschedule records a booking moving from one date and time to another
+type BookingReschedule struct {
+	ID            int       `json:"id" db:"id"`
+	BookingID     int       `json:"booking_id" db:"booking_id"`
+	FromDate      time.Time `json:"from_date" db:"from_date"`
+	FromTime      string    `json:"from_time" db:"from_time"`
+	ToDate        time.Time `json:"to_date" db:"to_date"`
+	ToTime        string    `json:"to_time" db:"to_time"`
+	ChangedBy     *int      `json:"changed_by" db:"changed_by"`
+	ChangedByName string    `json:"changed_by_name,omitempty"`
+	ActorRole     string    `json:"actor_role" db:"actor_role"`
+	Reason        string    `json:"reason" db:"reason"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 }
 
 // New Quote system
 type QuoteRequest struct {
 	ServiceID           int     `json:"service_id" validate:"required"`
 	SquareMeters        float64 `json:"square_meters" validate:"required,gt=0"`
+	Bedrooms            int      `json:"bedrooms" validate:"gte=0"`
+	Bathrooms           float64  `json:"bathrooms" validate:"gte=0"`
+	AddOns              []string `json:"add_ons"`
+	Condition           string   `json:"condition" validate:"omitempty,oneof=standard heavy extreme"`
+	Frequency           string   `json:"frequency" validate:"omitempty,oneof=weekly biweekly monthly"` // recurring cleaning being asked about
+	PromoCode           string   `json:"promo_code"`
 	Address             string  `json:"address"`
 	SpecialRequirements string  `json:"special_requirements"`
-	PreferredDate       string  `json:"preferred_date"`
+	PreferredDate       string  `json:"preferred_date" validate:"omitempty,date,notpast"`
 	ContactEmail        string  `json:"contact_email" validate:"required,email"`
 	ContactName         string  `json:"contact_name" validate:"required"`
 	ContactPhone        string  `json:"contact_phone"`
@@ -132,8 +213,13 @@ type QuoteResponse struct {
 	ContactName         string  `json:"contact_name"`
 	ContactPhone        string  `json:"contact_phone"`
 	EstimatedPrice      float64 `json:"estimated_price"`
+	PriceBreakdown      *PriceBreakdown `json:"price_breakdown,omitempty"`
 	Status              string  `json:"status"`
 	AdminNotes          string  `json:"admin_notes,omitempty"`
+	SentAt              *time.Time `json:"sent_at,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+	RejectionReason     string  `json:"rejection_reason,omitempty"`
+	BookingID           *int    `json:"booking_id,omitempty"`
 	CreatedAt           time.Time `json:"created_at"`
 }
 
@@ -148,8 +234,65 @@ type Quote struct {
 	ContactName         string    `json:"contact_name" db:"contact_name"`
 	ContactPhone        string    `json:"contact_phone" db:"contact_phone"`
 	EstimatedPrice      float64   `json:"estimated_price" db:"estimated_price"`
-	Status              string    `json:"status" db:"status"` // pending, sent, accepted, rejected
+	Status              string    `json:"status" db:"status"` // pending, sent, accepted, rejected, expired
 	AdminNotes          string    `json:"admin_notes" db:"admin_notes"`
+	UserID              *int      `json:"user_id" db:"user_id"`
+	CustomerID          *int      `json:"customer_id" db:"customer_id"`
+	PropertyID          *int      `json:"property_id" db:"property_id"`
+	PriceBreakdown      *PriceBreakdown `json:"price_breakdown" db:"price_breakdown"`
+	SentAt              *time.Time `json:"sent_at" db:"sent_at"`
+	ExpiresAt           *time.Time `json:"expires_at" db:"expires_at"`
+	RespondedAt         *time.Time `json:"responded_at" db:"responded_at"`
+	RejectionReason     string    `json:"rejection_reason" db:"rejection_reason"`
+	BookingID           *int      `json:"booking_id" db:"booking_id"`
 	CreatedAt           time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
-}
\ No newline at end of file
+}
+
+// Quote statuses. A quote is sent with a link the customer accepts or rejects
+// it through; a sent quote left unanswered expires.
+const (
+	QuoteStatusPending  = "pending"
+	QuoteStatusSent     = "sent"
+	QuoteStatusAccepted = "accepted"
+	QuoteStatusRejected = "rejected"
+	QuoteStatusExpired  = "expired"
+)
+
+// QuoteAcceptRequest books the quoted job in the slot the customer picked
+type QuoteAcceptRequest struct {
+	ScheduledDate       string `json:"scheduled_date" validate:"required,date,notpast"`
+	ScheduledTime       string `json:"scheduled_time" validate:"required,clock"`
+	SpecialInstructions string `json:"special_instructions"`
+}
+
+type QuoteRejectRequest struct {
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+// CleanerJob is the view of a booking given to the staff assigned to it
+type CleanerJob struct {
+	ID                  int       `json:"id"`
+	ServiceName         string    `json:"service_name"`
+	ScheduledDate       time.Time `json:"scheduled_date"`
+	ScheduledTime       string    `json:"scheduled_time"`
+	Address             string    `json:"address"`
+	SquareMeters        float64   `json:"square_meters"`
+	SpecialInstructions string    `json:"special_instructions"`
+	Status              string    `json:"status"`
+	CustomerName        string    `json:"customer_name"`
+	CustomerPhone       string    `json:"customer_phone"`
+	AccessNotes         string    `json:"access_notes"`
+	GateCode            string    `json:"gate_code"`
+	Pets                string    `json:"pets"`
+}
+
+type CleanerJobStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=in_progress completed no_show"`
+}
+
+// BookingAssignmentRequest selects cleaners individually, by crew, or both
+type BookingAssignmentRequest struct {
+	UserIDs []int `json:"user_ids" validate:"dive,gt=0"`
+	CrewID  *int  `json:"crew_id" validate:"omitempty,gt=0"`
+}
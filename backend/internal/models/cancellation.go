@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// CancellationPolicy sets what a customer pays for cancelling late or not
+// being there when the crew arrives, and how late they may still reschedule.
+// Fees are a percentage of the booking price.
+type CancellationPolicy struct {
+	FreeCancellationHours      int       `json:"free_cancellation_hours" db:"free_cancellation_hours"`
+	LateCancellationFeePercent float64   `json:"late_cancellation_fee_percent" db:"late_cancellation_fee_percent"`
+	NoShowFeePercent           float64   `json:"no_show_fee_percent" db:"no_show_fee_percent"`
+	RescheduleCutoffHours      int       `json:"reschedule_cutoff_hours" db:"reschedule_cutoff_hours"`
+	UpdatedAt                  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CancellationPolicyRequest struct {
+	FreeCancellationHours      *int     `json:"free_cancellation_hours" validate:"required,gte=0"`
+	LateCancellationFeePercent *float64 `json:"late_cancellation_fee_percent" validate:"required,gte=0,lte=100"`
+	NoShowFeePercent           *float64 `json:"no_show_fee_percent" validate:"required,gte=0,lte=100"`
+	RescheduleCutoffHours      *int     `json:"reschedule_cutoff_hours" validate:"required,gte=0"`
+}
+
+// CancellationFee is the fee charged for a cancelled or missed booking.
+// InvoiceID is the invoice the fee was billed on, if any.
+type CancellationFee struct {
+	Amount    float64 `json:"amount"`
+	Percent   float64 `json:"percent"`
+	InvoiceID *int    `json:"invoice_id,omitempty"`
+}
@@ -17,6 +17,7 @@ type ContactMessage struct {
 	Category    string    `json:"category" db:"category"` // general, booking, complaint, compliment, other
 	AdminNotes  string    `json:"admin_notes" db:"admin_notes"`
 	AssignedTo  *int      `json:"assigned_to" db:"assigned_to"` // admin user ID
+	CustomerID  *int      `json:"customer_id" db:"customer_id"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -47,8 +48,8 @@ type ContactMessageResponse struct {
 }
 
 type ContactMessageUpdate struct {
-	Status     string `json:"status" validate:"oneof=new read replied closed"`
-	Priority   string `json:"priority" validate:"oneof=low medium high urgent"`
+	Status     string `json:"status" validate:"required,oneof=new read replied closed"`
+	Priority   string `json:"priority" validate:"required,oneof=low medium high urgent"`
 	AdminNotes string `json:"admin_notes"`
 	AssignedTo *int   `json:"assigned_to"`
 }
@@ -61,6 +62,7 @@ type FAQ struct {
 	Category    string    `json:"category" db:"category"`
 	IsActive    bool      `json:"is_active" db:"is_active"`
 	DisplayOrder int      `json:"display_order" db:"display_order"`
+	ArchivedAt  *time.Time `json:"archived_at" db:"archived_at"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -79,4 +81,5 @@ type FAQResponse struct {
 	Category    string `json:"category"`
 	IsActive    bool   `json:"is_active"`
 	DisplayOrder int   `json:"display_order"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
 }
\ No newline at end of file
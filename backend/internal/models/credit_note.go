@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// CreditNote corrects an issued invoice by crediting some or all of it.
+// Credit notes are numbered on their own and can't be changed once issued.
+type CreditNote struct {
+	ID               int              `json:"id" db:"id"`
+	CreditNoteNumber string           `json:"credit_note_number" db:"credit_note_number"`
+	InvoiceID        int              `json:"invoice_id" db:"invoice_id"`
+	InvoiceNumber    string           `json:"invoice_number" db:"invoice_number"`
+	IssueDate        time.Time        `json:"issue_date" db:"issue_date"`
+	Reason           string           `json:"reason" db:"reason"`
+	Subtotal         float64          `json:"subtotal" db:"subtotal"`
+	TaxRate          float64          `json:"tax_rate" db:"tax_rate"`
+	TaxAmount        float64          `json:"tax_amount" db:"tax_amount"`
+	TotalAmount      float64          `json:"total_amount" db:"total_amount"`
+	CreatedBy        *int             `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
+	Items            []CreditNoteItem `json:"items"`
+}
+
+type CreditNoteItem struct {
+	ID           int     `json:"id" db:"id"`
+	CreditNoteID int     `json:"credit_note_id" db:"credit_note_id"`
+	Description  string  `json:"description" db:"description"`
+	Quantity     float64 `json:"quantity" db:"quantity"`
+	UnitPrice    float64 `json:"unit_price" db:"unit_price"`
+	TotalPrice   float64 `json:"total_price" db:"total_price"`
+	Taxable      bool    `json:"taxable" db:"taxable"`
+}
+
+// CreditNoteRequest issues a credit note. A full one credits whatever of the
+// invoice has not been credited yet; otherwise the items say what is
+// credited, taxed at the invoice's rate.
+type CreditNoteRequest struct {
+	Reason string                     `json:"reason" validate:"required"`
+	Full   bool                       `json:"full"`
+	Items  []InvoiceItemCreateRequest `json:"items" validate:"required_without=Full,dive"`
+}
+
+// CreditNoteDocument is the PDF of a credit note, rendered once
+type CreditNoteDocument struct {
+	CreditNoteID int       `json:"credit_note_id" db:"credit_note_id"`
+	PDF          []byte    `json:"-" db:"pdf"`
+	SHA256       string    `json:"sha256" db:"sha256"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
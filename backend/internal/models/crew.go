@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+type Crew struct {
+	ID        int        `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Color     string     `json:"color" db:"color"`
+	IsActive  bool       `json:"is_active" db:"is_active"`
+	Members   []Assignee `json:"members"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CrewRequest struct {
+	Name     string `json:"name" validate:"required"`
+	Color    string `json:"color" validate:"omitempty,hexcolor"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type CrewMembersRequest struct {
+	UserIDs []int `json:"user_ids" validate:"dive,gt=0"`
+}
+
+// Assignee is a cleaner working a booking, optionally as part of a crew
+type Assignee struct {
+	UserID   int    `json:"user_id"`
+	Name     string `json:"name"`
+	CrewID   *int   `json:"crew_id,omitempty"`
+	CrewName string `json:"crew_name,omitempty"`
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"time"
+)
+
+// Customer is everyone the business works for, with or without an account.
+// UserID links the client account, if any. A customer merged into another
+// keeps its record with MergedIntoID set.
+type Customer struct {
+	ID              int       `json:"id" db:"id"`
+	UserID          *int      `json:"user_id" db:"user_id"`
+	Name            string    `json:"name" db:"name"`
+	Company         string    `json:"company" db:"company"`
+	Email           string    `json:"email" db:"email"`
+	Phone           string    `json:"phone" db:"phone"`
+	BillingAddress  string    `json:"billing_address" db:"billing_address"`
+	BillingCity     string    `json:"billing_city" db:"billing_city"`
+	BillingState    string    `json:"billing_state" db:"billing_state"`
+	BillingZipCode  string    `json:"billing_zip_code" db:"billing_zip_code"`
+	BillingCountry  string    `json:"billing_country" db:"billing_country"`
+	TaxExempt       bool      `json:"tax_exempt" db:"tax_exempt"`
+	TaxExemptReason string    `json:"tax_exempt_reason" db:"tax_exempt_reason"`
+	Tags            []string  `json:"tags" db:"tags"`
+	MergedIntoID    *int      `json:"merged_into_id" db:"merged_into_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CustomerSummary is a customer in search results
+type CustomerSummary struct {
+	Customer
+	BookingCount int        `json:"booking_count"`
+	LastBooking  *time.Time `json:"last_booking"`
+}
+
+// CustomerDetail is a customer with everything recorded about them
+type CustomerDetail struct {
+	Customer
+	Contacts    []CustomerContact  `json:"contacts"`
+	Properties  []CustomerProperty `json:"properties"`
+	Notes       []CustomerNote     `json:"notes"`
+	Stats       CustomerStats      `json:"stats"`
+}
+
+type CustomerStats struct {
+	Bookings       int     `json:"bookings"`
+	Completed      int     `json:"completed"`
+	Quotes         int     `json:"quotes"`
+	TotalBilled    float64 `json:"total_billed"`
+	TotalPaid      float64 `json:"total_paid"`
+	Outstanding    float64 `json:"outstanding"`
+	Credit         float64 `json:"credit"`
+}
+
+// CustomerCredit is credit a customer earned by overpaying an invoice
+// (positive) or spent paying one (negative)
+type CustomerCredit struct {
+	ID          int       `json:"id" db:"id"`
+	CustomerID  int       `json:"customer_id" db:"customer_id"`
+	Amount      float64   `json:"amount" db:"amount"`
+	PaymentID   *int      `json:"payment_id" db:"payment_id"`
+	InvoiceID   *int      `json:"invoice_id" db:"invoice_id"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type CustomerContact struct {
+	ID         int       `json:"id" db:"id"`
+	CustomerID int       `json:"customer_id" db:"customer_id"`
+	Name       string    `json:"name" db:"name"`
+	Email      string    `json:"email" db:"email"`
+	Phone      string    `json:"phone" db:"phone"`
+	Label      string    `json:"label" db:"label"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// CustomerProperty is a place the customer has cleaned. Address is the
+// one-line form of street, unit, city, state and zip that is copied onto
+// bookings, quotes and invoices. Properties saved from a typed address have
+// only the street until someone fills in the rest.
+type CustomerProperty struct {
+	ID           int       `json:"id" db:"id"`
+	CustomerID   int       `json:"customer_id" db:"customer_id"`
+	Label        string    `json:"label" db:"label"`
+	Street       string    `json:"street" db:"street"`
+	Unit         string    `json:"unit" db:"unit"`
+	City         string    `json:"city" db:"city"`
+	State        string    `json:"state" db:"state"`
+	ZipCode      string    `json:"zip_code" db:"zip_code"`
+	Address      string    `json:"address" db:"address"`
+	AccessNotes  string    `json:"access_notes" db:"access_notes"`
+	GateCode     string    `json:"gate_code" db:"gate_code"`
+	Pets         string    `json:"pets" db:"pets"`
+	SquareMeters *float64  `json:"square_meters" db:"square_meters"`
+	Bedrooms     *int      `json:"bedrooms" db:"bedrooms"`
+	Bathrooms    *float64  `json:"bathrooms" db:"bathrooms"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CustomerNote struct {
+	ID         int       `json:"id" db:"id"`
+	CustomerID int       `json:"customer_id" db:"customer_id"`
+	AuthorID   *int      `json:"author_id" db:"author_id"`
+	AuthorName string    `json:"author_name"`
+	Body       string    `json:"body" db:"body"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// TimelineEvent is one entry of a customer's history. Type says what RefID
+// points at: booking, status, reschedule, quote, invoice, payment, message or
+// note.
+type TimelineEvent struct {
+	Type    string    `json:"type"`
+	RefID   int       `json:"ref_id"`
+	At      time.Time `json:"at"`
+	Summary string    `json:"summary"`
+}
+
+type CustomerRequest struct {
+	Name            string   `json:"name" validate:"required"`
+	Company         string   `json:"company"`
+	Email           string   `json:"email" validate:"omitempty,email"`
+	Phone           string   `json:"phone"`
+	BillingAddress  string   `json:"billing_address"`
+	BillingCity     string   `json:"billing_city"`
+	BillingState    string   `json:"billing_state"`
+	BillingZipCode  string   `json:"billing_zip_code"`
+	BillingCountry  string   `json:"billing_country"`
+	TaxExempt       bool     `json:"tax_exempt"`
+	TaxExemptReason string   `json:"tax_exempt_reason"`
+	Tags            []string `json:"tags" validate:"dive,required,max=50"`
+}
+
+type CustomerContactRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email" validate:"required_without=Phone,omitempty,email"`
+	Phone string `json:"phone" validate:"required_without=Email"`
+	Label string `json:"label"`
+}
+
+type CustomerPropertyRequest struct {
+	Label        string   `json:"label" validate:"max=100"`
+	Street       string   `json:"street" validate:"required,max=255"`
+	Unit         string   `json:"unit" validate:"max=50"`
+	City         string   `json:"city" validate:"required,max=100"`
+	State        string   `json:"state" validate:"required,max=50"`
+	ZipCode      string   `json:"zip_code" validate:"required,max=20"`
+	AccessNotes  string   `json:"access_notes"`
+	GateCode     string   `json:"gate_code" validate:"max=100"`
+	Pets         string   `json:"pets"`
+	SquareMeters *float64 `json:"square_meters" validate:"omitempty,gt=0"`
+	Bedrooms     *int     `json:"bedrooms" validate:"omitempty,gte=0"`
+	Bathrooms    *float64 `json:"bathrooms" validate:"omitempty,gte=0"`
+}
+
+type CustomerNoteRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+// CustomerMergeRequest names the duplicate folded into the customer
+type CustomerMergeRequest struct {
+	DuplicateID int `json:"duplicate_id" validate:"required,gt=0"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// GuestClaim records guest history linked to an account: how many bookings,
+// quotes and invoices made with Email were claimed. ClaimedBy is the admin who
+// merged them, nil when the account holder claimed their own verified email.
+type GuestClaim struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Email     string    `json:"email" db:"email"`
+	Bookings  int       `json:"bookings" db:"bookings"`
+	Quotes    int       `json:"quotes" db:"quotes"`
+	Invoices  int       `json:"invoices" db:"invoices"`
+	ClaimedBy *int      `json:"claimed_by" db:"claimed_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// GuestMergeRequest names the email whose guest history an admin merges into
+// an account
+type GuestMergeRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
@@ -8,6 +8,8 @@ import (
 type Invoice struct {
 	ID                 int       `json:"id" db:"id"`
 	BookingID          int       `json:"booking_id" db:"booking_id"`
+	Kind               string    `json:"kind" db:"kind"` // booking, fee, late_fee
+	ParentInvoiceID    *int      `json:"parent_invoice_id,omitempty" db:"parent_invoice_id"` // the invoice a late-fee invoice charges for
 	InvoiceNumber      string    `json:"invoice_number" db:"invoice_number"`
 	IssueDate          time.Time `json:"issue_date" db:"issue_date"`
 	DueDate            time.Time `json:"due_date" db:"due_date"`
@@ -37,7 +39,7 @@ type Invoice struct {
 	TotalAmount        float64   `json:"total_amount" db:"total_amount"`
 	
 	// Payment Information
-	Status             string    `json:"status" db:"status"` // pending, paid, overdue, cancelled
+	Status             string    `json:"status" db:"status"` // pending, partially_paid, paid, overdue, credited, void
 	PaymentMethod      string    `json:"payment_method" db:"payment_method"` // cash, check, credit_card, bank_transfer
 	PaymentDate        *time.Time `json:"payment_date" db:"payment_date"`
 	PaymentReference   string    `json:"payment_reference" db:"payment_reference"`
@@ -51,6 +53,13 @@ type Invoice struct {
 	Notes              string    `json:"notes" db:"notes"`
 	Terms              string    `json:"terms" db:"terms"`
 	
+	// Archived invoices are kept for the records but left out of lists
+	ArchivedAt         *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+	
+	// Voided invoices are kept but no longer owed
+	VoidedAt           *time.Time `json:"voided_at,omitempty" db:"voided_at"`
+	VoidReason         string    `json:"void_reason,omitempty" db:"void_reason"`
+	
 	CreatedAt          time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -72,6 +81,95 @@ type InvoiceResponse struct {
 	Items       []InvoiceItem `json:"items"`
 	ServiceName string        `json:"service_name"`
 	BookingDate time.Time     `json:"booking_date"`
+
+	// Payments received net of refunds, credit notes issued, and what they
+	// leave owing. A negative balance is owed back to the customer.
+	Payments       []Payment    `json:"payments"`
+	CreditNotes    []CreditNote `json:"credit_notes"`
+	AmountPaid     float64      `json:"amount_paid"`
+	AmountCredited float64      `json:"amount_credited"`
+	BalanceDue     float64      `json:"balance_due"`
+
+	// Invoices billing late fees on this one
+	LateFeeInvoices []LateFeeInvoice `json:"late_fee_invoices"`
+}
+
+// LateFeeInvoice is an invoice billing late fees on another, as listed on it
+type LateFeeInvoice struct {
+	ID            int       `json:"id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	IssueDate     time.Time `json:"issue_date"`
+	TotalAmount   float64   `json:"total_amount"`
+	Status        string    `json:"status"`
+	BalanceDue    float64   `json:"balance_due"`
+}
+
+// Payment is money received for an invoice. Credited is the part of it that
+// was more than the invoice owed and went to the customer's credit.
+type Payment struct {
+	ID         int       `json:"id" db:"id"`
+	InvoiceID  int       `json:"invoice_id" db:"invoice_id"`
+	Amount     float64   `json:"amount" db:"amount"`
+	Credited   float64   `json:"credited" db:"credited"`
+	Method     string    `json:"method" db:"method"`
+	Reference  string    `json:"reference" db:"reference"`
+	PaidAt     time.Time `json:"paid_at" db:"paid_at"`
+	Notes      string    `json:"notes" db:"notes"`
+	RecordedBy *int      `json:"recorded_by,omitempty" db:"recorded_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+
+	Refunds  []Refund `json:"refunds"`
+	Refunded float64  `json:"refunded"`
+}
+
+// Refund is money given back from a payment. The credit method gives it to
+// the customer's credit instead.
+type Refund struct {
+	ID         int       `json:"id" db:"id"`
+	PaymentID  int       `json:"payment_id" db:"payment_id"`
+	Amount     float64   `json:"amount" db:"amount"`
+	Method     string    `json:"method" db:"method"`
+	Reference  string    `json:"reference" db:"reference"`
+	Reason     string    `json:"reason" db:"reason"`
+	RefundedAt time.Time `json:"refunded_at" db:"refunded_at"`
+	RecordedBy *int      `json:"recorded_by,omitempty" db:"recorded_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// RefundRequest records a refund of a payment
+type RefundRequest struct {
+	Amount     float64    `json:"amount" validate:"required,gt=0"`
+	Method     string     `json:"method" validate:"required,max=50"`
+	Reference  string     `json:"reference" validate:"max=100"`
+	Reason     string     `json:"reason" validate:"required"`
+	RefundedAt *time.Time `json:"refunded_at"`
+}
+
+// VoidRequest voids an invoice that should not have been issued
+type VoidRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// PaymentRequest records a payment. Without an amount it pays the balance
+// due; the credit method pays from the customer's credit.
+type PaymentRequest struct {
+	Amount    float64    `json:"amount" validate:"gte=0"`
+	Method    string     `json:"method" validate:"required,max=50"`
+	Reference string     `json:"reference" validate:"max=100"`
+	PaidAt    *time.Time `json:"paid_at"`
+	Notes     string     `json:"notes"`
+}
+
+// InvoiceDocument is a rendered PDF of an invoice. Versions are never
+// changed; a new one is stored when the invoice changes.
+type InvoiceDocument struct {
+	ID               int       `json:"id" db:"id"`
+	InvoiceID        int       `json:"invoice_id" db:"invoice_id"`
+	Version          int       `json:"version" db:"version"`
+	PDF              []byte    `json:"-" db:"pdf"`
+	SHA256           string    `json:"sha256" db:"sha256"`
+	InvoiceUpdatedAt time.Time `json:"invoice_updated_at" db:"invoice_updated_at"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 }
 
 // InvoiceCreateRequest represents the request to create an invoice
@@ -86,7 +184,7 @@ type InvoiceCreateRequest struct {
 	TaxExemptReason    string                     `json:"tax_exempt_reason"`
 	PaymentMethod      string                     `json:"payment_method"`
 	Notes              string                     `json:"notes"`
-	Items              []InvoiceItemCreateRequest `json:"items"`
+	Items              []InvoiceItemCreateRequest `json:"items" validate:"dive"`
 }
 
 // InvoiceItemCreateRequest represents individual items for invoice creation
@@ -99,13 +197,19 @@ type InvoiceItemCreateRequest struct {
 
 // InvoiceUpdateRequest represents updates to an invoice
 type InvoiceUpdateRequest struct {
-	Status           string     `json:"status" validate:"omitempty,oneof=pending paid overdue cancelled"`
+	Status           string     `json:"status" validate:"omitempty,oneof=pending paid overdue cancelled void"`
 	PaymentMethod    string     `json:"payment_method"`
 	PaymentDate      *time.Time `json:"payment_date"`
 	PaymentReference string     `json:"payment_reference"`
 	Notes            string     `json:"notes"`
 }
 
+// InvoicePaymentRequest pays an invoice online. PaymentToken comes from the
+// payment gateway's client side library.
+type InvoicePaymentRequest struct {
+	PaymentToken string `json:"payment_token" validate:"required"`
+}
+
 // Florida tax rates and settings
 const (
 	FloridaStateTaxRate     = 0.06    // 6% Florida state sales tax
@@ -116,9 +220,20 @@ const (
 // Invoice statuses
 const (
 	InvoiceStatusPending  = "pending"
+	InvoiceStatusPartiallyPaid = "partially_paid"
 	InvoiceStatusPaid     = "paid"
 	InvoiceStatusOverdue  = "overdue"
 	InvoiceStatusCancelled = "cancelled"
+	InvoiceStatusCredited = "credited" // settled by credit notes, nothing paid
+	InvoiceStatusVoid     = "void"
+)
+
+// Invoice kinds. A booking has one invoice of its own; fees and late fees
+// are billed on invoices of their own for the same booking.
+const (
+	InvoiceKindBooking = "booking"
+	InvoiceKindFee     = "fee"
+	InvoiceKindLateFee = "late_fee"
 )
 
 // Payment methods
@@ -127,4 +242,5 @@ const (
 	PaymentMethodCheck       = "check"
 	PaymentMethodCreditCard  = "credit_card"
 	PaymentMethodBankTransfer = "bank_transfer"
+	PaymentMethodCredit       = "credit" // from the customer's credit
 )
\ No newline at end of file
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Document types that are numbered
+const (
+	DocumentTypeInvoice    = "invoice"
+	DocumentTypeCreditNote = "credit_note"
+)
+
+// How often a numbering sequence starts again at 1
+const (
+	NumberingResetYearly  = "yearly"
+	NumberingResetMonthly = "monthly"
+	NumberingResetNever   = "never"
+)
+
+// NumberingScheme says how a type of document is numbered. Format is a
+// template: {YYYY} and {YY} are the year of issue, {MM} the month and {SEQ}
+// the sequence number, zero padded to n digits with {SEQ:n}.
+type NumberingScheme struct {
+	DocumentType string    `json:"document_type" db:"document_type"`
+	Format       string    `json:"format" db:"format"`
+	Reset        string    `json:"reset" db:"reset"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+
+	// What the first number issued today would look like
+	Example string `json:"example" db:"-"`
+}
+
+type NumberingSchemeRequest struct {
+	Format string `json:"format" validate:"required,max=40"`
+	Reset  string `json:"reset" validate:"required,oneof=yearly monthly never"`
+}
+
+var numberingToken = regexp.MustCompile(`\{([A-Z]+)(?::([0-9]))?\}`)
+
+// Validate checks that the format has one sequence number and that numbers
+// from different periods can't collide
+func (s NumberingScheme) Validate() error {
+	counts := map[string]int{}
+	for _, match := range numberingToken.FindAllStringSubmatch(s.Format, -1) {
+		switch match[1] {
+		case "YYYY", "YY", "MM":
+			if match[2] != "" {
+				return fmt.Errorf("{%s} takes no width", match[1])
+			}
+		case "SEQ":
+		default:
+			return fmt.Errorf("unknown placeholder {%s}", match[1])
+		}
+		counts[match[1]]++
+	}
+	if strings.ContainsAny(numberingToken.ReplaceAllString(s.Format, ""), "{}") {
+		return errors.New("format has an invalid placeholder")
+	}
+
+	if counts["SEQ"] != 1 {
+		return errors.New("format must contain {SEQ} once")
+	}
+	hasYear := counts["YYYY"]+counts["YY"] > 0
+	switch s.Reset {
+	case NumberingResetYearly:
+		if !hasYear {
+			return errors.New("yearly numbering needs {YYYY} or {YY} in the format")
+		}
+	case NumberingResetMonthly:
+		if !hasYear || counts["MM"] == 0 {
+			return errors.New("monthly numbering needs the year and {MM} in the format")
+		}
+	case NumberingResetNever:
+	default:
+		return errors.New("reset must be yearly, monthly or never")
+	}
+	return nil
+}
+
+// Period is the sequence a document issued at t is numbered in
+func (s NumberingScheme) Period(t time.Time) string {
+	switch s.Reset {
+	case NumberingResetYearly:
+		return t.Format("2006")
+	case NumberingResetMonthly:
+		return t.Format("2006-01")
+	}
+	return ""
+}
+
+// Number formats the seq'th number of the period of t
+func (s NumberingScheme) Number(t time.Time, seq int) string {
+	return numberingToken.ReplaceAllStringFunc(s.Format, func(token string) string {
+		match := numberingToken.FindStringSubmatch(token)
+		switch match[1] {
+		case "YYYY":
+			return t.Format("2006")
+		case "YY":
+			return t.Format("06")
+		case "MM":
+			return t.Format("01")
+		}
+		width, _ := strconv.Atoi(match[2])
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
+
+// Pattern is a regular expression matching the numbers of the period of t,
+// capturing their sequence number
+func (s NumberingScheme) Pattern(t time.Time) string {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range numberingToken.FindAllStringSubmatchIndex(s.Format, -1) {
+		pattern.WriteString(regexp.QuoteMeta(s.Format[last:loc[0]]))
+		last = loc[1]
+
+		// Within a period, the parts of the date finer than the reset
+		// vary from number to number
+		switch s.Format[loc[2]:loc[3]] {
+		case "YYYY":
+			pattern.WriteString(datePattern(t, "2006", "[0-9]{4}", s.Reset != NumberingResetNever))
+		case "YY":
+			pattern.WriteString(datePattern(t, "06", "[0-9]{2}", s.Reset != NumberingResetNever))
+		case "MM":
+			pattern.WriteString(datePattern(t, "01", "[0-9]{2}", s.Reset == NumberingResetMonthly))
+		default:
+			pattern.WriteString("([0-9]+)")
+		}
+	}
+	pattern.WriteString(regexp.QuoteMeta(s.Format[last:]))
+	pattern.WriteString("$")
+	return pattern.String()
+}
+
+func datePattern(t time.Time, layout, wildcard string, fixed bool) string {
+	if fixed {
+		return t.Format(layout)
+	}
+	return wildcard
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Job conditions, each priced with its own multiplier
+const (
+	ConditionStandard = "standard"
+	ConditionHeavy    = "heavy"
+	ConditionExtreme  = "extreme"
+)
+
+// Price line types
+const (
+	PriceLineSize      = "size"
+	PriceLineBedrooms  = "bedrooms"
+	PriceLineBathrooms = "bathrooms"
+	PriceLineAddOn     = "add_on"
+)
+
+// ServicePricing holds the pricing rules of a service. Without size tiers the
+// size is charged at the base price per 50 square meters; without a minimum
+// charge the base price is the minimum.
+type ServicePricing struct {
+	ServiceID        int        `json:"service_id" db:"service_id"`
+	PricePerBedroom  float64    `json:"price_per_bedroom" db:"price_per_bedroom"`
+	PricePerBathroom float64    `json:"price_per_bathroom" db:"price_per_bathroom"`
+	MinimumCharge    *float64   `json:"minimum_charge" db:"minimum_charge"`
+	SizeTiers        []SizeTier `json:"size_tiers"`
+	UpdatedAt        *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SizeTier is the flat price of a job up to a size. Jobs larger than the
+// largest tier pay its price pro rata.
+type SizeTier struct {
+	UpToSquareMeters float64 `json:"up_to_square_meters" db:"up_to_square_meters" validate:"gt=0"`
+	Price            float64 `json:"price" db:"price" validate:"gte=0"`
+}
+
+type ServicePricingRequest struct {
+	PricePerBedroom  float64    `json:"price_per_bedroom" validate:"gte=0"`
+	PricePerBathroom float64    `json:"price_per_bathroom" validate:"gte=0"`
+	MinimumCharge    *float64   `json:"minimum_charge" validate:"omitempty,gte=0"`
+	SizeTiers        []SizeTier `json:"size_tiers" validate:"dive"`
+}
+
+// AddOn is an extra task priced on top of the service, such as the inside of
+// the fridge, which also makes the job longer. Code is what requests refer to
+// it by. It can only be booked with the services that allow it.
+type AddOn struct {
+	ID            int       `json:"id" db:"id"`
+	Code          string    `json:"code" db:"code"`
+	Name          string    `json:"name" db:"name"`
+	Price         float64   `json:"price" db:"price"`
+	DurationHours float64   `json:"duration_hours" db:"duration_hours"`
+	Active        bool      `json:"active" db:"active"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type AddOnRequest struct {
+	Code          string  `json:"code" validate:"required,max=50"`
+	Name          string  `json:"name" validate:"required,max=100"`
+	Price         float64 `json:"price" validate:"gte=0"`
+	DurationHours float64 `json:"duration_hours" validate:"gte=0"`
+	Active        *bool   `json:"active"`
+}
+
+// PriceInput is what a job is priced on
+type PriceInput struct {
+	ServiceID    int
+	SquareMeters float64
+	Bedrooms     int
+	Bathrooms    float64
+	AddOns       []string
+	Condition    string
+	Frequency    string // recurring frequency, if any
+}
+
+// PriceBreakdown itemizes a price. Lines are added up to the subtotal, which
+// the condition multiplier adjusts; the result is raised to the minimum
+// charge if below it, the frequency discount is taken off, and a promo code
+// comes off last.
+type PriceBreakdown struct {
+	Lines               []PriceLine `json:"lines"`
+	Subtotal            float64     `json:"subtotal"`
+	Condition           string      `json:"condition"`
+	ConditionMultiplier float64     `json:"condition_multiplier"`
+	ConditionAdjustment float64     `json:"condition_adjustment"`
+	MinimumCharge       float64     `json:"minimum_charge"`
+	MinimumAdjustment   float64     `json:"minimum_adjustment"`
+	Frequency           string      `json:"frequency,omitempty"`
+	DiscountPercent     float64     `json:"discount_percent"`
+	Discount            float64     `json:"discount"`
+	PromoCode           string      `json:"promo_code,omitempty"`
+	PromoDiscount       float64     `json:"promo_discount,omitempty"`
+	AddOnHours          float64     `json:"add_on_hours,omitempty"` // time the add-ons add to the job
+	Total               float64     `json:"total"`
+}
+
+type PriceLine struct {
+	Type        string  `json:"type"`
+	Code        string  `json:"code,omitempty"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
+
+// Value stores the breakdown as JSONB
+func (b PriceBreakdown) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan reads the breakdown from JSONB
+func (b *PriceBreakdown) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, b)
+	case string:
+		return json.Unmarshal([]byte(data), b)
+	}
+	return errors.New("unsupported price breakdown type")
+}
+
+// EstimateRequest prices a job without booking it. It is read from the query
+// string; add-ons are repeated, e.g. ?add_ons=windows&add_ons=laundry.
+type EstimateRequest struct {
+	ServiceID    int      `json:"service_id" form:"service_id" validate:"required"`
+	SquareMeters float64  `json:"square_meters" form:"square_meters" validate:"required,gt=0"`
+	Bedrooms     int      `json:"bedrooms" form:"bedrooms" validate:"gte=0"`
+	Bathrooms    float64  `json:"bathrooms" form:"bathrooms" validate:"gte=0"`
+	AddOns       []string `json:"add_ons" form:"add_ons"`
+	Condition    string   `json:"condition" form:"condition" validate:"omitempty,oneof=standard heavy extreme"`
+	Frequency    string   `json:"frequency" form:"frequency" validate:"omitempty,oneof=weekly biweekly monthly"`
+	PromoCode    string   `json:"promo_code" form:"promo_code"`
+}
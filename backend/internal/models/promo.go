@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// Promo code discount types
+const (
+	PromoPercent = "percent"
+	PromoFixed   = "fixed"
+)
+
+// PromoCode is a discount customers enter when booking. Limits left nil are
+// unlimited, and a code without service IDs applies to every service. Uses
+// counts redemptions on bookings that weren't cancelled.
+type PromoCode struct {
+	ID                  int        `json:"id" db:"id"`
+	Code                string     `json:"code" db:"code"`
+	Description         string     `json:"description" db:"description"`
+	DiscountType        string     `json:"discount_type" db:"discount_type"`
+	DiscountValue       float64    `json:"discount_value" db:"discount_value"`
+	MinOrder            float64    `json:"min_order" db:"min_order"`
+	StartsOn            *time.Time `json:"starts_on" db:"starts_on"`
+	EndsOn              *time.Time `json:"ends_on" db:"ends_on"`
+	MaxUses             *int       `json:"max_uses" db:"max_uses"`
+	MaxUsesPerCustomer  *int       `json:"max_uses_per_customer" db:"max_uses_per_customer"`
+	FirstBookingOnly    bool       `json:"first_booking_only" db:"first_booking_only"`
+	ServiceIDs          []int64    `json:"service_ids" db:"service_ids"`
+	Active              bool       `json:"active" db:"active"`
+	Uses                int        `json:"uses"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type PromoCodeRequest struct {
+	Code               string  `json:"code" validate:"required,max=50"`
+	Description        string  `json:"description"`
+	DiscountType       string  `json:"discount_type" validate:"required,oneof=percent fixed"`
+	DiscountValue      float64 `json:"discount_value" validate:"required,gt=0"`
+	MinOrder           float64 `json:"min_order" validate:"gte=0"`
+	StartsOn           string  `json:"starts_on" validate:"omitempty,date"`
+	EndsOn             string  `json:"ends_on" validate:"omitempty,date"`
+	MaxUses            *int    `json:"max_uses" validate:"omitempty,gt=0"`
+	MaxUsesPerCustomer *int    `json:"max_uses_per_customer" validate:"omitempty,gt=0"`
+	FirstBookingOnly   bool    `json:"first_booking_only"`
+	ServiceIDs         []int64 `json:"service_ids" validate:"dive,gt=0"`
+	Active             *bool   `json:"active"`
+}
+
+// PromoRedemption records a promo code used on a booking
+type PromoRedemption struct {
+	ID          int       `json:"id" db:"id"`
+	PromoCodeID int       `json:"promo_code_id" db:"promo_code_id"`
+	BookingID   int       `json:"booking_id" db:"booking_id"`
+	CustomerID  *int      `json:"customer_id" db:"customer_id"`
+	Discount    float64   `json:"discount" db:"discount"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// PromoCodeDetail is a promo code with the bookings it was used on
+type PromoCodeDetail struct {
+	PromoCode
+	Redemptions []PromoRedemption `json:"redemptions"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// BusinessHours are the regular opening hours for a weekday (0 = Sunday)
+type BusinessHours struct {
+	Weekday  int    `json:"weekday" db:"weekday" validate:"min=0,max=6"`
+	IsOpen   bool   `json:"is_open" db:"is_open"`
+	OpensAt  string `json:"opens_at" db:"opens_at" validate:"omitempty,clock"`
+	ClosesAt string `json:"closes_at" db:"closes_at" validate:"omitempty,clock"`
+}
+
+type BusinessHoursRequest struct {
+	Hours []BusinessHours `json:"hours" validate:"required,dive"`
+}
+
+// ScheduleException overrides the weekly hours for a date range, either
+// closing the business (holidays, blackout dates) or setting other hours
+type ScheduleException struct {
+	ID        int       `json:"id" db:"id"`
+	StartDate time.Time `json:"start_date" db:"start_date"`
+	EndDate   time.Time `json:"end_date" db:"end_date"`
+	IsClosed  bool      `json:"is_closed" db:"is_closed"`
+	OpensAt   string    `json:"opens_at,omitempty" db:"opens_at"`
+	ClosesAt  string    `json:"closes_at,omitempty" db:"closes_at"`
+	Reason    string    `json:"reason" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type ScheduleExceptionRequest struct {
+	StartDate string `json:"start_date" validate:"required,date"`
+	EndDate   string `json:"end_date" validate:"omitempty,date"` // defaults to start_date
+	IsClosed  bool   `json:"is_closed"`
+	OpensAt   string `json:"opens_at" validate:"omitempty,clock"`
+	ClosesAt  string `json:"closes_at" validate:"omitempty,clock"`
+	Reason    string `json:"reason"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	FrequencyWeekly   = "weekly"
+	FrequencyBiweekly = "biweekly"
+	FrequencyMonthly  = "monthly"
+)
+
+// BookingSeries is a recurring cleaning. Its occurrences are ordinary
+// bookings linked through bookings.series_id.
+type BookingSeries struct {
+	ID                  int               `json:"id" db:"id"`
+	UserID              int               `json:"user_id" db:"user_id"`
+	ServiceID           int               `json:"service_id" db:"service_id"`
+	ServiceName         string            `json:"service_name"`
+	Frequency           string            `json:"frequency" db:"frequency"`
+	StartDate           time.Time         `json:"start_date" db:"start_date"`
+	EndDate             *time.Time        `json:"end_date" db:"end_date"`
+	ScheduledTime       string            `json:"scheduled_time" db:"scheduled_time"`
+	Address             string            `json:"address" db:"address"`
+	SquareMeters        float64           `json:"square_meters" db:"square_meters"`
+	SpecialInstructions string            `json:"special_instructions" db:"special_instructions"`
+	DiscountPercent     float64           `json:"discount_percent" db:"discount_percent"`
+	Status              string            `json:"status" db:"status"` // active, cancelled
+	GeneratedUntil      *time.Time        `json:"generated_until" db:"generated_until"`
+	Occurrences         []BookingResponse `json:"occurrences,omitempty"`
+	CreatedAt           time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+type SeriesRequest struct {
+	UserID              int     `json:"user_id"` // admin only; clients book for themselves
+	ServiceID           int     `json:"service_id" validate:"required"`
+	Frequency           string  `json:"frequency" validate:"required,oneof=weekly biweekly monthly"`
+	StartDate           string  `json:"start_date" validate:"required,date,notpast"`
+	EndDate             string  `json:"end_date" validate:"omitempty,date"`
+	ScheduledTime       string  `json:"scheduled_time" validate:"required,clock"`
+	PropertyID          *int    `json:"property_id" validate:"omitempty,gt=0"` // a saved property instead of address and size
+	Address             string  `json:"address" validate:"required_without=PropertyID"`
+	SquareMeters        float64 `json:"square_meters" validate:"required_without=PropertyID,omitempty,gt=0"`
+	SpecialInstructions string  `json:"special_instructions"`
+}
+
+// SeriesUpdateRequest changes an occurrence and every one after it. Fields
+// left empty keep their current value. StartDate moves the first changed
+// occurrence, and with it the weekday or day of month of the rest.
+type SeriesUpdateRequest struct {
+	FromDate            string   `json:"from_date" validate:"required,date"`
+	StartDate           string   `json:"start_date" validate:"omitempty,date,notpast"`
+	Frequency           string   `json:"frequency" validate:"omitempty,oneof=weekly biweekly monthly"`
+	ScheduledTime       string   `json:"scheduled_time" validate:"omitempty,clock"`
+	Address             string   `json:"address"`
+	SquareMeters        *float64 `json:"square_meters" validate:"omitempty,gt=0"`
+	SpecialInstructions *string  `json:"special_instructions"`
+}
+
+type OccurrenceRescheduleRequest struct {
+	ScheduledDate string `json:"scheduled_date" validate:"required,date,notpast"`
+	ScheduledTime string `json:"scheduled_time" validate:"required,clock"`
+}
@@ -4,31 +4,67 @@ import (
 	"time"
 )
 
+// Service is an offering in the catalog. ServiceType is the slug of its
+// category, kept under its old name for existing clients. Archived services
+// can't be booked but keep their bookings.
 type Service struct {
-	ID          int     `json:"id" db:"id"`
-	Name        string  `json:"name" db:"name" validate:"required"`
-	Description string  `json:"description" db:"description"`
-	BasePrice   float64 `json:"base_price" db:"base_price" validate:"required,gt=0"`
-	Duration    float64 `json:"duration_hours" db:"duration_hours" validate:"required,gt=0"`
-	ServiceType string  `json:"service_type" db:"service_type" validate:"required,oneof=residential commercial"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int        `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name" validate:"required"`
+	Description string     `json:"description" db:"description"`
+	BasePrice   float64    `json:"base_price" db:"base_price" validate:"required,gt=0"`
+	Duration    float64    `json:"duration_hours" db:"duration_hours" validate:"required,gt=0"`
+	CategoryID  int        `json:"category_id" db:"category_id"`
+	ServiceType string     `json:"service_type"`
+	ArchivedAt  *time.Time `json:"archived_at" db:"archived_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// ServiceRequest takes the category by ID or, as before categories, by its
+// slug in service_type
 type ServiceRequest struct {
 	Name        string  `json:"name" validate:"required"`
 	Description string  `json:"description"`
 	BasePrice   float64 `json:"base_price" validate:"required,gt=0"`
 	Duration    float64 `json:"duration_hours" validate:"required,gt=0"`
-	ServiceType string  `json:"service_type" validate:"required,oneof=residential commercial"`
+	CategoryID  *int    `json:"category_id" validate:"required_without=ServiceType,omitempty,gt=0"`
+	ServiceType string  `json:"service_type" validate:"required_without=CategoryID"`
 }
 
 type ServiceResponse struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	BasePrice   float64 `json:"base_price"`
-	Duration    float64 `json:"duration_hours"`
-	ServiceType string  `json:"service_type"`
-	CreatedAt   time.Time `json:"created_at"`
-}
\ No newline at end of file
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	BasePrice   float64    `json:"base_price"`
+	Duration    float64    `json:"duration_hours"`
+	CategoryID  int        `json:"category_id"`
+	Category    string     `json:"category"`
+	ServiceType string     `json:"service_type"`
+	AddOns      []AddOn    `json:"add_ons"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ServiceCategory groups services in the catalog, e.g. Airbnb or post
+// renovation cleaning
+type ServiceCategory struct {
+	ID          int       `json:"id" db:"id"`
+	Slug        string    `json:"slug" db:"slug"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	SortOrder   int       `json:"sort_order" db:"sort_order"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type ServiceCategoryRequest struct {
+	Slug        string `json:"slug" validate:"required,max=50"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description"`
+	SortOrder   int    `json:"sort_order"`
+}
+
+// ServiceAddOnsRequest sets the add-ons that can be booked with a service
+type ServiceAddOnsRequest struct {
+	AddOnIDs []int `json:"add_on_ids" validate:"dive,gt=0"`
+}
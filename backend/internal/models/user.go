@@ -11,11 +11,19 @@ type User struct {
 	FirstName string    `json:"first_name" db:"first_name" validate:"required"`
 	LastName  string    `json:"last_name" db:"last_name" validate:"required"`
 	Phone     string    `json:"phone" db:"phone"`
-	Role      string    `json:"role" db:"role" validate:"required,oneof=client admin"`
+	Role      string    `json:"role" db:"role" validate:"required,oneof=client cleaner admin"`
+	EmailVerified bool  `json:"email_verified" db:"email_verified"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// User roles
+const (
+	RoleClient  = "client"
+	RoleCleaner = "cleaner"
+	RoleAdmin   = "admin"
+)
+
 type UserRegisterRequest struct {
 	Email     string `json:"email" validate:"required,email"`
 	Password  string `json:"password" validate:"required,min=6"`
@@ -36,5 +44,26 @@ type UserResponse struct {
 	LastName  string `json:"last_name"`
 	Phone     string `json:"phone"`
 	Role      string `json:"role"`
+	EmailVerified bool `json:"email_verified"`
 	CreatedAt time.Time `json:"created_at"`
-}
\ No newline at end of file
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type TokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+type CleanerCreateRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required,min=6"`
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name" validate:"required"`
+	Phone     string `json:"phone"`
+}
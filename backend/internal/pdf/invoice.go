@@ -0,0 +1,434 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"cleaning-app-backend/internal/models"
+)
+
+// Branding, matching the invoice the frontend renders
+var (
+	brandBlue  = Color{0.15, 0.39, 0.92}
+	lightBlue  = Color{0.94, 0.96, 1}
+	lightGray  = Color{0.96, 0.96, 0.97}
+	ruleGray   = Color{0.82, 0.84, 0.86}
+	textGray   = Color{0.42, 0.45, 0.5}
+	paidGreen  = Color{0.09, 0.5, 0.24}
+	voidRed    = Color{0.8, 0.15, 0.15}
+	company    = "PREMIER PRIME"
+	tagline    = "Professional Cleaning Services"
+	contacts   = []string{"adaperez@premierprime.org", "(561) 452-3128", "www.premierprime.org"}
+	thankYou   = "Thank You for Choosing Premier Prime!"
+	dateLayout = "January 2, 2006"
+)
+
+const (
+	margin = 50.0
+	right  = PageWidth - margin
+	bottom = PageHeight - 60
+)
+
+// Item table columns: the description starts at the left, the numbers are
+// right aligned at these positions
+const (
+	qtyRight    = 390.0
+	rateRight   = 475.0
+	amountRight = right - 8
+)
+
+// Invoice renders an invoice as a PDF
+func Invoice(invoice *models.InvoiceResponse) []byte {
+	l := &layout{doc: New(), title: "INVOICE", numberLabel: "Invoice Number", totalLabel: "Total"}
+
+	l.header(invoice, invoice.InvoiceNumber)
+	l.status(invoice)
+	l.details(invoice, "Invoice Details", []string{
+		"Issue Date: " + invoice.IssueDate.Format(dateLayout),
+		"Due Date: " + invoice.DueDate.Format(dateLayout),
+		"Service: " + invoice.ServiceName,
+		"Service Date: " + invoice.BookingDate.Format(dateLayout),
+	})
+	l.items(invoice)
+	l.totals(invoice)
+	l.payment(invoice)
+	l.paragraph("Voided", invoice.VoidReason)
+	l.paragraph("Notes", invoice.Notes)
+	l.paragraph("Terms", invoice.Terms)
+	l.footer()
+
+	return l.doc.Bytes()
+}
+
+// CreditNote renders a credit note of an invoice as a PDF. It is laid out
+// like the invoice, with the credited lines and amounts.
+func CreditNote(note *models.CreditNote, invoice *models.InvoiceResponse) []byte {
+	credited := *invoice
+	credited.Items = make([]models.InvoiceItem, len(note.Items))
+	for i, item := range note.Items {
+		credited.Items[i] = models.InvoiceItem{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			TotalPrice:  item.TotalPrice,
+			Taxable:     item.Taxable,
+		}
+	}
+	credited.Subtotal = note.Subtotal
+	credited.TaxRate = note.TaxRate
+	credited.TaxAmount = note.TaxAmount
+	credited.TotalAmount = note.TotalAmount
+
+	l := &layout{doc: New(), title: "CREDIT NOTE", numberLabel: "Credit Note Number", totalLabel: "Total Credit"}
+	l.header(&credited, note.CreditNoteNumber)
+	l.doc.TextRight(right, 116, Helvetica, 9, textGray, "Credits invoice #"+invoice.InvoiceNumber)
+	l.details(&credited, "Credit Note Details", []string{
+		"Issue Date: " + note.IssueDate.Format(dateLayout),
+		"Invoice: #" + invoice.InvoiceNumber,
+		"Invoice Date: " + invoice.IssueDate.Format(dateLayout),
+		"Service: " + invoice.ServiceName,
+	})
+	l.items(&credited)
+	l.totals(&credited)
+	l.paragraph("Reason", note.Reason)
+	l.footer()
+
+	return l.doc.Bytes()
+}
+
+// layout draws an invoice or credit note top to bottom, starting a new page
+// when the next block doesn't fit
+type layout struct {
+	doc *Document
+	y   float64
+
+	title, numberLabel, totalLabel string
+}
+
+// need starts a new page unless height more points fit on this one
+func (l *layout) need(height float64) bool {
+	if l.y+height <= bottom {
+		return false
+	}
+	l.doc.AddPage()
+	l.y = margin + 10
+	return true
+}
+
+func (l *layout) header(invoice *models.InvoiceResponse, number string) {
+	d := l.doc
+	d.Rect(0, 0, PageWidth, 8, brandBlue)
+
+	d.Text(margin, 62, HelveticaBold, 24, brandBlue, company)
+	d.Text(margin, 80, Helvetica, 12, textGray, tagline)
+	y := 98.0
+	for _, contact := range contacts {
+		d.Text(margin, y, Helvetica, 9, textGray, contact)
+		y += 12
+	}
+	if invoice.FloridaTaxID != "" {
+		d.Text(margin, y, Helvetica, 9, textGray, "Florida Tax ID: "+invoice.FloridaTaxID)
+	}
+
+	d.TextRight(right, 62, HelveticaBold, 26, Black, l.title)
+	d.TextRight(right, 82, Helvetica, 9, textGray, l.numberLabel)
+	d.TextRight(right, 98, HelveticaBold, 13, brandBlue, "#"+number)
+
+	d.Line(margin, 152, right, 152, 1, ruleGray)
+	l.y = 176
+}
+
+func (l *layout) status(invoice *models.InvoiceResponse) {
+	d := l.doc
+	switch invoice.Status {
+	case models.InvoiceStatusPaid:
+		d.TextRight(right, 116, HelveticaBold, 11, paidGreen, "PAID")
+	case models.InvoiceStatusPartiallyPaid:
+		d.TextRight(right, 116, HelveticaBold, 11, paidGreen, "PARTIALLY PAID")
+	case models.InvoiceStatusCredited:
+		d.TextRight(right, 116, HelveticaBold, 11, textGray, "CREDITED")
+	case models.InvoiceStatusCancelled:
+		d.TextRight(right, 116, HelveticaBold, 11, voidRed, "CANCELLED")
+	case models.InvoiceStatusVoid:
+		d.TextRight(right, 116, HelveticaBold, 11, voidRed, "VOID")
+	case models.InvoiceStatusOverdue:
+		d.TextRight(right, 116, HelveticaBold, 11, voidRed, "OVERDUE")
+	}
+}
+
+// details draws the document's own details, the service address and who it
+// is billed to side by side
+func (l *layout) details(invoice *models.InvoiceResponse, title string, lines []string) {
+	const width = 155.0
+	columns := []struct {
+		x     float64
+		title string
+		lines []string
+	}{
+		{margin, title, lines},
+		{margin + 172, "Service Address", []string{
+			invoice.ServiceAddress,
+			cityLine(invoice.ServiceCity, invoice.ServiceState, invoice.ServiceZipCode),
+		}},
+		{margin + 344, "Bill To", []string{
+			invoice.CustomerName,
+			invoice.BillingAddress,
+			cityLine(invoice.BillingCity, invoice.BillingState, invoice.BillingZipCode),
+			invoice.BillingCountry,
+			invoice.CustomerEmail,
+			invoice.CustomerPhone,
+		}},
+	}
+
+	deepest := l.y
+	for _, column := range columns {
+		l.doc.Text(column.x, l.y, HelveticaBold, 11, Black, column.title)
+		y := l.y + 16
+		for _, line := range column.lines {
+			if line == "" {
+				continue
+			}
+			for _, wrapped := range Wrap(line, Helvetica, 9, width) {
+				l.doc.Text(column.x, y, Helvetica, 9, Black, wrapped)
+				y += 12
+			}
+		}
+		deepest = math.Max(deepest, y)
+	}
+	l.y = deepest + 16
+}
+
+func (l *layout) items(invoice *models.InvoiceResponse) {
+	items := invoice.Items
+	if len(items) == 0 {
+		// Invoices from before line items were kept bill the whole subtotal
+		items = []models.InvoiceItem{{
+			Description: invoice.ServiceName,
+			Quantity:    1,
+			UnitPrice:   invoice.Subtotal,
+			TotalPrice:  invoice.Subtotal,
+			Taxable:     !invoice.TaxExempt,
+		}}
+	}
+	showTaxable := !invoice.TaxExempt && hasUntaxed(items)
+
+	l.need(60)
+	l.tableHeader()
+	for _, item := range items {
+		lines := Wrap(item.Description, Helvetica, 9, qtyRight-margin-60)
+		if showTaxable && !item.Taxable {
+			lines = append(lines, "")
+		}
+		height := float64(len(lines))*12 + 10
+		if l.need(height) {
+			l.tableHeader()
+		}
+
+		y := l.y + 14
+		for _, line := range lines {
+			l.doc.Text(margin+8, y, Helvetica, 9, Black, line)
+			y += 12
+		}
+		if showTaxable && !item.Taxable {
+			l.doc.Text(margin+8, y-12, Helvetica, 8, textGray, "Not taxable")
+		}
+		l.doc.TextRight(qtyRight, l.y+14, Helvetica, 9, Black, quantity(item.Quantity))
+		l.doc.TextRight(rateRight, l.y+14, Helvetica, 9, Black, money(item.UnitPrice))
+		l.doc.TextRight(amountRight, l.y+14, HelveticaBold, 9, Black, money(item.TotalPrice))
+
+		l.y += height
+		l.doc.Line(margin, l.y, right, l.y, 0.5, ruleGray)
+	}
+	l.y += 16
+}
+
+func (l *layout) tableHeader() {
+	l.doc.Rect(margin, l.y, right-margin, 20, lightBlue)
+	l.doc.Text(margin+8, l.y+14, HelveticaBold, 9, Black, "Description")
+	l.doc.TextRight(qtyRight, l.y+14, HelveticaBold, 9, Black, "Qty")
+	l.doc.TextRight(rateRight, l.y+14, HelveticaBold, 9, Black, "Rate")
+	l.doc.TextRight(amountRight, l.y+14, HelveticaBold, 9, Black, "Amount")
+	l.y += 20
+}
+
+func (l *layout) totals(invoice *models.InvoiceResponse) {
+	type row struct{ label, amount string }
+	rows := []row{{"Subtotal", money(invoice.Subtotal)}}
+
+	if invoice.TaxExempt {
+		reason := "Tax exempt"
+		if invoice.TaxExemptReason != "" {
+			reason += ": " + invoice.TaxExemptReason
+		}
+		rows = append(rows, row{reason, money(0)})
+	} else {
+		if hasUntaxed(invoice.Items) {
+			var taxable float64
+			for _, item := range invoice.Items {
+				if item.Taxable {
+					taxable += item.TotalPrice
+				}
+			}
+			rows = append(rows, row{"Taxable Amount", money(taxable)})
+		}
+		// The standard rate is the state tax plus the county surtax; show
+		// each part. Any other rate was set by hand and is shown as is.
+		standard := models.FloridaStateTaxRate + models.FloridaDiscretionaryTax
+		if math.Abs(invoice.TaxRate-standard) < 0.00001 {
+			state := math.Round(invoice.TaxAmount*models.FloridaStateTaxRate/standard*100) / 100
+			rows = append(rows,
+				row{"Florida State Sales Tax (" + percent(models.FloridaStateTaxRate) + ")", money(state)},
+				row{"Discretionary Surtax (" + percent(models.FloridaDiscretionaryTax) + ")", money(invoice.TaxAmount - state)},
+			)
+		} else {
+			rows = append(rows, row{"Sales Tax (" + percent(invoice.TaxRate) + ")", money(invoice.TaxAmount)})
+		}
+	}
+
+	const left = 330.0
+	height := float64(len(rows))*16 + 44
+	l.need(height)
+	l.doc.Rect(left, l.y, right-left, height, lightGray)
+
+	y := l.y + 20
+	for _, r := range rows {
+		label := r.label
+		for runes := []rune(label); Width(label, Helvetica, 9) > amountRight-left-90; {
+			runes = runes[:len(runes)-1]
+			label = strings.TrimRight(string(runes), " ") + "..."
+		}
+		l.doc.Text(left+10, y, Helvetica, 9, textGray, label)
+		l.doc.TextRight(amountRight, y, Helvetica, 9, Black, r.amount)
+		y += 16
+	}
+	l.doc.Line(left+10, y-6, amountRight, y-6, 0.5, ruleGray)
+	l.doc.Text(left+10, y+12, HelveticaBold, 12, Black, l.totalLabel)
+	l.doc.TextRight(amountRight, y+12, HelveticaBold, 14, brandBlue, money(invoice.TotalAmount))
+
+	l.y += height + 20
+}
+
+// payment lists what was paid, refunded and credited on the invoice and the
+// balance it leaves
+func (l *layout) payment(invoice *models.InvoiceResponse) {
+	if len(invoice.Payments) == 0 && len(invoice.CreditNotes) == 0 {
+		return
+	}
+
+	if len(invoice.Payments) > 0 {
+		l.need(40)
+		l.doc.Text(margin, l.y, HelveticaBold, 11, paidGreen, "Payments Received")
+		l.y += 14
+		for _, payment := range invoice.Payments {
+			details := payment.PaidAt.Format(dateLayout)
+			if payment.Method != "" {
+				details += " by " + strings.ReplaceAll(payment.Method, "_", " ")
+			}
+			if payment.Reference != "" {
+				details += ", reference " + payment.Reference
+			}
+			if payment.Credited > 0 {
+				details += " (" + money(payment.Credited) + " credited to account)"
+			}
+			l.ledgerLine(details, payment.Amount)
+			for _, refund := range payment.Refunds {
+				l.ledgerLine("Refunded "+refund.RefundedAt.Format(dateLayout), -refund.Amount)
+			}
+		}
+		l.y += 6
+	}
+
+	if len(invoice.CreditNotes) > 0 {
+		l.need(40)
+		l.doc.Text(margin, l.y, HelveticaBold, 11, Black, "Credit Notes")
+		l.y += 14
+		for _, note := range invoice.CreditNotes {
+			l.ledgerLine("#"+note.CreditNoteNumber+" issued "+note.IssueDate.Format(dateLayout), note.TotalAmount)
+		}
+		l.y += 6
+	}
+
+	l.need(16)
+	l.doc.Text(margin, l.y, HelveticaBold, 9, Black, "Balance Due")
+	l.doc.TextRight(amountRight, l.y, HelveticaBold, 9, Black, money(invoice.BalanceDue))
+	l.y += 22
+}
+
+func (l *layout) ledgerLine(details string, amount float64) {
+	l.need(12)
+	l.doc.Text(margin, l.y, Helvetica, 9, Black, details)
+	l.doc.TextRight(amountRight, l.y, Helvetica, 9, Black, money(amount))
+	l.y += 12
+}
+
+func (l *layout) paragraph(title, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	lines := Wrap(text, Helvetica, 9, right-margin)
+	l.need(30)
+	l.doc.Text(margin, l.y, HelveticaBold, 11, Black, title)
+	l.y += 16
+	for _, line := range lines {
+		l.need(12)
+		l.doc.Text(margin, l.y, Helvetica, 9, Black, line)
+		l.y += 12
+	}
+	l.y += 12
+}
+
+func (l *layout) footer() {
+	l.need(50)
+	l.y += 8
+	l.doc.Line(margin, l.y, right, l.y, 1, ruleGray)
+	center := PageWidth / 2
+	l.doc.Text(center-Width(thankYou, HelveticaBold, 11)/2, l.y+20, HelveticaBold, 11, Black, thankYou)
+	line := strings.Join(contacts, "   |   ")
+	l.doc.Text(center-Width(line, Helvetica, 8)/2, l.y+34, Helvetica, 8, textGray, line)
+}
+
+func hasUntaxed(items []models.InvoiceItem) bool {
+	for _, item := range items {
+		if !item.Taxable {
+			return true
+		}
+	}
+	return false
+}
+
+func cityLine(city, state, zip string) string {
+	line := city
+	if state != "" {
+		if line != "" {
+			line += ", "
+		}
+		line += state
+	}
+	if zip != "" {
+		line = strings.TrimSpace(line + " " + zip)
+	}
+	return line
+}
+
+func money(amount float64) string {
+	if amount < 0 {
+		return "-" + money(-amount)
+	}
+	whole := fmt.Sprintf("%.2f", amount)
+	digits, cents := whole[:len(whole)-3], whole[len(whole)-3:]
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return "$" + digits + cents
+}
+
+func quantity(q float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", q), "0"), ".")
+}
+
+func percent(rate float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", rate*100), "0"), ".") + "%"
+}
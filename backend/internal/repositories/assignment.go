@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type AssignmentRepository struct{}
+
+const cleanerJobColumns = `b.id, s.name, b.scheduled_date, b.scheduled_time, b.address, b.square_meters,
+		 COALESCE(b.special_instructions, '') as special_instructions, b.status,
+		 COALESCE(u.first_name || ' ' || u.last_name, b.guest_name, '') as customer_name,
+		 COALESCE(u.phone, b.guest_phone, '') as customer_phone,
+		 COALESCE(p.access_notes, ''), COALESCE(p.gate_code, ''), COALESCE(p.pets, '')`
+
+func scanCleanerJob(scanner interface{ Scan(...interface{}) error }, job *models.CleanerJob) error {
+	return scanner.Scan(
+		&job.ID, &job.ServiceName, &job.ScheduledDate, &job.ScheduledTime, &job.Address, &job.SquareMeters,
+		&job.SpecialInstructions, &job.Status, &job.CustomerName, &job.CustomerPhone,
+		&job.AccessNotes, &job.GateCode, &job.Pets,
+	)
+}
+
+// GetAssignedJobs returns the bookings assigned to a cleaner on a given date
+func (r *AssignmentRepository) GetAssignedJobs(userID int, date time.Time) ([]models.CleanerJob, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+cleanerJobColumns+`
+		 FROM booking_assignments ba
+		 JOIN bookings b ON ba.booking_id = b.id
+		 JOIN services s ON b.service_id = s.id
+		 LEFT JOIN users u ON b.user_id = u.id
+		 LEFT JOIN customer_properties p ON b.property_id = p.id
+		 WHERE ba.user_id = $1 AND b.scheduled_date = $2 AND b.status NOT IN ('cancelled', 'skipped', 'no_show')
+		 ORDER BY b.scheduled_time`,
+		userID, date,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.CleanerJob{}
+	for rows.Next() {
+		var job models.CleanerJob
+		if err := scanCleanerJob(rows, &job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// GetAssignedJob returns a booking only if it is assigned to the cleaner
+func (r *AssignmentRepository) GetAssignedJob(userID, bookingID int) (*models.CleanerJob, error) {
+	var job models.CleanerJob
+	row := database.DB.QueryRow(
+		`SELECT `+cleanerJobColumns+`
+		 FROM booking_assignments ba
+		 JOIN bookings b ON ba.booking_id = b.id
+		 JOIN services s ON b.service_id = s.id
+		 LEFT JOIN users u ON b.user_id = u.id
+		 LEFT JOIN customer_properties p ON b.property_id = p.id
+		 WHERE ba.user_id = $1 AND b.id = $2`,
+		userID, bookingID,
+	)
+	err := scanCleanerJob(row, &job)
+
+	return &job, err
+}
+
+const assigneeColumns = `ba.user_id, u.first_name || ' ' || u.last_name, ba.crew_id, COALESCE(c.name, '')`
+
+func scanAssignee(scanner interface{ Scan(...interface{}) error }, assignee *models.Assignee) error {
+	return scanner.Scan(&assignee.UserID, &assignee.Name, &assignee.CrewID, &assignee.CrewName)
+}
+
+// SetAssignees replaces the cleaners assigned to a booking
+func (r *AssignmentRepository) SetAssignees(bookingID int, assignees []models.Assignee, assignedBy int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM booking_assignments WHERE booking_id = $1", bookingID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertAssignees(tx, bookingID, assignees, assignedBy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddAssignees assigns additional cleaners to a booking, keeping existing ones
+func (r *AssignmentRepository) AddAssignees(bookingID int, assignees []models.Assignee, assignedBy int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertAssignees(tx, bookingID, assignees, assignedBy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertAssignees(tx *sql.Tx, bookingID int, assignees []models.Assignee, assignedBy int) error {
+	for _, assignee := range assignees {
+		_, err := tx.Exec(
+			`INSERT INTO booking_assignments (booking_id, user_id, crew_id, assigned_by, assigned_at)
+			 VALUES ($1, $2, $3, $4, $5) ON CONFLICT (booking_id, user_id) DO NOTHING`,
+			bookingID, assignee.UserID, assignee.CrewID, assignedBy, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *AssignmentRepository) RemoveAssignee(bookingID, userID int) error {
+	result, err := database.DB.Exec(
+		"DELETE FROM booking_assignments WHERE booking_id = $1 AND user_id = $2",
+		bookingID, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetAssignees returns the cleaners assigned to a booking
+func (r *AssignmentRepository) GetAssignees(bookingID int) ([]models.Assignee, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+assigneeColumns+`
+		 FROM booking_assignments ba
+		 JOIN users u ON ba.user_id = u.id
+		 LEFT JOIN crews c ON ba.crew_id = c.id
+		 WHERE ba.booking_id = $1
+		 ORDER BY u.first_name, u.last_name`,
+		bookingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignees := []models.Assignee{}
+	for rows.Next() {
+		var assignee models.Assignee
+		if err := scanAssignee(rows, &assignee); err != nil {
+			return nil, err
+		}
+		assignees = append(assignees, assignee)
+	}
+
+	return assignees, nil
+}
+
+// GetAssigneesByDateRange returns the assignees of every booking scheduled in
+// the range, keyed by booking ID
+func (r *AssignmentRepository) GetAssigneesByDateRange(startDate, endDate time.Time) (map[int][]models.Assignee, error) {
+	rows, err := database.DB.Query(
+		`SELECT ba.booking_id, `+assigneeColumns+`
+		 FROM booking_assignments ba
+		 JOIN bookings b ON ba.booking_id = b.id
+		 JOIN users u ON ba.user_id = u.id
+		 LEFT JOIN crews c ON ba.crew_id = c.id
+		 WHERE b.scheduled_date >= $1 AND b.scheduled_date <= $2
+		 ORDER BY ba.booking_id, u.first_name, u.last_name`,
+		startDate, endDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignees := make(map[int][]models.Assignee)
+	for rows.Next() {
+		var bookingID int
+		var assignee models.Assignee
+		err := rows.Scan(&bookingID, &assignee.UserID, &assignee.Name, &assignee.CrewID, &assignee.CrewName)
+		if err != nil {
+			return nil, err
+		}
+		assignees[bookingID] = append(assignees[bookingID], assignee)
+	}
+
+	return assignees, nil
+}
+
+// GetConflictingAssignees returns which of the given cleaners already work
+// another active booking that overlaps the booking. newDate and newTime
+// override the booking's own schedule when checking a reschedule, and
+// newDuration its length when the job grows; pass nil to check the booking
+// as it is.
+func (r *AssignmentRepository) GetConflictingAssignees(bookingID int, userIDs []int, newDate, newTime *string, newDuration *float64) ([]models.Assignee, error) {
+	rows, err := database.DB.Query(
+		`WITH target AS (
+			SELECT t.id,
+			       COALESCE($3::date, t.scheduled_date) + COALESCE($4::time, t.scheduled_time) AS starts_at,
+			       COALESCE($5::numeric, t.duration_hours) * INTERVAL '1 hour' AS length
+			FROM bookings t
+			WHERE t.id = $1
+		 )
+		 SELECT DISTINCT ba.user_id, u.first_name || ' ' || u.last_name, ba.crew_id, COALESCE(c.name, '')
+		 FROM target
+		 JOIN bookings o ON o.id != target.id AND o.status NOT IN ('cancelled', 'skipped', 'no_show')
+		 JOIN booking_assignments ba ON ba.booking_id = o.id
+		 JOIN users u ON ba.user_id = u.id
+		 LEFT JOIN crews c ON ba.crew_id = c.id
+		 WHERE ba.user_id = ANY($2)
+		   AND o.scheduled_date + o.scheduled_time < target.starts_at + target.length
+		   AND target.starts_at < o.scheduled_date + o.scheduled_time + o.duration_hours * INTERVAL '1 hour'`,
+		bookingID, pq.Array(userIDs), newDate, newTime, newDuration,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conflicts := []models.Assignee{}
+	for rows.Next() {
+		var assignee models.Assignee
+		if err := scanAssignee(rows, &assignee); err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, assignee)
+	}
+
+	return conflicts, nil
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type BookingRescheduleRepository struct{}
+
+// RescheduleBooking moves a booking and records where it moved from in one
+// transaction. The booking row stays locked while allow inspects its status,
+// so it can't be cancelled or started halfway through. When details is given,
+// its address, size, instructions, price and duration are saved in the same
+// transaction.
+func (r *BookingRescheduleRepository) RescheduleBooking(bookingID int, date time.Time, scheduledTime string, actor models.StatusActor, reason string, details *models.Booking, allow func(status string) error) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var fromDate time.Time
+	var fromTime string
+	err = tx.QueryRow(
+		"SELECT status, scheduled_date, scheduled_time FROM bookings WHERE id = $1 FOR UPDATE",
+		bookingID,
+	).Scan(&status, &fromDate, &fromTime)
+	if err != nil {
+		return err
+	}
+
+	if err := allow(status); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if details != nil {
+		_, err = tx.Exec(
+			`UPDATE bookings SET address=$1, square_meters=$2, special_instructions=$3, total_price=$4, duration_hours=$5
+			 WHERE id=$6`,
+			details.Address, details.SquareMeters, details.SpecialInstructions, details.TotalPrice, details.DurationHours, bookingID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		"UPDATE bookings SET scheduled_date = $1, scheduled_time = $2, updated_at = $3 WHERE id = $4",
+		date, scheduledTime, now, bookingID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO booking_reschedules (booking_id, from_date, from_time, to_date, to_time, changed_by, actor_role, reason, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)`,
+		bookingID, fromDate, fromTime, date, scheduledTime, actor.UserID, actor.Role, reason, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *BookingRescheduleRepository) GetReschedules(bookingID int) ([]models.BookingReschedule, error) {
+	rows, err := database.DB.Query(
+		`SELECT h.id, h.booking_id, h.from_date, TO_CHAR(h.from_time, 'HH24:MI'), h.to_date, TO_CHAR(h.to_time, 'HH24:MI'),
+		        h.changed_by, COALESCE(u.first_name || ' ' || u.last_name, ''), h.actor_role, COALESCE(h.reason, ''), h.created_at
+		 FROM booking_reschedules h
+		 LEFT JOIN users u ON h.changed_by = u.id
+		 WHERE h.booking_id = $1
+		 ORDER BY h.created_at, h.id`,
+		bookingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reschedules := []models.BookingReschedule{}
+	for rows.Next() {
+		var reschedule models.BookingReschedule
+		err := rows.Scan(
+			&reschedule.ID, &reschedule.BookingID, &reschedule.FromDate, &reschedule.FromTime,
+			&reschedule.ToDate, &reschedule.ToTime, &reschedule.ChangedBy, &reschedule.ChangedByName,
+			&reschedule.ActorRole, &reschedule.Reason, &reschedule.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reschedules = append(reschedules, reschedule)
+	}
+
+	return reschedules, nil
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type BookingStatusRepository struct{}
+
+// ChangeBookingStatus moves a booking to a new status and records the change
+// in one transaction. The booking row stays locked while allow inspects the
+// current status, so concurrent changes can't both pass the check. It returns
+// the previous status.
+func (r *BookingStatusRepository) ChangeBookingStatus(bookingID int, to string, actor models.StatusActor, reason string, allow func(from string) error) (string, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var from string
+	err = tx.QueryRow("SELECT status FROM bookings WHERE id = $1 FOR UPDATE", bookingID).Scan(&from)
+	if err != nil {
+		return "", err
+	}
+
+	if err := allow(from); err != nil {
+		return from, err
+	}
+
+	now := time.Now()
+	_, err = tx.Exec("UPDATE bookings SET status = $1, updated_at = $2 WHERE id = $3", to, now, bookingID)
+	if err != nil {
+		return from, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO booking_status_history (booking_id, from_status, to_status, changed_by, actor_role, reason, created_at)
+		 VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)`,
+		bookingID, from, to, actor.UserID, actor.Role, reason, now,
+	)
+	if err != nil {
+		return from, err
+	}
+
+	return from, tx.Commit()
+}
+
+func (r *BookingStatusRepository) GetStatusHistory(bookingID int) ([]models.BookingStatusChange, error) {
+	rows, err := database.DB.Query(
+		`SELECT h.id, h.booking_id, h.from_status, h.to_status, h.changed_by,
+		        COALESCE(u.first_name || ' ' || u.last_name, ''), h.actor_role, COALESCE(h.reason, ''), h.created_at
+		 FROM booking_status_history h
+		 LEFT JOIN users u ON h.changed_by = u.id
+		 WHERE h.booking_id = $1
+		 ORDER BY h.created_at, h.id`,
+		bookingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.BookingStatusChange{}
+	for rows.Next() {
+		var change models.BookingStatusChange
+		err := rows.Scan(
+			&change.ID, &change.BookingID, &change.FromStatus, &change.ToStatus, &change.ChangedBy,
+			&change.ChangedByName, &change.ActorRole, &change.Reason, &change.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	return history, nil
+}
@@ -30,7 +30,7 @@ func (r *CalendarRepository) GetBookingsByDateRange(startDate, endDate time.Time
 	rows, err := database.DB.Query(
 		`SELECT b.id, b.user_id, b.service_id, s.name, b.scheduled_date, b.scheduled_time, 
 		         b.address, b.square_meters, COALESCE(b.special_instructions, '') as special_instructions, 
-		         b.total_price, b.status, 
+		         b.total_price, b.duration_hours, b.status, 
 		         COALESCE(b.guest_name, '') as guest_name, COALESCE(b.guest_email, '') as guest_email, 
 		         COALESCE(b.guest_phone, '') as guest_phone, b.is_guest_booking, 
 		         b.created_at 
@@ -52,7 +52,7 @@ func (r *CalendarRepository) GetBookingsByDateRange(startDate, endDate time.Time
 			&booking.ID, &booking.UserID, &booking.ServiceID, &booking.ServiceName,
 			&booking.ScheduledDate, &booking.ScheduledTime, &booking.Address,
 			&booking.SquareMeters, &booking.SpecialInstructions, &booking.TotalPrice,
-			&booking.Status, &booking.GuestName, &booking.GuestEmail, &booking.GuestPhone,
+			&booking.DurationHours, &booking.Status, &booking.GuestName, &booking.GuestEmail, &booking.GuestPhone,
 			&booking.IsGuestBooking, &booking.CreatedAt,
 		)
 		if err != nil {
@@ -64,6 +64,42 @@ func (r *CalendarRepository) GetBookingsByDateRange(startDate, endDate time.Time
 	return bookings, nil
 }
 
+// BookingWindow is the time a booking occupies on its day, in minutes after midnight
+type BookingWindow struct {
+	BookingID   int
+	StartMinute int
+	EndMinute   int
+}
+
+// GetBookingWindows returns the windows of all active bookings on a date,
+// leaving out excludeID so a booking doesn't collide with itself when moved
+func (r *CalendarRepository) GetBookingWindows(date time.Time, excludeID int) ([]BookingWindow, error) {
+	rows, err := database.DB.Query(
+		`SELECT id,
+		        EXTRACT(EPOCH FROM scheduled_time)::int / 60,
+		        (EXTRACT(EPOCH FROM scheduled_time) / 60 + duration_hours * 60)::int
+		 FROM bookings
+		 WHERE scheduled_date = $1 AND status NOT IN ('cancelled', 'skipped', 'no_show') AND id != $2
+		 ORDER BY scheduled_time`,
+		date, excludeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []BookingWindow{}
+	for rows.Next() {
+		var window BookingWindow
+		if err := rows.Scan(&window.BookingID, &window.StartMinute, &window.EndMinute); err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
 func (r *CalendarRepository) GetBookingStats(period string) (*BookingStats, error) {
 	stats := &BookingStats{
 		Period: period,
@@ -157,21 +193,3 @@ func (r *CalendarRepository) GetBookingStats(period string) (*BookingStats, erro
 
 	return stats, nil
 }
-
-func (r *CalendarRepository) RescheduleBooking(bookingID int, newDate, newTime, reason string) error {
-	// Parse the new date
-	parsedDate, err := time.Parse("2006-01-02", newDate)
-	if err != nil {
-		return err
-	}
-
-	// Update the booking
-	_, err = database.DB.Exec(
-		`UPDATE bookings SET scheduled_date=$1, scheduled_time=$2, updated_at=$3 WHERE id=$4`,
-		parsedDate, newTime, time.Now(), bookingID,
-	)
-
-	// TODO: Add notification logic here to inform the customer about the reschedule
-
-	return err
-}
\ No newline at end of file
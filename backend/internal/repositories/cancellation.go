@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type CancellationRepository struct{}
+
+func (r *CancellationRepository) GetPolicy() (*models.CancellationPolicy, error) {
+	var policy models.CancellationPolicy
+	err := database.DB.QueryRow(
+		`SELECT free_cancellation_hours, late_cancellation_fee_percent, no_show_fee_percent,
+		        reschedule_cutoff_hours, updated_at
+		 FROM cancellation_policy WHERE id = 1`,
+	).Scan(
+		&policy.FreeCancellationHours, &policy.LateCancellationFeePercent, &policy.NoShowFeePercent,
+		&policy.RescheduleCutoffHours, &policy.UpdatedAt,
+	)
+
+	return &policy, err
+}
+
+func (r *CancellationRepository) SavePolicy(policy *models.CancellationPolicy) error {
+	policy.UpdatedAt = time.Now()
+	_, err := database.DB.Exec(
+		`INSERT INTO cancellation_policy (id, free_cancellation_hours, late_cancellation_fee_percent, no_show_fee_percent,
+		                                  reschedule_cutoff_hours, updated_at)
+		 VALUES (1, $1, $2, $3, $4, $5)
+		 ON CONFLICT (id) DO UPDATE
+		 SET free_cancellation_hours = EXCLUDED.free_cancellation_hours,
+		     late_cancellation_fee_percent = EXCLUDED.late_cancellation_fee_percent,
+		     no_show_fee_percent = EXCLUDED.no_show_fee_percent,
+		     reschedule_cutoff_hours = EXCLUDED.reschedule_cutoff_hours,
+		     updated_at = EXCLUDED.updated_at`,
+		policy.FreeCancellationHours, policy.LateCancellationFeePercent, policy.NoShowFeePercent,
+		policy.RescheduleCutoffHours, policy.UpdatedAt,
+	)
+	return err
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"database/sql"
+
 	"cleaning-app-backend/internal/database"
 	"cleaning-app-backend/internal/models"
 	"time"
@@ -9,13 +11,13 @@ import (
 type ContactRepository struct{}
 
 func (r *ContactRepository) CreateContactMessage(message *models.ContactMessage) error {
-	query := `INSERT INTO contact_messages (name, email, phone, subject, message, status, priority, category, created_at, updated_at) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+	query := `INSERT INTO contact_messages (name, email, phone, subject, message, status, priority, category, customer_id, created_at, updated_at) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
 
 	err := database.DB.QueryRow(
 		query,
 		message.Name, message.Email, message.Phone, message.Subject, message.Message,
-		message.Status, message.Priority, message.Category, time.Now(), time.Now(),
+		message.Status, message.Priority, message.Category, message.CustomerID, time.Now(), time.Now(),
 	).Scan(&message.ID)
 
 	message.CreatedAt = time.Now()
@@ -78,10 +80,10 @@ func (r *ContactRepository) GetFAQs(category string) ([]models.FAQResponse, erro
 	var args []interface{}
 
 	if category != "" {
-		query = "SELECT id, question, answer, category, is_active, display_order FROM faqs WHERE category=$1 AND is_active=true ORDER BY display_order, id"
+		query = "SELECT id, question, answer, category, is_active, display_order FROM faqs WHERE category=$1 AND is_active=true AND archived_at IS NULL ORDER BY display_order, id"
 		args = append(args, category)
 	} else {
-		query = "SELECT id, question, answer, category, is_active, display_order FROM faqs WHERE is_active=true ORDER BY display_order, id"
+		query = "SELECT id, question, answer, category, is_active, display_order FROM faqs WHERE is_active=true AND archived_at IS NULL ORDER BY display_order, id"
 	}
 
 	rows, err := database.DB.Query(query, args...)
@@ -127,7 +129,44 @@ func (r *ContactRepository) UpdateFAQ(faq *models.FAQ) error {
 	return err
 }
 
-func (r *ContactRepository) DeleteFAQ(id int) error {
-	_, err := database.DB.Exec("DELETE FROM faqs WHERE id=$1", id)
-	return err
+// GetAllFAQs lists FAQs for admins, inactive ones included and archived ones
+// on request
+func (r *ContactRepository) GetAllFAQs(includeArchived bool) ([]models.FAQResponse, error) {
+	rows, err := database.DB.Query(
+		`SELECT id, question, answer, category, is_active, display_order, archived_at
+		 FROM faqs WHERE archived_at IS NULL OR $1 ORDER BY display_order, id`,
+		includeArchived,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	faqs := []models.FAQResponse{}
+	for rows.Next() {
+		var faq models.FAQResponse
+		err := rows.Scan(&faq.ID, &faq.Question, &faq.Answer, &faq.Category, &faq.IsActive, &faq.DisplayOrder, &faq.ArchivedAt)
+		if err != nil {
+			return nil, err
+		}
+		faqs = append(faqs, faq)
+	}
+
+	return faqs, rows.Err()
+}
+
+// SetFAQArchived archives an FAQ or brings it back
+func (r *ContactRepository) SetFAQArchived(id int, archived bool) error {
+	result, err := database.DB.Exec(
+		`UPDATE faqs SET archived_at = CASE WHEN $2 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END,
+		 updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		id, archived,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,279 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type CreditNoteRepository struct{}
+
+const creditNoteColumns = `cn.id, cn.credit_note_number, cn.invoice_id, i.invoice_number, cn.issue_date, cn.reason,
+	cn.subtotal, cn.tax_rate, cn.tax_amount, cn.total_amount, cn.created_by, cn.created_at`
+
+func scanCreditNote(scanner interface{ Scan(...interface{}) error }, note *models.CreditNote) error {
+	return scanner.Scan(
+		&note.ID, &note.CreditNoteNumber, &note.InvoiceID, &note.InvoiceNumber, &note.IssueDate, &note.Reason,
+		&note.Subtotal, &note.TaxRate, &note.TaxAmount, &note.TotalAmount, &note.CreatedBy, &note.CreatedAt,
+	)
+}
+
+// CreateCreditNote issues a credit note for an invoice. A full one credits
+// what is left of the invoice; otherwise the note's items are credited, taxed
+// at the invoice's rate. Together the credit notes of an invoice can't credit
+// more than it billed. The credit note is numbered in the same transaction, and
+// the invoice is settled if nothing is left owing.
+func (r *CreditNoteRepository) CreateCreditNote(note *models.CreditNote, full bool) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var invoice models.Invoice
+	err = tx.QueryRow(
+		`SELECT invoice_number, status, subtotal, tax_rate, tax_amount, total_amount, tax_exempt
+		 FROM invoices WHERE id = $1 AND archived_at IS NULL FOR UPDATE`,
+		note.InvoiceID,
+	).Scan(&invoice.InvoiceNumber, &invoice.Status, &invoice.Subtotal, &invoice.TaxRate,
+		&invoice.TaxAmount, &invoice.TotalAmount, &invoice.TaxExempt)
+	if err == sql.ErrNoRows {
+		return errors.New("invoice not found")
+	}
+	if err != nil {
+		return err
+	}
+	if !isSettleable(invoice.Status) {
+		return errors.New("invoice is void")
+	}
+
+	var creditedSubtotal, creditedTax, creditedTotal float64
+	var notes int
+	err = tx.QueryRow(
+		`SELECT COUNT(*), COALESCE(SUM(subtotal), 0), COALESCE(SUM(tax_amount), 0), COALESCE(SUM(total_amount), 0)
+		 FROM credit_notes WHERE invoice_id = $1`,
+		note.InvoiceID,
+	).Scan(&notes, &creditedSubtotal, &creditedTax, &creditedTotal)
+	if err != nil {
+		return err
+	}
+	remaining := roundCents(invoice.TotalAmount - creditedTotal)
+	if remaining <= 0 {
+		return errors.New("invoice is fully credited")
+	}
+
+	note.TaxRate = invoice.TaxRate
+	if invoice.TaxExempt {
+		note.TaxRate = 0
+	}
+	if full {
+		note.Subtotal = roundCents(invoice.Subtotal - creditedSubtotal)
+		note.TaxAmount = roundCents(invoice.TaxAmount - creditedTax)
+		note.TotalAmount = remaining
+		if notes == 0 {
+			if note.Items, err = creditedInvoiceItems(tx, note.InvoiceID); err != nil {
+				return err
+			}
+		}
+		if len(note.Items) == 0 {
+			note.Items = []models.CreditNoteItem{{
+				Description: "Balance of invoice " + invoice.InvoiceNumber,
+				Quantity:    1,
+				UnitPrice:   note.Subtotal,
+				TotalPrice:  note.Subtotal,
+				Taxable:     note.TaxAmount > 0,
+			}}
+		}
+	} else {
+		var subtotal, taxable float64
+		for _, item := range note.Items {
+			subtotal += item.TotalPrice
+			if item.Taxable {
+				taxable += item.TotalPrice
+			}
+		}
+		note.Subtotal = roundCents(subtotal)
+		note.TaxAmount = roundCents(taxable * note.TaxRate)
+		note.TotalAmount = roundCents(note.Subtotal + note.TaxAmount)
+		if note.TotalAmount > remaining || note.Subtotal > roundCents(invoice.Subtotal-creditedSubtotal) {
+			return errors.New("credit exceeds invoice")
+		}
+	}
+
+	note.IssueDate = time.Now()
+	note.InvoiceNumber = invoice.InvoiceNumber
+	note.CreditNoteNumber, err = NextDocumentNumber(tx, models.DocumentTypeCreditNote, note.IssueDate)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(
+		`INSERT INTO credit_notes (credit_note_number, invoice_id, issue_date, reason, subtotal, tax_rate, tax_amount, total_amount, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING id, created_at`,
+		note.CreditNoteNumber, note.InvoiceID, note.IssueDate, note.Reason,
+		note.Subtotal, note.TaxRate, note.TaxAmount, note.TotalAmount, note.CreatedBy,
+	).Scan(&note.ID, &note.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create credit note: %v", err)
+	}
+	for i := range note.Items {
+		item := &note.Items[i]
+		item.CreditNoteID = note.ID
+		err = tx.QueryRow(
+			`INSERT INTO credit_note_items (credit_note_id, description, quantity, unit_price, total_price, taxable)
+			 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			item.CreditNoteID, item.Description, item.Quantity, item.UnitPrice, item.TotalPrice, item.Taxable,
+		).Scan(&item.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create credit note item: %v", err)
+		}
+	}
+
+	status, err := settledStatus(tx, note.InvoiceID, invoice.Status)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`UPDATE invoices SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, note.InvoiceID, status,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// creditedInvoiceItems copies an invoice's lines for a credit note crediting
+// all of it
+func creditedInvoiceItems(tx *sql.Tx, invoiceID int) ([]models.CreditNoteItem, error) {
+	rows, err := tx.Query(
+		`SELECT description, quantity, unit_price, total_price, taxable FROM invoice_items WHERE invoice_id = $1 ORDER BY id`,
+		invoiceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.CreditNoteItem{}
+	for rows.Next() {
+		var item models.CreditNoteItem
+		if err := rows.Scan(&item.Description, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.Taxable); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *CreditNoteRepository) GetCreditNoteByID(id int) (*models.CreditNote, error) {
+	var note models.CreditNote
+	err := scanCreditNote(database.DB.QueryRow(
+		`SELECT `+creditNoteColumns+`
+		 FROM credit_notes cn JOIN invoices i ON cn.invoice_id = i.id
+		 WHERE cn.id = $1`,
+		id,
+	), &note)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("credit note not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	notes := []models.CreditNote{note}
+	if err := withCreditNoteItems(notes); err != nil {
+		return nil, err
+	}
+	return &notes[0], nil
+}
+
+func (r *CreditNoteRepository) GetCreditNotesByInvoiceID(invoiceID int) ([]models.CreditNote, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+creditNoteColumns+`
+		 FROM credit_notes cn JOIN invoices i ON cn.invoice_id = i.id
+		 WHERE cn.invoice_id = $1
+		 ORDER BY cn.id`,
+		invoiceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.CreditNote{}
+	for rows.Next() {
+		var note models.CreditNote
+		if err := scanCreditNote(rows, &note); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return notes, withCreditNoteItems(notes)
+}
+
+func withCreditNoteItems(notes []models.CreditNote) error {
+	for i := range notes {
+		rows, err := database.DB.Query(
+			`SELECT id, credit_note_id, description, quantity, unit_price, total_price, taxable
+			 FROM credit_note_items WHERE credit_note_id = $1 ORDER BY id`,
+			notes[i].ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		notes[i].Items = []models.CreditNoteItem{}
+		for rows.Next() {
+			var item models.CreditNoteItem
+			if err := rows.Scan(&item.ID, &item.CreditNoteID, &item.Description, &item.Quantity,
+				&item.UnitPrice, &item.TotalPrice, &item.Taxable); err != nil {
+				rows.Close()
+				return err
+			}
+			notes[i].Items = append(notes[i].Items, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDocument returns a credit note's PDF, or nil if it hasn't been rendered
+func (r *CreditNoteRepository) GetDocument(creditNoteID int) (*models.CreditNoteDocument, error) {
+	var doc models.CreditNoteDocument
+	err := database.DB.QueryRow(
+		`SELECT credit_note_id, pdf, sha256, created_at FROM credit_note_documents WHERE credit_note_id = $1`,
+		creditNoteID,
+	).Scan(&doc.CreditNoteID, &doc.PDF, &doc.SHA256, &doc.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// CreateDocument stores a credit note's PDF. If another request stored one
+// first, that one is kept and returned.
+func (r *CreditNoteRepository) CreateDocument(doc *models.CreditNoteDocument) (*models.CreditNoteDocument, error) {
+	_, err := database.DB.Exec(
+		`INSERT INTO credit_note_documents (credit_note_id, pdf, sha256) VALUES ($1, $2, $3)
+		 ON CONFLICT (credit_note_id) DO NOTHING`,
+		doc.CreditNoteID, doc.PDF, doc.SHA256,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetDocument(doc.CreditNoteID)
+}
@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type CrewRepository struct{}
+
+func (r *CrewRepository) CreateCrew(crew *models.Crew) error {
+	query := `INSERT INTO crews (name, color, is_active, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	now := time.Now()
+	crew.CreatedAt = now
+	crew.UpdatedAt = now
+	return database.DB.QueryRow(query, crew.Name, crew.Color, crew.IsActive, now, now).Scan(&crew.ID)
+}
+
+func (r *CrewRepository) GetCrewByID(id int) (*models.Crew, error) {
+	var crew models.Crew
+	err := database.DB.QueryRow(
+		"SELECT id, name, color, is_active, created_at, updated_at FROM crews WHERE id = $1",
+		id,
+	).Scan(&crew.ID, &crew.Name, &crew.Color, &crew.IsActive, &crew.CreatedAt, &crew.UpdatedAt)
+	if err != nil {
+		return &crew, err
+	}
+
+	crew.Members, err = r.GetCrewMembers(id)
+	return &crew, err
+}
+
+func (r *CrewRepository) GetAllCrews() ([]models.Crew, error) {
+	rows, err := database.DB.Query(
+		"SELECT id, name, color, is_active, created_at, updated_at FROM crews ORDER BY name",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	crews := []models.Crew{}
+	for rows.Next() {
+		var crew models.Crew
+		if err := rows.Scan(&crew.ID, &crew.Name, &crew.Color, &crew.IsActive, &crew.CreatedAt, &crew.UpdatedAt); err != nil {
+			return nil, err
+		}
+		crews = append(crews, crew)
+	}
+
+	for i := range crews {
+		crews[i].Members, err = r.GetCrewMembers(crews[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return crews, nil
+}
+
+func (r *CrewRepository) UpdateCrew(crew *models.Crew) error {
+	crew.UpdatedAt = time.Now()
+	_, err := database.DB.Exec(
+		"UPDATE crews SET name = $1, color = $2, is_active = $3, updated_at = $4 WHERE id = $5",
+		crew.Name, crew.Color, crew.IsActive, crew.UpdatedAt, crew.ID,
+	)
+	return err
+}
+
+func (r *CrewRepository) DeleteCrew(id int) error {
+	_, err := database.DB.Exec("DELETE FROM crews WHERE id = $1", id)
+	return err
+}
+
+func (r *CrewRepository) GetCrewMembers(crewID int) ([]models.Assignee, error) {
+	rows, err := database.DB.Query(
+		`SELECT u.id, u.first_name || ' ' || u.last_name
+		 FROM crew_members cm
+		 JOIN users u ON cm.user_id = u.id
+		 WHERE cm.crew_id = $1
+		 ORDER BY u.first_name, u.last_name`,
+		crewID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.Assignee{}
+	for rows.Next() {
+		var member models.Assignee
+		if err := rows.Scan(&member.UserID, &member.Name); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// SetCrewMembers replaces the members of a crew
+func (r *CrewRepository) SetCrewMembers(crewID int, userIDs []int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM crew_members WHERE crew_id = $1", crewID)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		_, err = tx.Exec(
+			"INSERT INTO crew_members (crew_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			crewID, userID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE crews SET updated_at = $1 WHERE id = $2", time.Now(), crewID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountAvailableCrews returns the number of active crews that have members,
+// i.e. how many jobs can run at the same time
+func (r *CrewRepository) CountAvailableCrews() (int, error) {
+	var count int
+	err := database.DB.QueryRow(
+		`SELECT COUNT(*) FROM crews c
+		 WHERE c.is_active AND EXISTS (SELECT 1 FROM crew_members cm WHERE cm.crew_id = c.id)`,
+	).Scan(&count)
+	return count, err
+}
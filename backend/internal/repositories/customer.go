@@ -0,0 +1,457 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type CustomerRepository struct{}
+
+const customerColumns = `c.id, c.user_id, c.name, COALESCE(c.company, ''), COALESCE(c.email, ''), COALESCE(c.phone, ''),
+		 COALESCE(c.billing_address, ''), COALESCE(c.billing_city, ''), COALESCE(c.billing_state, ''),
+		 COALESCE(c.billing_zip_code, ''), COALESCE(c.billing_country, ''), c.tax_exempt,
+		 COALESCE(c.tax_exempt_reason, ''), c.tags, c.merged_into_id, c.created_at, c.updated_at`
+
+func scanCustomer(scanner interface{ Scan(...interface{}) error }, customer *models.Customer, extra ...interface{}) error {
+	dest := []interface{}{
+		&customer.ID, &customer.UserID, &customer.Name, &customer.Company, &customer.Email, &customer.Phone,
+		&customer.BillingAddress, &customer.BillingCity, &customer.BillingState,
+		&customer.BillingZipCode, &customer.BillingCountry, &customer.TaxExempt,
+		&customer.TaxExemptReason, pq.Array(&customer.Tags), &customer.MergedIntoID, &customer.CreatedAt, &customer.UpdatedAt,
+	}
+	return scanner.Scan(append(dest, extra...)...)
+}
+
+func (r *CustomerRepository) CreateCustomer(customer *models.Customer) error {
+	now := time.Now()
+	customer.CreatedAt = now
+	customer.UpdatedAt = now
+	if customer.Tags == nil {
+		customer.Tags = []string{}
+	}
+
+	return database.DB.QueryRow(
+		`INSERT INTO customers (user_id, name, company, email, phone, billing_address, billing_city, billing_state,
+		 billing_zip_code, billing_country, tax_exempt, tax_exempt_reason, tags, created_at, updated_at)
+		 VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''),
+		 NULLIF($9, ''), NULLIF($10, ''), $11, NULLIF($12, ''), $13, $14, $15) RETURNING id`,
+		customer.UserID, customer.Name, customer.Company, customer.Email, customer.Phone,
+		customer.BillingAddress, customer.BillingCity, customer.BillingState, customer.BillingZipCode,
+		customer.BillingCountry, customer.TaxExempt, customer.TaxExemptReason, pq.Array(customer.Tags), now, now,
+	).Scan(&customer.ID)
+}
+
+func (r *CustomerRepository) UpdateCustomer(customer *models.Customer) error {
+	customer.UpdatedAt = time.Now()
+	if customer.Tags == nil {
+		customer.Tags = []string{}
+	}
+
+	result, err := database.DB.Exec(
+		`UPDATE customers SET name = $1, company = NULLIF($2, ''), email = NULLIF($3, ''), phone = NULLIF($4, ''),
+		 billing_address = NULLIF($5, ''), billing_city = NULLIF($6, ''), billing_state = NULLIF($7, ''),
+		 billing_zip_code = NULLIF($8, ''), billing_country = NULLIF($9, ''), tax_exempt = $10,
+		 tax_exempt_reason = NULLIF($11, ''), tags = $12, updated_at = $13
+		 WHERE id = $14`,
+		customer.Name, customer.Company, customer.Email, customer.Phone,
+		customer.BillingAddress, customer.BillingCity, customer.BillingState, customer.BillingZipCode,
+		customer.BillingCountry, customer.TaxExempt, customer.TaxExemptReason, pq.Array(customer.Tags),
+		customer.UpdatedAt, customer.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *CustomerRepository) GetCustomerByID(id int) (*models.Customer, error) {
+	var customer models.Customer
+	err := scanCustomer(database.DB.QueryRow(`SELECT `+customerColumns+` FROM customers c WHERE c.id = $1`, id), &customer)
+	return &customer, err
+}
+
+func (r *CustomerRepository) GetCustomerByUserID(userID int) (*models.Customer, error) {
+	var customer models.Customer
+	err := scanCustomer(database.DB.QueryRow(`SELECT `+customerColumns+` FROM customers c WHERE c.user_id = $1`, userID), &customer)
+	return &customer, err
+}
+
+// FindUnownedByEmail returns the oldest customer without an account that is
+// not merged away and whose own email or one of whose contacts matches,
+// case-insensitively. A customer with an account is only found through it.
+func (r *CustomerRepository) FindUnownedByEmail(email string) (*models.Customer, error) {
+	var customer models.Customer
+	err := scanCustomer(database.DB.QueryRow(
+		`SELECT `+customerColumns+` FROM customers c
+		 WHERE c.merged_into_id IS NULL AND c.user_id IS NULL
+		   AND (LOWER(c.email) = LOWER($1)
+		        OR EXISTS (SELECT 1 FROM customer_contacts cc WHERE cc.customer_id = c.id AND LOWER(cc.email) = LOWER($1)))
+		 ORDER BY c.created_at, c.id
+		 LIMIT 1`,
+		email,
+	), &customer)
+	return &customer, err
+}
+
+// GetUnownedIDsByEmail lists the customers without an account that are not
+// merged away and whose own email matches, case-insensitively
+func (r *CustomerRepository) GetUnownedIDsByEmail(email string) ([]int, error) {
+	rows, err := database.DB.Query(
+		`SELECT id FROM customers
+		 WHERE merged_into_id IS NULL AND user_id IS NULL AND LOWER(email) = LOWER($1)
+		 ORDER BY id`,
+		email,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// AttachUser links a customer without an account to a client account
+func (r *CustomerRepository) AttachUser(customerID, userID int) error {
+	result, err := database.DB.Exec(
+		"UPDATE customers SET user_id = $1, updated_at = $2 WHERE id = $3 AND user_id IS NULL",
+		userID, time.Now(), customerID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errors.New("customer already has an account")
+	}
+	return nil
+}
+
+// SearchCustomers finds customers that are not merged away by name, company,
+// email or phone, their contacts' included. An empty query matches everyone;
+// tag narrows the results to customers with that tag.
+func (r *CustomerRepository) SearchCustomers(query, tag string, limit, offset int) ([]models.CustomerSummary, int, error) {
+	where := `c.merged_into_id IS NULL
+		 AND ($1 = '' OR c.name ILIKE '%' || $1 || '%' OR c.company ILIKE '%' || $1 || '%'
+		      OR c.email ILIKE '%' || $1 || '%' OR c.phone ILIKE '%' || $1 || '%'
+		      OR EXISTS (SELECT 1 FROM customer_contacts cc WHERE cc.customer_id = c.id
+		                 AND (cc.name ILIKE '%' || $1 || '%' OR cc.email ILIKE '%' || $1 || '%' OR cc.phone ILIKE '%' || $1 || '%')))
+		 AND ($2 = '' OR $2 = ANY(c.tags))`
+
+	var total int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM customers c WHERE `+where, query, tag).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT `+customerColumns+`,
+		        (SELECT COUNT(*) FROM bookings b WHERE b.customer_id = c.id),
+		        (SELECT MAX(b.scheduled_date) FROM bookings b WHERE b.customer_id = c.id)
+		 FROM customers c
+		 WHERE `+where+`
+		 ORDER BY c.name, c.id
+		 LIMIT $3 OFFSET $4`,
+		query, tag, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	customers := []models.CustomerSummary{}
+	for rows.Next() {
+		var summary models.CustomerSummary
+		if err := scanCustomer(rows, &summary.Customer, &summary.BookingCount, &summary.LastBooking); err != nil {
+			return nil, 0, err
+		}
+		customers = append(customers, summary)
+	}
+
+	return customers, total, nil
+}
+
+func (r *CustomerRepository) GetStats(customerID int) (models.CustomerStats, error) {
+	var stats models.CustomerStats
+	err := database.DB.QueryRow(
+		`SELECT
+		   (SELECT COUNT(*) FROM bookings WHERE customer_id = $1),
+		   (SELECT COUNT(*) FROM bookings WHERE customer_id = $1 AND status = 'completed'),
+		   (SELECT COUNT(*) FROM quotes WHERE customer_id = $1),
+		   COALESCE((SELECT SUM(i.total_amount - ib.amount_credited) FROM invoices i JOIN invoice_balances ib ON ib.invoice_id = i.id
+		             WHERE i.customer_id = $1 AND i.archived_at IS NULL AND i.status NOT IN ('cancelled', 'void')), 0),
+		   COALESCE((SELECT SUM(ib.amount_paid) FROM invoices i JOIN invoice_balances ib ON ib.invoice_id = i.id
+		             WHERE i.customer_id = $1 AND i.archived_at IS NULL), 0),
+		   COALESCE((SELECT SUM(GREATEST(ib.balance_due, 0)) FROM invoices i JOIN invoice_balances ib ON ib.invoice_id = i.id
+		             WHERE i.customer_id = $1 AND i.archived_at IS NULL AND i.status IN ('pending', 'partially_paid', 'overdue')), 0),
+		   COALESCE((SELECT SUM(amount) FROM customer_credits WHERE customer_id = $1), 0)`,
+		customerID,
+	).Scan(&stats.Bookings, &stats.Completed, &stats.Quotes, &stats.TotalBilled, &stats.TotalPaid, &stats.Outstanding, &stats.Credit)
+	return stats, err
+}
+
+func (r *CustomerRepository) GetContacts(customerID int) ([]models.CustomerContact, error) {
+	rows, err := database.DB.Query(
+		`SELECT id, customer_id, COALESCE(name, ''), COALESCE(email, ''), COALESCE(phone, ''), COALESCE(label, ''), created_at
+		 FROM customer_contacts WHERE customer_id = $1 ORDER BY id`,
+		customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []models.CustomerContact{}
+	for rows.Next() {
+		var contact models.CustomerContact
+		err := rows.Scan(&contact.ID, &contact.CustomerID, &contact.Name, &contact.Email, &contact.Phone, &contact.Label, &contact.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+	return contacts, nil
+}
+
+func (r *CustomerRepository) AddContact(contact *models.CustomerContact) error {
+	contact.CreatedAt = time.Now()
+	return database.DB.QueryRow(
+		`INSERT INTO customer_contacts (customer_id, name, email, phone, label, created_at)
+		 VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6) RETURNING id`,
+		contact.CustomerID, contact.Name, contact.Email, contact.Phone, contact.Label, contact.CreatedAt,
+	).Scan(&contact.ID)
+}
+
+func (r *CustomerRepository) DeleteContact(customerID, contactID int) error {
+	result, err := database.DB.Exec("DELETE FROM customer_contacts WHERE id = $1 AND customer_id = $2", contactID, customerID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *CustomerRepository) GetNotes(customerID int) ([]models.CustomerNote, error) {
+	rows, err := database.DB.Query(
+		`SELECT n.id, n.customer_id, n.author_id, COALESCE(u.first_name || ' ' || u.last_name, ''), n.body, n.created_at
+		 FROM customer_notes n
+		 LEFT JOIN users u ON n.author_id = u.id
+		 WHERE n.customer_id = $1
+		 ORDER BY n.created_at DESC, n.id DESC`,
+		customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.CustomerNote{}
+	for rows.Next() {
+		var note models.CustomerNote
+		if err := rows.Scan(&note.ID, &note.CustomerID, &note.AuthorID, &note.AuthorName, &note.Body, &note.CreatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+func (r *CustomerRepository) AddNote(note *models.CustomerNote) error {
+	note.CreatedAt = time.Now()
+	return database.DB.QueryRow(
+		`INSERT INTO customer_notes (customer_id, author_id, body, created_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		note.CustomerID, note.AuthorID, note.Body, note.CreatedAt,
+	).Scan(&note.ID)
+}
+
+// GetTimeline returns the latest events of a customer, newest first: bookings
+// made, their status changes and reschedules, quotes, invoices, payments,
+// refunds, credit notes, voided invoices, messages and staff notes
+func (r *CustomerRepository) GetTimeline(customerID, limit int) ([]models.TimelineEvent, error) {
+	rows, err := database.DB.Query(
+		`SELECT type, ref_id, at, summary FROM (
+		   SELECT 'booking' AS type, b.id AS ref_id, b.created_at AS at,
+		          'Booked ' || s.name || ' for ' || TO_CHAR(b.scheduled_date, 'YYYY-MM-DD') AS summary
+		   FROM bookings b JOIN services s ON b.service_id = s.id
+		   WHERE b.customer_id = $1 AND NOT COALESCE(b.is_custom_invoice, false)
+		   UNION ALL
+		   SELECT 'status', h.booking_id, h.created_at,
+		          'Booking #' || h.booking_id || ' ' || COALESCE(h.from_status || ' → ', '') || h.to_status
+		   FROM booking_status_history h JOIN bookings b ON h.booking_id = b.id
+		   WHERE b.customer_id = $1
+		   UNION ALL
+		   SELECT 'reschedule', h.booking_id, h.created_at,
+		          'Booking #' || h.booking_id || ' moved to ' || TO_CHAR(h.to_date, 'YYYY-MM-DD') || ' ' || TO_CHAR(h.to_time, 'HH24:MI')
+		   FROM booking_reschedules h JOIN bookings b ON h.booking_id = b.id
+		   WHERE b.customer_id = $1
+		   UNION ALL
+		   SELECT 'quote', q.id, q.created_at, 'Requested a quote for ' || s.name
+		   FROM quotes q JOIN services s ON q.service_id = s.id
+		   WHERE q.customer_id = $1
+		   UNION ALL
+		   SELECT 'invoice', i.id, i.issue_date, 'Invoice ' || i.invoice_number || ' issued for ' || TO_CHAR(i.total_amount, 'FM999999990.00')
+		   FROM invoices i WHERE i.customer_id = $1 AND i.archived_at IS NULL
+		   UNION ALL
+		   SELECT 'payment', i.id, p.paid_at, 'Paid ' || TO_CHAR(p.amount, 'FM999999990.00') || ' on invoice ' || i.invoice_number
+		   FROM payments p JOIN invoices i ON p.invoice_id = i.id WHERE i.customer_id = $1 AND i.archived_at IS NULL
+		   UNION ALL
+		   SELECT 'refund', i.id, r.refunded_at, 'Refunded ' || TO_CHAR(r.amount, 'FM999999990.00') || ' on invoice ' || i.invoice_number
+		   FROM refunds r JOIN payments p ON r.payment_id = p.id JOIN invoices i ON p.invoice_id = i.id
+		   WHERE i.customer_id = $1 AND i.archived_at IS NULL
+		   UNION ALL
+		   SELECT 'credit_note', cn.id, cn.created_at,
+		          'Credit note ' || cn.credit_note_number || ' issued for ' || TO_CHAR(cn.total_amount, 'FM999999990.00') || ' on invoice ' || i.invoice_number
+		   FROM credit_notes cn JOIN invoices i ON cn.invoice_id = i.id WHERE i.customer_id = $1 AND i.archived_at IS NULL
+		   UNION ALL
+		   SELECT 'void', i.id, i.voided_at, 'Invoice ' || i.invoice_number || ' voided'
+		   FROM invoices i WHERE i.customer_id = $1 AND i.archived_at IS NULL AND i.voided_at IS NOT NULL
+		   UNION ALL
+		   SELECT 'message', m.id, m.created_at, 'Wrote in: ' || m.subject
+		   FROM contact_messages m WHERE m.customer_id = $1
+		   UNION ALL
+		   SELECT 'note', n.id, n.created_at, n.body
+		   FROM customer_notes n WHERE n.customer_id = $1
+		 ) events
+		 ORDER BY at DESC, type, ref_id DESC
+		 LIMIT $2`,
+		customerID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.TimelineEvent{}
+	for rows.Next() {
+		var event models.TimelineEvent
+		if err := rows.Scan(&event.Type, &event.RefID, &event.At, &event.Summary); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// MergeCustomers folds a duplicate into a customer in one transaction. All
+// records of the duplicate move over, its tags are added, blank profile fields
+// are filled from it and its email is kept as a contact. The duplicate stays
+// behind, pointing at the customer it was merged into.
+func (r *CustomerRepository) MergeCustomers(customerID, duplicateID int, authorID *int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock both rows in id order so concurrent merges can't deadlock
+	var customer, duplicate models.Customer
+	rows, err := tx.Query(`SELECT `+customerColumns+` FROM customers c WHERE c.id IN ($1, $2) ORDER BY c.id FOR UPDATE`, customerID, duplicateID)
+	if err != nil {
+		return err
+	}
+	found := 0
+	for rows.Next() {
+		var c models.Customer
+		if err := scanCustomer(rows, &c); err != nil {
+			rows.Close()
+			return err
+		}
+		if c.ID == customerID {
+			customer = c
+		} else {
+			duplicate = c
+		}
+		found++
+	}
+	rows.Close()
+	if found != 2 {
+		return sql.ErrNoRows
+	}
+	if customer.MergedIntoID != nil || duplicate.MergedIntoID != nil {
+		return errors.New("customer already merged")
+	}
+	if customer.UserID != nil && duplicate.UserID != nil {
+		return errors.New("both customers have accounts")
+	}
+
+	for _, table := range []string{"bookings", "quotes", "invoices", "contact_messages", "customer_contacts", "customer_properties", "customer_notes", "promo_redemptions", "customer_credits"} {
+		if _, err := tx.Exec("UPDATE "+table+" SET customer_id = $1 WHERE customer_id = $2", customerID, duplicateID); err != nil {
+			return err
+		}
+	}
+
+	if duplicate.Email != "" && !strings.EqualFold(duplicate.Email, customer.Email) {
+		_, err = tx.Exec(
+			`INSERT INTO customer_contacts (customer_id, name, email, phone, label, created_at)
+			 VALUES ($1, $2, $3, NULLIF($4, ''), 'merged', $5)`,
+			customerID, duplicate.Name, duplicate.Email, duplicate.Phone, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The account moves along; the duplicate must let go of it first because
+	// user_id is unique
+	userID := customer.UserID
+	if userID == nil && duplicate.UserID != nil {
+		userID = duplicate.UserID
+		if _, err := tx.Exec("UPDATE customers SET user_id = NULL WHERE id = $1", duplicateID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(
+		`UPDATE customers SET
+		   user_id = $1,
+		   company = COALESCE(company, NULLIF($2, '')),
+		   email = COALESCE(email, NULLIF($3, '')),
+		   phone = COALESCE(phone, NULLIF($4, '')),
+		   billing_address = COALESCE(billing_address, NULLIF($5, '')),
+		   billing_city = COALESCE(billing_city, NULLIF($6, '')),
+		   billing_state = COALESCE(billing_state, NULLIF($7, '')),
+		   billing_zip_code = COALESCE(billing_zip_code, NULLIF($8, '')),
+		   billing_country = COALESCE(billing_country, NULLIF($9, '')),
+		   tags = ARRAY(SELECT DISTINCT unnest(tags || $10::TEXT[])),
+		   updated_at = $11
+		 WHERE id = $12`,
+		userID, duplicate.Company, duplicate.Email, duplicate.Phone, duplicate.BillingAddress, duplicate.BillingCity,
+		duplicate.BillingState, duplicate.BillingZipCode, duplicate.BillingCountry, pq.Array(duplicate.Tags), now, customerID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE customers SET merged_into_id = $1, updated_at = $2 WHERE id = $3", customerID, now, duplicateID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO customer_notes (customer_id, author_id, body, created_at) VALUES ($1, $2, $3, $4)",
+		customerID, authorID, fmt.Sprintf("Merged duplicate customer #%d (%s)", duplicateID, duplicate.Name), now,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type GuestClaimRepository struct{}
+
+// ClaimGuestHistory links the guest bookings, quotes and invoices made with
+// email (case-insensitively) that no account owns yet to the user, and records
+// the claim, in one transaction. Invoices are matched by the email billed or
+// by their customer's, so custom invoices come along too; invoices of another
+// account's bookings are left alone even if the email matches.
+func (r *GuestClaimRepository) ClaimGuestHistory(userID int, email string, claimedBy *int) (*models.GuestClaim, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	claim := &models.GuestClaim{UserID: userID, Email: email, ClaimedBy: claimedBy, CreatedAt: time.Now()}
+
+	result, err := tx.Exec(
+		`UPDATE bookings SET user_id = $1, updated_at = $3
+		 WHERE user_id IS NULL AND is_guest_booking = true AND LOWER(guest_email) = LOWER($2)`,
+		userID, email, claim.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Bookings, err = affectedRows(result); err != nil {
+		return nil, err
+	}
+
+	result, err = tx.Exec(
+		`UPDATE quotes SET user_id = $1, updated_at = $3
+		 WHERE user_id IS NULL AND LOWER(contact_email) = LOWER($2)`,
+		userID, email, claim.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Quotes, err = affectedRows(result); err != nil {
+		return nil, err
+	}
+
+	result, err = tx.Exec(
+		`UPDATE invoices SET user_id = $1, updated_at = $3
+		 WHERE user_id IS NULL
+		   AND (LOWER(customer_email) = LOWER($2)
+		        OR customer_id IN (SELECT id FROM customers WHERE user_id IS NULL AND LOWER(email) = LOWER($2)))
+		   AND (booking_id IS NULL OR booking_id IN (SELECT id FROM bookings WHERE user_id IS NULL OR user_id = $1))`,
+		userID, email, claim.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Invoices, err = affectedRows(result); err != nil {
+		return nil, err
+	}
+
+	// Nothing to record when there was no guest history left to claim
+	if claim.Bookings+claim.Quotes+claim.Invoices == 0 {
+		return claim, tx.Commit()
+	}
+
+	err = tx.QueryRow(
+		`INSERT INTO guest_claims (user_id, email, bookings, quotes, invoices, claimed_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		claim.UserID, claim.Email, claim.Bookings, claim.Quotes, claim.Invoices, claim.ClaimedBy, claim.CreatedAt,
+	).Scan(&claim.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return claim, tx.Commit()
+}
+
+func (r *GuestClaimRepository) GetClaims(userID int) ([]models.GuestClaim, error) {
+	rows, err := database.DB.Query(
+		`SELECT id, user_id, email, bookings, quotes, invoices, claimed_by, created_at
+		 FROM guest_claims WHERE user_id = $1 ORDER BY created_at, id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	claims := []models.GuestClaim{}
+	for rows.Next() {
+		var claim models.GuestClaim
+		err := rows.Scan(&claim.ID, &claim.UserID, &claim.Email, &claim.Bookings, &claim.Quotes,
+			&claim.Invoices, &claim.ClaimedBy, &claim.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+
+	return claims, nil
+}
+
+func affectedRows(result sql.Result) (int, error) {
+	n, err := result.RowsAffected()
+	return int(n), err
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -16,7 +17,8 @@ func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
 	return &InvoiceRepository{db: db}
 }
 
-// CreateInvoice creates a new invoice with line items
+// CreateInvoice creates a new invoice with line items. It numbers the
+// invoice by its issue date.
 func (r *InvoiceRepository) CreateInvoice(invoice *models.Invoice, items []models.InvoiceItem) error {
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -33,6 +35,19 @@ func (r *InvoiceRepository) CreateInvoice(invoice *models.Invoice, items []model
 		}
 	}()
 
+	err = insertInvoice(tx, invoice, items)
+	return err
+}
+
+// insertInvoice numbers and stores an invoice and its line items in a
+// transaction
+func insertInvoice(tx *sql.Tx, invoice *models.Invoice, items []models.InvoiceItem) error {
+	var err error
+	invoice.InvoiceNumber, err = NextDocumentNumber(tx, models.DocumentTypeInvoice, invoice.IssueDate)
+	if err != nil {
+		return err
+	}
+
 	// Insert main invoice record
 	query := `
 		INSERT INTO invoices (
@@ -42,12 +57,16 @@ func (r *InvoiceRepository) CreateInvoice(invoice *models.Invoice, items []model
 			service_address, service_city, service_state, service_zip_code,
 			subtotal, tax_rate, tax_amount, total_amount,
 			status, payment_method, florida_tax_id, tax_exempt, tax_exempt_reason,
-			notes, terms, created_at, updated_at
+			notes, terms, created_at, updated_at, kind, parent_invoice_id, customer_id, property_id
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
-			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29
+			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31,
+			(SELECT customer_id FROM bookings WHERE id = $1), (SELECT property_id FROM bookings WHERE id = $1)
 		) RETURNING id`
 
+	if invoice.Kind == "" {
+		invoice.Kind = models.InvoiceKindBooking
+	}
 	err = tx.QueryRow(query,
 		invoice.BookingID, invoice.InvoiceNumber, invoice.IssueDate, invoice.DueDate,
 		invoice.CustomerName, invoice.CustomerEmail, invoice.CustomerPhone,
@@ -55,7 +74,7 @@ func (r *InvoiceRepository) CreateInvoice(invoice *models.Invoice, items []model
 		invoice.ServiceAddress, invoice.ServiceCity, invoice.ServiceState, invoice.ServiceZipCode,
 		invoice.Subtotal, invoice.TaxRate, invoice.TaxAmount, invoice.TotalAmount,
 		invoice.Status, invoice.PaymentMethod, invoice.FloridaTaxID, invoice.TaxExempt, invoice.TaxExemptReason,
-		invoice.Notes, invoice.Terms, invoice.CreatedAt, invoice.UpdatedAt,
+		invoice.Notes, invoice.Terms, invoice.CreatedAt, invoice.UpdatedAt, invoice.Kind, invoice.ParentInvoiceID,
 	).Scan(&invoice.ID)
 
 	if err != nil {
@@ -90,25 +109,26 @@ func (r *InvoiceRepository) GetInvoiceByID(id int) (*models.InvoiceResponse, err
 	var paymentReference sql.NullString
 	
 	query := `SELECT 
-		id, booking_id, invoice_number, issue_date, due_date,
+		id, booking_id, kind, parent_invoice_id, invoice_number, issue_date, due_date,
 		customer_name, customer_email, customer_phone,
 		billing_address, billing_city, billing_state, billing_zip_code, billing_country,
 		service_address, service_city, service_state, service_zip_code,
 		subtotal, tax_rate, tax_amount, total_amount,
 		status, payment_method, payment_date, payment_reference,
 		florida_tax_id, tax_exempt, tax_exempt_reason,
-		notes, terms, created_at, updated_at
+		notes, terms, created_at, updated_at, archived_at, voided_at, COALESCE(void_reason, '')
 		FROM invoices WHERE id = $1`
 
 	err := r.db.QueryRow(query, id).Scan(
-		&invoice.ID, &invoice.BookingID, &invoice.InvoiceNumber, &invoice.IssueDate, &invoice.DueDate,
+		&invoice.ID, &invoice.BookingID, &invoice.Kind, &invoice.ParentInvoiceID, &invoice.InvoiceNumber, &invoice.IssueDate, &invoice.DueDate,
 		&invoice.CustomerName, &invoice.CustomerEmail, &invoice.CustomerPhone,
 		&invoice.BillingAddress, &invoice.BillingCity, &invoice.BillingState, &invoice.BillingZipCode, &invoice.BillingCountry,
 		&invoice.ServiceAddress, &invoice.ServiceCity, &invoice.ServiceState, &invoice.ServiceZipCode,
 		&invoice.Subtotal, &invoice.TaxRate, &invoice.TaxAmount, &invoice.TotalAmount,
 		&invoice.Status, &invoice.PaymentMethod, &paymentDate, &paymentReference,
 		&invoice.FloridaTaxID, &invoice.TaxExempt, &invoice.TaxExemptReason,
-		&invoice.Notes, &invoice.Terms, &invoice.CreatedAt, &invoice.UpdatedAt,
+		&invoice.Notes, &invoice.Terms, &invoice.CreatedAt, &invoice.UpdatedAt, &invoice.ArchivedAt,
+		&invoice.VoidedAt, &invoice.VoidReason,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -164,25 +184,25 @@ func (r *InvoiceRepository) GetInvoiceByID(id int) (*models.InvoiceResponse, err
 		ServiceName: serviceName,
 		BookingDate: bookingDate,
 	}
+	if err := withBalance(response); err != nil {
+		return nil, err
+	}
+	if response.LateFeeInvoices, err = (&LateFeeRepository{}).GetLateFeeInvoices(id); err != nil {
+		return nil, fmt.Errorf("failed to get late fee invoices: %v", err)
+	}
 
 	return response, nil
 }
 
-// GetInvoiceByBookingID retrieves an invoice by booking ID
+// GetInvoiceByBookingID retrieves a booking's own invoice, leaving out the
+// fee and late-fee invoices billed for it
 func (r *InvoiceRepository) GetInvoiceByBookingID(bookingID int) (*models.InvoiceResponse, error) {
-	var invoice models.Invoice
-	query := `SELECT * FROM invoices WHERE booking_id = $1`
+	var id int
+	query := `SELECT id FROM invoices
+		WHERE booking_id = $1 AND kind = $2 AND archived_at IS NULL AND status <> 'void'
+		ORDER BY id LIMIT 1`
 
-	err := r.db.QueryRow(query, bookingID).Scan(
-		&invoice.ID, &invoice.BookingID, &invoice.InvoiceNumber, &invoice.IssueDate, &invoice.DueDate,
-		&invoice.CustomerName, &invoice.CustomerEmail, &invoice.CustomerPhone,
-		&invoice.BillingAddress, &invoice.BillingCity, &invoice.BillingState, &invoice.BillingZipCode, &invoice.BillingCountry,
-		&invoice.ServiceAddress, &invoice.ServiceCity, &invoice.ServiceState, &invoice.ServiceZipCode,
-		&invoice.Subtotal, &invoice.TaxRate, &invoice.TaxAmount, &invoice.TotalAmount,
-		&invoice.Status, &invoice.PaymentMethod, &invoice.PaymentDate, &invoice.PaymentReference,
-		&invoice.FloridaTaxID, &invoice.TaxExempt, &invoice.TaxExemptReason,
-		&invoice.Notes, &invoice.Terms, &invoice.CreatedAt, &invoice.UpdatedAt,
-	)
+	err := r.db.QueryRow(query, bookingID, models.InvoiceKindBooking).Scan(&id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("invoice not found for booking")
@@ -190,14 +210,115 @@ func (r *InvoiceRepository) GetInvoiceByBookingID(bookingID int) (*models.Invoic
 		return nil, fmt.Errorf("failed to get invoice: %v", err)
 	}
 
-	return r.GetInvoiceByID(invoice.ID)
+	return r.GetInvoiceByID(id)
+}
+
+// GetInvoicesByUserID returns a client's invoices: those of their bookings and
+// those claimed from their guest history
+func (r *InvoiceRepository) GetInvoicesByUserID(userID int) ([]models.InvoiceResponse, error) {
+	rows, err := r.db.Query(
+		`SELECT i.id FROM invoices i
+		 JOIN bookings b ON i.booking_id = b.id
+		 WHERE (i.user_id = $1 OR b.user_id = $1) AND i.archived_at IS NULL
+		 ORDER BY i.issue_date DESC, i.id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoices: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan invoice: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.getInvoicesByIDs(ids)
+}
+
+// GetInvoicesByBookingID returns every invoice billed for a booking that is
+// not void or archived: its own and its fee and late-fee invoices, oldest
+// first
+func (r *InvoiceRepository) GetInvoicesByBookingID(bookingID int) ([]models.InvoiceResponse, error) {
+	rows, err := r.db.Query(
+		`SELECT id FROM invoices
+		 WHERE booking_id = $1 AND archived_at IS NULL AND status <> 'void'
+		 ORDER BY id`,
+		bookingID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoices: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan invoice: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.getInvoicesByIDs(ids)
+}
+
+func (r *InvoiceRepository) getInvoicesByIDs(ids []int) ([]models.InvoiceResponse, error) {
+	invoices := []models.InvoiceResponse{}
+	for _, id := range ids {
+		invoice, err := r.GetInvoiceByID(id)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, *invoice)
+	}
+	return invoices, nil
+}
+
+// LinkBooking records the invoice on its booking
+func (r *InvoiceRepository) LinkBooking(bookingID, invoiceID int) error {
+	_, err := r.db.Exec("UPDATE bookings SET invoice_id = $1 WHERE id = $2", invoiceID, bookingID)
+	return err
+}
+
+// Advisory lock namespace for per-invoice payment locks
+const invoiceLockNamespace = 2
+
+// LockInvoice serializes payments of one invoice, across every API instance,
+// so it can't be charged twice. Call the returned function to release it.
+func (r *InvoiceRepository) LockInvoice(id int) (func(), error) {
+	ctx := context.Background()
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1, $2)", invoiceLockNamespace, id); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1, $2)", invoiceLockNamespace, id)
+		conn.Close()
+	}, nil
 }
 
 // GetAllInvoices retrieves all invoices with pagination
 func (r *InvoiceRepository) GetAllInvoices(limit, offset int) ([]models.InvoiceResponse, int, error) {
 	// Get total count
 	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM invoices`
+	countQuery := `SELECT COUNT(*) FROM invoices WHERE archived_at IS NULL`
 	err := r.db.QueryRow(countQuery).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get invoice count: %v", err)
@@ -205,15 +326,15 @@ func (r *InvoiceRepository) GetAllInvoices(limit, offset int) ([]models.InvoiceR
 
 	// Get invoices
 	query := `SELECT 
-		id, booking_id, invoice_number, issue_date, due_date,
+		id, booking_id, kind, parent_invoice_id, invoice_number, issue_date, due_date,
 		customer_name, customer_email, customer_phone,
 		billing_address, billing_city, billing_state, billing_zip_code, billing_country,
 		service_address, service_city, service_state, service_zip_code,
 		subtotal, tax_rate, tax_amount, total_amount,
 		status, payment_method, payment_date, payment_reference,
 		florida_tax_id, tax_exempt, tax_exempt_reason,
-		notes, terms, created_at, updated_at
-		FROM invoices ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+		notes, terms, created_at, updated_at, voided_at, COALESCE(void_reason, '')
+		FROM invoices WHERE archived_at IS NULL ORDER BY created_at DESC LIMIT $1 OFFSET $2`
 	
 	rows, err := r.db.Query(query, limit, offset)
 	if err != nil {
@@ -228,7 +349,7 @@ func (r *InvoiceRepository) GetAllInvoices(limit, offset int) ([]models.InvoiceR
 		var paymentReference sql.NullString
 		
 		err = rows.Scan(
-			&invoice.ID, &invoice.BookingID, &invoice.InvoiceNumber, &invoice.IssueDate, &invoice.DueDate,
+			&invoice.ID, &invoice.BookingID, &invoice.Kind, &invoice.ParentInvoiceID, &invoice.InvoiceNumber, &invoice.IssueDate, &invoice.DueDate,
 			&invoice.CustomerName, &invoice.CustomerEmail, &invoice.CustomerPhone,
 			&invoice.BillingAddress, &invoice.BillingCity, &invoice.BillingState, &invoice.BillingZipCode, &invoice.BillingCountry,
 			&invoice.ServiceAddress, &invoice.ServiceCity, &invoice.ServiceState, &invoice.ServiceZipCode,
@@ -236,6 +357,7 @@ func (r *InvoiceRepository) GetAllInvoices(limit, offset int) ([]models.InvoiceR
 			&invoice.Status, &invoice.PaymentMethod, &paymentDate, &paymentReference,
 			&invoice.FloridaTaxID, &invoice.TaxExempt, &invoice.TaxExemptReason,
 			&invoice.Notes, &invoice.Terms, &invoice.CreatedAt, &invoice.UpdatedAt,
+			&invoice.VoidedAt, &invoice.VoidReason,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan invoice: %v", err)
@@ -293,26 +415,22 @@ func (r *InvoiceRepository) GetAllInvoices(limit, offset int) ([]models.InvoiceR
 			ServiceName: serviceName,
 			BookingDate: bookingDate,
 		}
+		if err := withBalance(&response); err != nil {
+			return nil, 0, err
+		}
 		responses = append(responses, response)
 	}
 
 	return responses, totalCount, nil
 }
 
-// UpdateInvoice updates an invoice
-func (r *InvoiceRepository) UpdateInvoice(id int, updates *models.InvoiceUpdateRequest) error {
-	query := `
-		UPDATE invoices SET 
-			status = COALESCE(NULLIF($2, ''), status),
-			payment_method = COALESCE(NULLIF($3, ''), payment_method),
-			payment_date = COALESCE($4, payment_date),
-			payment_reference = COALESCE(NULLIF($5, ''), payment_reference),
-			notes = COALESCE(NULLIF($6, ''), notes),
-			updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1`
-
-	result, err := r.db.Exec(query, id, updates.Status, updates.PaymentMethod, 
-		updates.PaymentDate, updates.PaymentReference, updates.Notes)
+// UpdateStatus sets an invoice's status. What an issued invoice says can't
+// be changed.
+func (r *InvoiceRepository) UpdateStatus(id int, status string) error {
+	result, err := r.db.Exec(
+		`UPDATE invoices SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		id, status,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to update invoice: %v", err)
 	}
@@ -329,11 +447,72 @@ func (r *InvoiceRepository) UpdateInvoice(id int, updates *models.InvoiceUpdateR
 	return nil
 }
 
+// VoidInvoice voids an invoice that should not have been issued. The record
+// is kept. Only an invoice nothing has been paid or credited on can be
+// voided; any other is corrected with a credit note.
+func (r *InvoiceRepository) VoidInvoice(id int, reason string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM invoices WHERE id = $1 FOR UPDATE`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("invoice not found")
+	}
+	if err != nil {
+		return err
+	}
+	if !isSettleable(status) {
+		return fmt.Errorf("invoice is void")
+	}
+
+	var paid, credited float64
+	err = tx.QueryRow(
+		`SELECT amount_paid, amount_credited FROM invoice_balances WHERE invoice_id = $1`, id,
+	).Scan(&paid, &credited)
+	if err != nil {
+		return err
+	}
+	if roundCents(paid) != 0 || roundCents(credited) != 0 {
+		return fmt.Errorf("invoice has payments or credit notes")
+	}
+
+	_, err = tx.Exec(
+		`UPDATE invoices SET status = $2, voided_at = CURRENT_TIMESTAMP, void_reason = $3, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $1`,
+		id, models.InvoiceStatusVoid, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to void invoice: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// MarkOverdue moves invoices still owed after their due date to overdue and
+// returns how many it moved
+func (r *InvoiceRepository) MarkOverdue() (int64, error) {
+	result, err := r.db.Exec(
+		`UPDATE invoices i SET status = $1, updated_at = CURRENT_TIMESTAMP
+		 FROM invoice_balances ib
+		 WHERE ib.invoice_id = i.id AND i.status IN ($2, $3) AND i.due_date < CURRENT_DATE
+		   AND i.archived_at IS NULL AND ib.balance_due > 0`,
+		models.InvoiceStatusOverdue, models.InvoiceStatusPending, models.InvoiceStatusPartiallyPaid,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark invoices overdue: %v", err)
+	}
+	return result.RowsAffected()
+}
+
 // GetInvoicesByStatus retrieves invoices by status
 func (r *InvoiceRepository) GetInvoicesByStatus(status string, limit, offset int) ([]models.InvoiceResponse, int, error) {
 	// Get total count for this status
 	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM invoices WHERE status = $1`
+	countQuery := `SELECT COUNT(*) FROM invoices WHERE status = $1 AND archived_at IS NULL`
 	err := r.db.QueryRow(countQuery, status).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get invoice count: %v", err)
@@ -342,15 +521,15 @@ func (r *InvoiceRepository) GetInvoicesByStatus(status string, limit, offset int
 	// Get invoices
 	var invoices []models.Invoice
 	query := `SELECT 
-		id, booking_id, invoice_number, issue_date, due_date,
+		id, booking_id, kind, parent_invoice_id, invoice_number, issue_date, due_date,
 		customer_name, customer_email, customer_phone,
 		billing_address, billing_city, billing_state, billing_zip_code, billing_country,
 		service_address, service_city, service_state, service_zip_code,
 		subtotal, tax_rate, tax_amount, total_amount,
 		status, payment_method, payment_date, payment_reference,
 		florida_tax_id, tax_exempt, tax_exempt_reason,
-		notes, terms, created_at, updated_at
-		FROM invoices WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+		notes, terms, created_at, updated_at, voided_at, COALESCE(void_reason, '')
+		FROM invoices WHERE status = $1 AND archived_at IS NULL ORDER BY created_at DESC LIMIT $2 OFFSET $3`
 	rows, err := r.db.Query(query, status, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get invoices by status: %v", err)
@@ -363,7 +542,7 @@ func (r *InvoiceRepository) GetInvoicesByStatus(status string, limit, offset int
 		var paymentReference sql.NullString
 
 		err = rows.Scan(
-			&invoice.ID, &invoice.BookingID, &invoice.InvoiceNumber, &invoice.IssueDate, &invoice.DueDate,
+			&invoice.ID, &invoice.BookingID, &invoice.Kind, &invoice.ParentInvoiceID, &invoice.InvoiceNumber, &invoice.IssueDate, &invoice.DueDate,
 			&invoice.CustomerName, &invoice.CustomerEmail, &invoice.CustomerPhone,
 			&invoice.BillingAddress, &invoice.BillingCity, &invoice.BillingState, &invoice.BillingZipCode, &invoice.BillingCountry,
 			&invoice.ServiceAddress, &invoice.ServiceCity, &invoice.ServiceState, &invoice.ServiceZipCode,
@@ -371,6 +550,7 @@ func (r *InvoiceRepository) GetInvoicesByStatus(status string, limit, offset int
 			&invoice.Status, &invoice.PaymentMethod, &paymentDate, &paymentReference,
 			&invoice.FloridaTaxID, &invoice.TaxExempt, &invoice.TaxExemptReason,
 			&invoice.Notes, &invoice.Terms, &invoice.CreatedAt, &invoice.UpdatedAt,
+			&invoice.VoidedAt, &invoice.VoidReason,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan invoice: %v", err)
@@ -427,39 +607,25 @@ func (r *InvoiceRepository) GetInvoicesByStatus(status string, limit, offset int
 			ServiceName: serviceName,
 			BookingDate: bookingDate,
 		}
+		if err := withBalance(&response); err != nil {
+			return nil, 0, err
+		}
 		responses = append(responses, response)
 	}
 
 	return responses, totalCount, nil
 }
 
-// DeleteInvoice deletes an invoice and its items
-func (r *InvoiceRepository) DeleteInvoice(id int) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
-	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
-
-	// Delete invoice items first
-	_, err = tx.Exec("DELETE FROM invoice_items WHERE invoice_id = $1", id)
-	if err != nil {
-		return fmt.Errorf("failed to delete invoice items: %v", err)
-	}
-
-	// Delete invoice
-	result, err := tx.Exec("DELETE FROM invoices WHERE id = $1", id)
+// SetArchived archives an invoice or brings it back. Invoices are financial
+// records, so they are never deleted.
+func (r *InvoiceRepository) SetArchived(id int, archived bool) error {
+	result, err := r.db.Exec(
+		`UPDATE invoices SET archived_at = CASE WHEN $2 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END,
+		 updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		id, archived,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to delete invoice: %v", err)
+		return fmt.Errorf("failed to archive invoice: %v", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -474,16 +640,56 @@ func (r *InvoiceRepository) DeleteInvoice(id int) error {
 	return nil
 }
 
-// GenerateInvoiceNumber generates a unique invoice number
-func (r *InvoiceRepository) GenerateInvoiceNumber() (string, error) {
-	var count int
-	query := `SELECT COUNT(*) FROM invoices WHERE EXTRACT(YEAR FROM created_at) = EXTRACT(YEAR FROM CURRENT_DATE)`
-	err := r.db.QueryRow(query).Scan(&count)
+// IsInvoiceOwner reports whether an invoice belongs to a client: it is for
+// one of their bookings or was claimed from their guest history. Archived
+// invoices belong to nobody.
+func (r *InvoiceRepository) IsInvoiceOwner(id, userID int) (bool, error) {
+	var owned bool
+	err := r.db.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM invoices i
+			JOIN bookings b ON i.booking_id = b.id
+			WHERE i.id = $1 AND (i.user_id = $2 OR b.user_id = $2) AND i.archived_at IS NULL
+		)`,
+		id, userID,
+	).Scan(&owned)
+	if err != nil {
+		return false, fmt.Errorf("failed to check invoice owner: %v", err)
+	}
+	return owned, nil
+}
+
+// GetLatestDocument returns the newest PDF stored for an invoice, or nil if
+// none has been rendered yet
+func (r *InvoiceRepository) GetLatestDocument(invoiceID int) (*models.InvoiceDocument, error) {
+	var doc models.InvoiceDocument
+	err := r.db.QueryRow(
+		`SELECT id, invoice_id, version, pdf, sha256, invoice_updated_at, created_at
+		 FROM invoice_documents WHERE invoice_id = $1
+		 ORDER BY version DESC LIMIT 1`,
+		invoiceID,
+	).Scan(&doc.ID, &doc.InvoiceID, &doc.Version, &doc.PDF, &doc.SHA256, &doc.InvoiceUpdatedAt, &doc.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to get invoice count: %v", err)
+		return nil, fmt.Errorf("failed to get invoice document: %v", err)
 	}
+	return &doc, nil
+}
 
-	year := time.Now().Year()
-	invoiceNumber := fmt.Sprintf("PP-%d-%05d", year, count+1)
-	return invoiceNumber, nil
-}
\ No newline at end of file
+// CreateDocument stores a PDF as the invoice's next version. Hold the
+// invoice's lock so two renders can't take the same version.
+func (r *InvoiceRepository) CreateDocument(doc *models.InvoiceDocument) error {
+	err := r.db.QueryRow(
+		`INSERT INTO invoice_documents (invoice_id, version, pdf, sha256, invoice_updated_at)
+		 SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4
+		 FROM invoice_documents WHERE invoice_id = $1
+		 RETURNING id, version, created_at`,
+		doc.InvoiceID, doc.PDF, doc.SHA256, doc.InvoiceUpdatedAt,
+	).Scan(&doc.ID, &doc.Version, &doc.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store invoice document: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"cleaning-app-backend/internal/database"
+)
+
+type JobRepository struct{}
+
+// Advisory lock namespace for background job locks
+const jobLockNamespace = 4
+
+// Background jobs, keyed in jobLockNamespace
+const (
+	JobInvoiceOverdue   = 1
+	JobSeriesGeneration = 2
+	JobQuoteExpiry      = 3
+)
+
+// TryLock takes a background job's lock unless another API instance holds
+// it, in which case ok is false. Call the returned function to release it.
+func (r *JobRepository) TryLock(job int) (unlock func(), ok bool, err error) {
+	ctx := context.Background()
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1, $2)", jobLockNamespace, job).Scan(&ok); err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+
+	return func() {
+		conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1, $2)", jobLockNamespace, job)
+		conn.Close()
+	}, true, nil
+}
@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+// LateFeeRepository bills late fees on overdue invoices. An issued invoice
+// can't be changed, so the fees are billed on an invoice of their own, and
+// every month of an invoice is billed once: late_fees is unique by invoice
+// and month.
+type LateFeeRepository struct{}
+
+// LateFeeCandidate is an overdue invoice and the months it has been billed
+// late fees for
+type LateFeeCandidate struct {
+	InvoiceID    int
+	DueDate      time.Time
+	BilledMonths int
+}
+
+// GetCandidates returns the overdue invoices still owed, leaving out those
+// that bill late fees themselves
+func (r *LateFeeRepository) GetCandidates() ([]LateFeeCandidate, error) {
+	rows, err := database.DB.Query(
+		`SELECT i.id, i.due_date, COALESCE((SELECT MAX(period) FROM late_fees WHERE invoice_id = i.id), 0)
+		 FROM invoices i JOIN invoice_balances ib ON ib.invoice_id = i.id
+		 WHERE i.status = $1 AND i.archived_at IS NULL AND ib.balance_due > 0
+		   AND NOT EXISTS (SELECT 1 FROM late_fees WHERE fee_invoice_id = i.id)
+		 ORDER BY i.id`,
+		models.InvoiceStatusOverdue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []LateFeeCandidate{}
+	for rows.Next() {
+		var candidate LateFeeCandidate
+		if err := rows.Scan(&candidate.InvoiceID, &candidate.DueDate, &candidate.BilledMonths); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// GetLateFeeInvoices lists the invoices billing late fees on an invoice
+func (r *LateFeeRepository) GetLateFeeInvoices(invoiceID int) ([]models.LateFeeInvoice, error) {
+	rows, err := database.DB.Query(
+		`SELECT i.id, i.invoice_number, i.issue_date, i.total_amount, i.status, ib.balance_due
+		 FROM invoices i JOIN invoice_balances ib ON ib.invoice_id = i.id
+		 WHERE i.parent_invoice_id = $1 AND i.kind = $2 AND i.archived_at IS NULL
+		 ORDER BY i.id`,
+		invoiceID, models.InvoiceKindLateFee,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []models.LateFeeInvoice{}
+	for rows.Next() {
+		var invoice models.LateFeeInvoice
+		if err := rows.Scan(&invoice.ID, &invoice.InvoiceNumber, &invoice.IssueDate, &invoice.TotalAmount, &invoice.Status, &invoice.BalanceDue); err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+	return invoices, rows.Err()
+}
+
+// MonthsPastDue is how many full months have passed since an invoice was due
+func MonthsPastDue(dueDate, today time.Time) int {
+	months := 0
+	for !dueDate.AddDate(0, months+1, 0).After(today) {
+		months++
+	}
+	return months
+}
+
+// BillLateFees bills an overdue invoice's late fees for every full month past
+// due not billed yet, each at rate of what it still owes. They are billed on
+// one new invoice with a line per month, addressed like the overdue one, and
+// returned; nil when there was nothing to bill.
+func (r *LateFeeRepository) BillLateFees(invoiceID int, rate float64, today time.Time) (*models.Invoice, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var overdue models.Invoice
+	err = tx.QueryRow(
+		`SELECT booking_id, invoice_number, due_date, status, customer_name, customer_email, customer_phone,
+		        billing_address, billing_city, billing_state, billing_zip_code, billing_country,
+		        service_address, service_city, service_state, service_zip_code, florida_tax_id, terms
+		 FROM invoices WHERE id = $1 AND archived_at IS NULL FOR UPDATE`,
+		invoiceID,
+	).Scan(&overdue.BookingID, &overdue.InvoiceNumber, &overdue.DueDate, &overdue.Status,
+		&overdue.CustomerName, &overdue.CustomerEmail, &overdue.CustomerPhone,
+		&overdue.BillingAddress, &overdue.BillingCity, &overdue.BillingState, &overdue.BillingZipCode, &overdue.BillingCountry,
+		&overdue.ServiceAddress, &overdue.ServiceCity, &overdue.ServiceState, &overdue.ServiceZipCode,
+		&overdue.FloridaTaxID, &overdue.Terms)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("invoice not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if overdue.Status != models.InvoiceStatusOverdue {
+		return nil, nil
+	}
+
+	_, balance, err := invoiceBalance(tx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	var billed int
+	err = tx.QueryRow(`SELECT COALESCE(MAX(period), 0) FROM late_fees WHERE invoice_id = $1`, invoiceID).Scan(&billed)
+	if err != nil {
+		return nil, err
+	}
+
+	fee := roundCents(balance * rate)
+	months := MonthsPastDue(overdue.DueDate, today)
+	if fee <= 0 || months <= billed {
+		return nil, nil
+	}
+
+	invoice := &models.Invoice{
+		BookingID:       overdue.BookingID,
+		Kind:            models.InvoiceKindLateFee,
+		ParentInvoiceID: &invoiceID,
+		IssueDate:       today,
+		DueDate:         today.AddDate(0, 0, models.DefaultDueDays),
+		CustomerName:    overdue.CustomerName,
+		CustomerEmail:   overdue.CustomerEmail,
+		CustomerPhone:   overdue.CustomerPhone,
+		BillingAddress:  overdue.BillingAddress,
+		BillingCity:     overdue.BillingCity,
+		BillingState:    overdue.BillingState,
+		BillingZipCode:  overdue.BillingZipCode,
+		BillingCountry:  overdue.BillingCountry,
+		ServiceAddress:  overdue.ServiceAddress,
+		ServiceCity:     overdue.ServiceCity,
+		ServiceState:    overdue.ServiceState,
+		ServiceZipCode:  overdue.ServiceZipCode,
+		Status:          models.InvoiceStatusPending,
+		FloridaTaxID:    overdue.FloridaTaxID,
+		Notes:           "Late fees on invoice " + overdue.InvoiceNumber,
+		Terms:           overdue.Terms,
+		CreatedAt:       today,
+		UpdatedAt:       today,
+	}
+	items := []models.InvoiceItem{}
+	for period := billed + 1; period <= months; period++ {
+		items = append(items, models.InvoiceItem{
+			Description: fmt.Sprintf("Late fee on invoice %s, month %d past due (%.1f%% of $%.2f)",
+				overdue.InvoiceNumber, period, rate*100, balance),
+			Quantity:   1,
+			UnitPrice:  fee,
+			TotalPrice: fee,
+			Taxable:    false, // fees are not a taxable service
+		})
+	}
+	invoice.Subtotal = roundCents(fee * float64(len(items)))
+	invoice.TotalAmount = invoice.Subtotal
+
+	if err := insertInvoice(tx, invoice, items); err != nil {
+		return nil, err
+	}
+	// The fees belong to whoever owns the overdue invoice
+	_, err = tx.Exec(
+		`UPDATE invoices SET user_id = o.user_id, customer_id = o.customer_id
+		 FROM invoices o WHERE invoices.id = $1 AND o.id = $2`,
+		invoice.ID, invoiceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for period := billed + 1; period <= months; period++ {
+		_, err := tx.Exec(
+			`INSERT INTO late_fees (invoice_id, period, fee_invoice_id, rate, balance, amount)
+			 VALUES ($1, $2, $3, $4, $5, $6)`,
+			invoiceID, period, invoice.ID, rate, balance, fee,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record late fee: %v", err)
+		}
+	}
+
+	return invoice, tx.Commit()
+}
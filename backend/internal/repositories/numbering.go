@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type NumberingRepository struct{}
+
+// The numbers already issued for each document type, to start a new
+// sequence after
+var issuedNumbers = map[string]string{
+	models.DocumentTypeInvoice:    "SELECT invoice_number FROM invoices",
+	models.DocumentTypeCreditNote: "SELECT credit_note_number FROM credit_notes",
+}
+
+func (r *NumberingRepository) GetSchemes() ([]models.NumberingScheme, error) {
+	rows, err := database.DB.Query(
+		`SELECT document_type, format, reset, updated_at FROM numbering_schemes ORDER BY document_type`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schemes := []models.NumberingScheme{}
+	for rows.Next() {
+		var scheme models.NumberingScheme
+		if err := rows.Scan(&scheme.DocumentType, &scheme.Format, &scheme.Reset, &scheme.UpdatedAt); err != nil {
+			return nil, err
+		}
+		schemes = append(schemes, scheme)
+	}
+	return schemes, rows.Err()
+}
+
+// SaveScheme changes how a document type is numbered. It returns
+// sql.ErrNoRows for an unknown type.
+func (r *NumberingRepository) SaveScheme(scheme *models.NumberingScheme) error {
+	return database.DB.QueryRow(
+		`UPDATE numbering_schemes SET format = $2, reset = $3, updated_at = CURRENT_TIMESTAMP
+		 WHERE document_type = $1 RETURNING updated_at`,
+		scheme.DocumentType, scheme.Format, scheme.Reset,
+	).Scan(&scheme.UpdatedAt)
+}
+
+// NextDocumentNumber takes the next number for a document issued at the
+// given time. Call it in the transaction that stores the document: the
+// counter stays locked until it commits, and a rollback gives the number
+// back. A period's counter starts after the highest number already issued
+// in the format, so changing the format can't reuse a number.
+func NextDocumentNumber(tx *sql.Tx, documentType string, issued time.Time) (string, error) {
+	var scheme models.NumberingScheme
+	err := tx.QueryRow(
+		`SELECT document_type, format, reset FROM numbering_schemes WHERE document_type = $1`,
+		documentType,
+	).Scan(&scheme.DocumentType, &scheme.Format, &scheme.Reset)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s numbering: %v", documentType, err)
+	}
+	period := scheme.Period(issued)
+
+	next := func() (int, error) {
+		var seq int
+		err := tx.QueryRow(
+			`UPDATE document_sequences SET last_value = last_value + 1
+			 WHERE document_type = $1 AND period = $2 RETURNING last_value`,
+			documentType, period,
+		).Scan(&seq)
+		return seq, err
+	}
+
+	seq, err := next()
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(
+			`INSERT INTO document_sequences (document_type, period, last_value)
+			 SELECT $1, $2, COALESCE(MAX(CAST(SUBSTRING(number FROM $3) AS INTEGER)), 0)
+			 FROM (`+issuedNumbers[documentType]+`) AS issued(number)
+			 WHERE number ~ $3
+			 ON CONFLICT (document_type, period) DO NOTHING`,
+			documentType, period, scheme.Pattern(issued),
+		)
+		if err == nil {
+			seq, err = next()
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate %s number: %v", documentType, err)
+	}
+
+	return scheme.Number(issued, seq), nil
+}
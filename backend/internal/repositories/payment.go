@@ -0,0 +1,369 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type PaymentRepository struct{}
+
+const paymentColumns = `id, invoice_id, amount, credited, method, reference, paid_at, notes, recorded_by, created_at`
+
+func scanPayment(scanner interface{ Scan(...interface{}) error }, payment *models.Payment) error {
+	return scanner.Scan(
+		&payment.ID, &payment.InvoiceID, &payment.Amount, &payment.Credited, &payment.Method,
+		&payment.Reference, &payment.PaidAt, &payment.Notes, &payment.RecordedBy, &payment.CreatedAt,
+	)
+}
+
+func (r *PaymentRepository) GetPaymentsByInvoiceID(invoiceID int) ([]models.Payment, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+paymentColumns+` FROM payments WHERE invoice_id = $1 ORDER BY paid_at, id`,
+		invoiceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []models.Payment{}
+	for rows.Next() {
+		var payment models.Payment
+		if err := scanPayment(rows, &payment); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
+}
+
+// RecordPayment stores a payment of an invoice and moves the invoice to
+// partially_paid or paid. A payment without an amount pays the balance due.
+// What is paid beyond the balance goes to the customer's credit, and a credit
+// payment spends it. The invoice row stays locked until the payment is
+// stored, so concurrent payments see each other.
+func (r *PaymentRepository) RecordPayment(payment *models.Payment) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status, number string
+	var customerID *int
+	err = tx.QueryRow(
+		`SELECT status, customer_id, invoice_number FROM invoices
+		 WHERE id = $1 AND archived_at IS NULL FOR UPDATE`,
+		payment.InvoiceID,
+	).Scan(&status, &customerID, &number)
+	if err == sql.ErrNoRows {
+		return errors.New("invoice not found")
+	}
+	if err != nil {
+		return err
+	}
+	switch status {
+	case models.InvoiceStatusPending, models.InvoiceStatusPartiallyPaid, models.InvoiceStatusOverdue:
+	default:
+		return errors.New("invoice is not payable")
+	}
+
+	_, balance, err := invoiceBalance(tx, payment.InvoiceID)
+	if err != nil {
+		return err
+	}
+	balance = math.Max(balance, 0)
+	if payment.Amount == 0 {
+		payment.Amount = balance
+	}
+	payment.Amount = roundCents(payment.Amount)
+	if payment.Amount <= 0 {
+		return errors.New("invoice has no balance due")
+	}
+	payment.Credited = roundCents(math.Max(payment.Amount-balance, 0))
+
+	if payment.Credited > 0 && customerID == nil {
+		return errors.New("invoice has no customer to credit")
+	}
+	if payment.Method == models.PaymentMethodCredit {
+		if customerID == nil {
+			return errors.New("insufficient credit")
+		}
+		if payment.Credited > 0 {
+			return errors.New("credit payment exceeds balance due")
+		}
+		// Lock the customer so two invoices can't spend the same credit
+		if _, err := tx.Exec(`SELECT id FROM customers WHERE id = $1 FOR UPDATE`, *customerID); err != nil {
+			return err
+		}
+		var available float64
+		if err := tx.QueryRow(
+			`SELECT COALESCE(SUM(amount), 0) FROM customer_credits WHERE customer_id = $1`, *customerID,
+		).Scan(&available); err != nil {
+			return err
+		}
+		if payment.Amount > roundCents(available) {
+			return errors.New("insufficient credit")
+		}
+	}
+
+	err = tx.QueryRow(
+		`INSERT INTO payments (invoice_id, amount, credited, method, reference, paid_at, notes, recorded_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, created_at`,
+		payment.InvoiceID, payment.Amount, payment.Credited, payment.Method, payment.Reference,
+		payment.PaidAt, payment.Notes, payment.RecordedBy,
+	).Scan(&payment.ID, &payment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record payment: %v", err)
+	}
+
+	if status, err = settledStatus(tx, payment.InvoiceID, status); err != nil {
+		return err
+	}
+	// The invoice's payment fields show the latest payment
+	_, err = tx.Exec(
+		`UPDATE invoices SET status = $2, payment_method = $3, payment_reference = $4,
+		 payment_date = $5, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $1`,
+		payment.InvoiceID, status, payment.Method, payment.Reference, payment.PaidAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if payment.Method == models.PaymentMethodCredit {
+		if err := addCredit(tx, *customerID, -payment.Amount, payment.ID, "Paid invoice "+number); err != nil {
+			return err
+		}
+	}
+	if payment.Credited > 0 {
+		if err := addCredit(tx, *customerID, payment.Credited, payment.ID, "Overpaid invoice "+number); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RecordRefund stores a refund of a payment. It can give back what the
+// payment paid towards its invoice, less earlier refunds; a refund with the
+// credit method goes to the customer's credit. The invoice owes the refunded
+// amount again unless a credit note covers it.
+func (r *PaymentRepository) RecordRefund(refund *models.Refund) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var invoiceID int
+	var applied float64
+	var status, number string
+	var customerID *int
+	err = tx.QueryRow(
+		`SELECT i.id, p.amount - p.credited, i.status, i.customer_id, i.invoice_number
+		 FROM payments p JOIN invoices i ON p.invoice_id = i.id
+		 WHERE p.id = $1
+		 FOR UPDATE OF i`,
+		refund.PaymentID,
+	).Scan(&invoiceID, &applied, &status, &customerID, &number)
+	if err == sql.ErrNoRows {
+		return errors.New("payment not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	var refunded float64
+	if err := tx.QueryRow(
+		`SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id = $1`, refund.PaymentID,
+	).Scan(&refunded); err != nil {
+		return err
+	}
+	refund.Amount = roundCents(refund.Amount)
+	if refund.Amount > roundCents(applied-refunded) {
+		return errors.New("refund exceeds payment")
+	}
+	if refund.Method == models.PaymentMethodCredit && customerID == nil {
+		return errors.New("invoice has no customer to credit")
+	}
+
+	err = tx.QueryRow(
+		`INSERT INTO refunds (payment_id, amount, method, reference, reason, refunded_at, recorded_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at`,
+		refund.PaymentID, refund.Amount, refund.Method, refund.Reference, refund.Reason,
+		refund.RefundedAt, refund.RecordedBy,
+	).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record refund: %v", err)
+	}
+
+	if isSettleable(status) {
+		if status, err = settledStatus(tx, invoiceID, status); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(
+		`UPDATE invoices SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, invoiceID, status,
+	); err != nil {
+		return err
+	}
+
+	if refund.Method == models.PaymentMethodCredit {
+		if err := addCredit(tx, *customerID, refund.Amount, refund.PaymentID, "Refund on invoice "+number); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// invoiceBalance is what an invoice has been paid, net of refunds, and what
+// is left owing after payments and credit notes
+func invoiceBalance(tx *sql.Tx, invoiceID int) (paid, balance float64, err error) {
+	err = tx.QueryRow(
+		`SELECT amount_paid, balance_due FROM invoice_balances WHERE invoice_id = $1`, invoiceID,
+	).Scan(&paid, &balance)
+	return roundCents(paid), roundCents(balance), err
+}
+
+// isSettleable reports whether payments and credit notes decide an invoice's
+// status. Void and cancelled invoices keep theirs.
+func isSettleable(status string) bool {
+	return status != models.InvoiceStatusVoid && status != models.InvoiceStatusCancelled
+}
+
+// settledStatus is the status of an invoice once its payments, refunds and
+// credit notes are counted. An overdue invoice stays overdue until it is
+// settled.
+func settledStatus(tx *sql.Tx, invoiceID int, status string) (string, error) {
+	paid, balance, err := invoiceBalance(tx, invoiceID)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case balance <= 0 && paid > 0:
+		return models.InvoiceStatusPaid, nil
+	case balance <= 0:
+		return models.InvoiceStatusCredited, nil
+	case status == models.InvoiceStatusOverdue:
+		return status, nil
+	case paid > 0:
+		return models.InvoiceStatusPartiallyPaid, nil
+	}
+	return models.InvoiceStatusPending, nil
+}
+
+func addCredit(tx *sql.Tx, customerID int, amount float64, paymentID int, description string) error {
+	_, err := tx.Exec(
+		`INSERT INTO customer_credits (customer_id, amount, payment_id, description) VALUES ($1, $2, $3, $4)`,
+		customerID, amount, paymentID, description,
+	)
+	return err
+}
+
+// GetCustomerCredits returns a customer's credit entries, newest first, and
+// the credit they add up to
+func (r *PaymentRepository) GetCustomerCredits(customerID int) ([]models.CustomerCredit, float64, error) {
+	rows, err := database.DB.Query(
+		`SELECT c.id, c.customer_id, c.amount, c.payment_id, p.invoice_id, c.description, c.created_at
+		 FROM customer_credits c
+		 LEFT JOIN payments p ON c.payment_id = p.id
+		 WHERE c.customer_id = $1
+		 ORDER BY c.created_at DESC, c.id DESC`,
+		customerID,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	credits := []models.CustomerCredit{}
+	var balance float64
+	for rows.Next() {
+		var credit models.CustomerCredit
+		if err := rows.Scan(
+			&credit.ID, &credit.CustomerID, &credit.Amount, &credit.PaymentID, &credit.InvoiceID,
+			&credit.Description, &credit.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		credits = append(credits, credit)
+		balance += credit.Amount
+	}
+	return credits, roundCents(balance), rows.Err()
+}
+
+// GetRefundsByInvoiceID returns the refunds of an invoice's payments
+func (r *PaymentRepository) GetRefundsByInvoiceID(invoiceID int) ([]models.Refund, error) {
+	rows, err := database.DB.Query(
+		`SELECT r.id, r.payment_id, r.amount, r.method, r.reference, r.reason, r.refunded_at, r.recorded_by, r.created_at
+		 FROM refunds r JOIN payments p ON r.payment_id = p.id
+		 WHERE p.invoice_id = $1
+		 ORDER BY r.refunded_at, r.id`,
+		invoiceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []models.Refund{}
+	for rows.Next() {
+		var refund models.Refund
+		if err := rows.Scan(
+			&refund.ID, &refund.PaymentID, &refund.Amount, &refund.Method, &refund.Reference,
+			&refund.Reason, &refund.RefundedAt, &refund.RecordedBy, &refund.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, rows.Err()
+}
+
+// withBalance adds an invoice's payments, their refunds, its credit notes
+// and its balance to it
+func withBalance(invoice *models.InvoiceResponse) error {
+	payments := &PaymentRepository{}
+	var err error
+	if invoice.Payments, err = payments.GetPaymentsByInvoiceID(invoice.ID); err != nil {
+		return fmt.Errorf("failed to get payments: %v", err)
+	}
+	refunds, err := payments.GetRefundsByInvoiceID(invoice.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get refunds: %v", err)
+	}
+	for i := range invoice.Payments {
+		payment := &invoice.Payments[i]
+		payment.Refunds = []models.Refund{}
+		for _, refund := range refunds {
+			if refund.PaymentID == payment.ID {
+				payment.Refunds = append(payment.Refunds, refund)
+				payment.Refunded = roundCents(payment.Refunded + refund.Amount)
+			}
+		}
+	}
+	if invoice.CreditNotes, err = (&CreditNoteRepository{}).GetCreditNotesByInvoiceID(invoice.ID); err != nil {
+		return fmt.Errorf("failed to get credit notes: %v", err)
+	}
+
+	err = database.DB.QueryRow(
+		`SELECT amount_paid, amount_credited, balance_due FROM invoice_balances WHERE invoice_id = $1`, invoice.ID,
+	).Scan(&invoice.AmountPaid, &invoice.AmountCredited, &invoice.BalanceDue)
+	if err != nil {
+		return fmt.Errorf("failed to get invoice balance: %v", err)
+	}
+	return nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type PricingRepository struct{}
+
+// GetServicePricing returns the pricing rules of a service. A service without
+// rules gets empty ones.
+func (r *PricingRepository) GetServicePricing(serviceID int) (*models.ServicePricing, error) {
+	pricing := &models.ServicePricing{ServiceID: serviceID, SizeTiers: []models.SizeTier{}}
+	err := database.DB.QueryRow(
+		`SELECT price_per_bedroom, price_per_bathroom, minimum_charge, updated_at
+		 FROM service_pricing WHERE service_id = $1`,
+		serviceID,
+	).Scan(&pricing.PricePerBedroom, &pricing.PricePerBathroom, &pricing.MinimumCharge, &pricing.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT up_to_square_meters, price FROM service_size_tiers
+		 WHERE service_id = $1 ORDER BY up_to_square_meters`,
+		serviceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tier models.SizeTier
+		if err := rows.Scan(&tier.UpToSquareMeters, &tier.Price); err != nil {
+			return nil, err
+		}
+		pricing.SizeTiers = append(pricing.SizeTiers, tier)
+	}
+	return pricing, nil
+}
+
+// SaveServicePricing replaces the pricing rules of a service
+func (r *PricingRepository) SaveServicePricing(pricing *models.ServicePricing) (err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+	pricing.UpdatedAt = &now
+	_, err = tx.Exec(
+		`INSERT INTO service_pricing (service_id, price_per_bedroom, price_per_bathroom, minimum_charge, updated_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (service_id) DO UPDATE SET price_per_bedroom = EXCLUDED.price_per_bedroom,
+		 price_per_bathroom = EXCLUDED.price_per_bathroom, minimum_charge = EXCLUDED.minimum_charge,
+		 updated_at = EXCLUDED.updated_at`,
+		pricing.ServiceID, pricing.PricePerBedroom, pricing.PricePerBathroom, pricing.MinimumCharge, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM service_size_tiers WHERE service_id = $1", pricing.ServiceID); err != nil {
+		return err
+	}
+	for _, tier := range pricing.SizeTiers {
+		_, err = tx.Exec(
+			"INSERT INTO service_size_tiers (service_id, up_to_square_meters, price) VALUES ($1, $2, $3)",
+			pricing.ServiceID, tier.UpToSquareMeters, tier.Price,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const addOnColumns = `a.id, a.code, a.name, a.price, a.duration_hours, a.active, a.created_at, a.updated_at`
+
+func scanAddOn(scanner interface{ Scan(...interface{}) error }, addOn *models.AddOn) error {
+	return scanner.Scan(&addOn.ID, &addOn.Code, &addOn.Name, &addOn.Price, &addOn.DurationHours, &addOn.Active, &addOn.CreatedAt, &addOn.UpdatedAt)
+}
+
+func (r *PricingRepository) GetAddOns(activeOnly bool) ([]models.AddOn, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+addOnColumns+` FROM add_ons a WHERE a.active OR NOT $1 ORDER BY a.name`,
+		activeOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAddOns(rows)
+}
+
+// GetServiceAddOnsByCode returns the active add-ons with the given codes
+// that can be booked with a service
+func (r *PricingRepository) GetServiceAddOnsByCode(serviceID int, codes []string) ([]models.AddOn, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+addOnColumns+` FROM add_ons a
+		 JOIN service_add_ons sa ON sa.add_on_id = a.id AND sa.service_id = $1
+		 WHERE a.active AND a.code = ANY($2) ORDER BY a.name`,
+		serviceID, pq.Array(codes),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAddOns(rows)
+}
+
+func scanAddOns(rows *sql.Rows) ([]models.AddOn, error) {
+	addOns := []models.AddOn{}
+	for rows.Next() {
+		var addOn models.AddOn
+		if err := scanAddOn(rows, &addOn); err != nil {
+			return nil, err
+		}
+		addOns = append(addOns, addOn)
+	}
+	return addOns, nil
+}
+
+func (r *PricingRepository) GetAddOn(id int) (*models.AddOn, error) {
+	var addOn models.AddOn
+	if err := scanAddOn(database.DB.QueryRow(`SELECT `+addOnColumns+` FROM add_ons a WHERE a.id = $1`, id), &addOn); err != nil {
+		return nil, err
+	}
+	return &addOn, nil
+}
+
+func (r *PricingRepository) CreateAddOn(addOn *models.AddOn) error {
+	now := time.Now()
+	addOn.CreatedAt = now
+	addOn.UpdatedAt = now
+	return database.DB.QueryRow(
+		`INSERT INTO add_ons (code, name, price, duration_hours, active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		addOn.Code, addOn.Name, addOn.Price, addOn.DurationHours, addOn.Active, now, now,
+	).Scan(&addOn.ID)
+}
+
+func (r *PricingRepository) UpdateAddOn(addOn *models.AddOn) error {
+	addOn.UpdatedAt = time.Now()
+	result, err := database.DB.Exec(
+		`UPDATE add_ons SET code = $1, name = $2, price = $3, duration_hours = $4, active = $5, updated_at = $6 WHERE id = $7`,
+		addOn.Code, addOn.Name, addOn.Price, addOn.DurationHours, addOn.Active, addOn.UpdatedAt, addOn.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type PromoRepository struct{}
+
+// Redemptions on cancelled bookings don't count towards usage limits
+const promoUses = `(SELECT COUNT(*) FROM promo_redemptions r JOIN bookings b ON r.booking_id = b.id
+	WHERE r.promo_code_id = p.id AND b.status <> 'cancelled')`
+
+const promoCodeColumns = `p.id, p.code, p.description, p.discount_type, p.discount_value, p.min_order,
+	p.starts_on, p.ends_on, p.max_uses, p.max_uses_per_customer, p.first_booking_only, p.service_ids,
+	p.active, ` + promoUses + `, p.created_at, p.updated_at`
+
+func scanPromoCode(scanner interface{ Scan(...interface{}) error }, promo *models.PromoCode) error {
+	err := scanner.Scan(
+		&promo.ID, &promo.Code, &promo.Description, &promo.DiscountType, &promo.DiscountValue, &promo.MinOrder,
+		&promo.StartsOn, &promo.EndsOn, &promo.MaxUses, &promo.MaxUsesPerCustomer, &promo.FirstBookingOnly,
+		pq.Array(&promo.ServiceIDs), &promo.Active, &promo.Uses, &promo.CreatedAt, &promo.UpdatedAt,
+	)
+	if promo.ServiceIDs == nil {
+		promo.ServiceIDs = []int64{}
+	}
+	return err
+}
+
+func (r *PromoRepository) GetPromoCodes() ([]models.PromoCode, error) {
+	rows, err := database.DB.Query(`SELECT ` + promoCodeColumns + ` FROM promo_codes p ORDER BY p.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promos := []models.PromoCode{}
+	for rows.Next() {
+		var promo models.PromoCode
+		if err := scanPromoCode(rows, &promo); err != nil {
+			return nil, err
+		}
+		promos = append(promos, promo)
+	}
+	return promos, nil
+}
+
+func (r *PromoRepository) GetPromoCode(id int) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := scanPromoCode(database.DB.QueryRow(`SELECT `+promoCodeColumns+` FROM promo_codes p WHERE p.id = $1`, id), &promo); err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+// GetPromoCodeByCode looks a code up the way customers type it, ignoring case
+func (r *PromoRepository) GetPromoCodeByCode(code string) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := scanPromoCode(database.DB.QueryRow(`SELECT `+promoCodeColumns+` FROM promo_codes p WHERE UPPER(p.code) = UPPER($1)`, code), &promo); err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *PromoRepository) CreatePromoCode(promo *models.PromoCode) error {
+	now := time.Now()
+	promo.CreatedAt = now
+	promo.UpdatedAt = now
+	return database.DB.QueryRow(
+		`INSERT INTO promo_codes (code, description, discount_type, discount_value, min_order, starts_on, ends_on,
+		 max_uses, max_uses_per_customer, first_booking_only, service_ids, active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id`,
+		promo.Code, promo.Description, promo.DiscountType, promo.DiscountValue, promo.MinOrder, promo.StartsOn, promo.EndsOn,
+		promo.MaxUses, promo.MaxUsesPerCustomer, promo.FirstBookingOnly, pq.Array(promo.ServiceIDs), promo.Active, now, now,
+	).Scan(&promo.ID)
+}
+
+func (r *PromoRepository) UpdatePromoCode(promo *models.PromoCode) error {
+	promo.UpdatedAt = time.Now()
+	result, err := database.DB.Exec(
+		`UPDATE promo_codes SET code = $1, description = $2, discount_type = $3, discount_value = $4, min_order = $5,
+		 starts_on = $6, ends_on = $7, max_uses = $8, max_uses_per_customer = $9, first_booking_only = $10,
+		 service_ids = $11, active = $12, updated_at = $13 WHERE id = $14`,
+		promo.Code, promo.Description, promo.DiscountType, promo.DiscountValue, promo.MinOrder, promo.StartsOn, promo.EndsOn,
+		promo.MaxUses, promo.MaxUsesPerCustomer, promo.FirstBookingOnly, pq.Array(promo.ServiceIDs), promo.Active,
+		promo.UpdatedAt, promo.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CountCustomerUses counts a customer's redemptions of a code on bookings
+// that weren't cancelled
+func (r *PromoRepository) CountCustomerUses(promoCodeID, customerID int) (int, error) {
+	var count int
+	err := database.DB.QueryRow(
+		`SELECT COUNT(*) FROM promo_redemptions r JOIN bookings b ON r.booking_id = b.id
+		 WHERE r.promo_code_id = $1 AND r.customer_id = $2 AND b.status <> 'cancelled'`,
+		promoCodeID, customerID,
+	).Scan(&count)
+	return count, err
+}
+
+// CountCustomerBookings counts a customer's bookings that weren't cancelled
+func (r *PromoRepository) CountCustomerBookings(customerID int) (int, error) {
+	var count int
+	err := database.DB.QueryRow(
+		"SELECT COUNT(*) FROM bookings WHERE customer_id = $1 AND status <> 'cancelled'",
+		customerID,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *PromoRepository) CreateRedemption(redemption *models.PromoRedemption) error {
+	redemption.CreatedAt = time.Now()
+	return database.DB.QueryRow(
+		`INSERT INTO promo_redemptions (promo_code_id, booking_id, customer_id, discount, created_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		redemption.PromoCodeID, redemption.BookingID, redemption.CustomerID, redemption.Discount, redemption.CreatedAt,
+	).Scan(&redemption.ID)
+}
+
+func (r *PromoRepository) GetRedemptions(promoCodeID int) ([]models.PromoRedemption, error) {
+	rows, err := database.DB.Query(
+		`SELECT id, promo_code_id, booking_id, customer_id, discount, created_at
+		 FROM promo_redemptions WHERE promo_code_id = $1 ORDER BY created_at DESC`,
+		promoCodeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := []models.PromoRedemption{}
+	for rows.Next() {
+		var redemption models.PromoRedemption
+		err := rows.Scan(&redemption.ID, &redemption.PromoCodeID, &redemption.BookingID, &redemption.CustomerID,
+			&redemption.Discount, &redemption.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+	return redemptions, nil
+}
+
+// Advisory lock namespace for per-promo-code redemption locks
+const promoLockNamespace = 3
+
+// LockPromoCode serializes redemptions of one code, across every API
+// instance, so its usage limits can't be overrun. Call the returned function
+// to release it.
+func (r *PromoRepository) LockPromoCode(id int) (func(), error) {
+	ctx := context.Background()
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1, $2)", promoLockNamespace, id); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1, $2)", promoLockNamespace, id)
+		conn.Close()
+	}, nil
+}
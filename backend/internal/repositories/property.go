@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type PropertyRepository struct{}
+
+const propertyColumns = `p.id, p.customer_id, COALESCE(p.label, ''), p.street, COALESCE(p.unit, ''),
+		 COALESCE(p.city, ''), COALESCE(p.state, ''), COALESCE(p.zip_code, ''), p.address,
+		 COALESCE(p.access_notes, ''), COALESCE(p.gate_code, ''), COALESCE(p.pets, ''),
+		 p.square_meters, p.bedrooms, p.bathrooms, p.created_at, COALESCE(p.updated_at, p.created_at)`
+
+func scanProperty(scanner interface{ Scan(...interface{}) error }, property *models.CustomerProperty) error {
+	return scanner.Scan(
+		&property.ID, &property.CustomerID, &property.Label, &property.Street, &property.Unit,
+		&property.City, &property.State, &property.ZipCode, &property.Address,
+		&property.AccessNotes, &property.GateCode, &property.Pets,
+		&property.SquareMeters, &property.Bedrooms, &property.Bathrooms, &property.CreatedAt, &property.UpdatedAt,
+	)
+}
+
+func (r *PropertyRepository) GetProperties(customerID int) ([]models.CustomerProperty, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+propertyColumns+` FROM customer_properties p WHERE p.customer_id = $1 ORDER BY p.id`,
+		customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	properties := []models.CustomerProperty{}
+	for rows.Next() {
+		var property models.CustomerProperty
+		if err := scanProperty(rows, &property); err != nil {
+			return nil, err
+		}
+		properties = append(properties, property)
+	}
+	return properties, nil
+}
+
+func (r *PropertyRepository) GetProperty(id int) (*models.CustomerProperty, error) {
+	var property models.CustomerProperty
+	err := scanProperty(database.DB.QueryRow(`SELECT `+propertyColumns+` FROM customer_properties p WHERE p.id = $1`, id), &property)
+	if err != nil {
+		return nil, err
+	}
+	return &property, nil
+}
+
+// FindByAddress returns the customer's property with the given one-line
+// address, ignoring case
+func (r *PropertyRepository) FindByAddress(customerID int, address string) (*models.CustomerProperty, error) {
+	var property models.CustomerProperty
+	err := scanProperty(database.DB.QueryRow(
+		`SELECT `+propertyColumns+` FROM customer_properties p
+		 WHERE p.customer_id = $1 AND LOWER(p.address) = LOWER($2)
+		 ORDER BY p.id LIMIT 1`,
+		customerID, address,
+	), &property)
+	if err != nil {
+		return nil, err
+	}
+	return &property, nil
+}
+
+func (r *PropertyRepository) CreateProperty(property *models.CustomerProperty) error {
+	now := time.Now()
+	property.CreatedAt = now
+	property.UpdatedAt = now
+	return database.DB.QueryRow(
+		`INSERT INTO customer_properties (customer_id, label, street, unit, city, state, zip_code, address,
+		 access_notes, gate_code, pets, square_meters, bedrooms, bathrooms, created_at, updated_at)
+		 VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8,
+		 NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), $12, $13, $14, $15, $16) RETURNING id`,
+		property.CustomerID, property.Label, property.Street, property.Unit, property.City, property.State,
+		property.ZipCode, property.Address, property.AccessNotes, property.GateCode, property.Pets,
+		property.SquareMeters, property.Bedrooms, property.Bathrooms, now, now,
+	).Scan(&property.ID)
+}
+
+// UpdateProperty saves a property. Bookings already made keep the address
+// they were made with.
+func (r *PropertyRepository) UpdateProperty(property *models.CustomerProperty) error {
+	property.UpdatedAt = time.Now()
+	result, err := database.DB.Exec(
+		`UPDATE customer_properties SET label = NULLIF($1, ''), street = $2, unit = NULLIF($3, ''),
+		 city = NULLIF($4, ''), state = NULLIF($5, ''), zip_code = NULLIF($6, ''), address = $7,
+		 access_notes = NULLIF($8, ''), gate_code = NULLIF($9, ''), pets = NULLIF($10, ''),
+		 square_meters = $11, bedrooms = $12, bathrooms = $13, updated_at = $14
+		 WHERE id = $15 AND customer_id = $16`,
+		property.Label, property.Street, property.Unit, property.City, property.State, property.ZipCode,
+		property.Address, property.AccessNotes, property.GateCode, property.Pets,
+		property.SquareMeters, property.Bedrooms, property.Bathrooms, property.UpdatedAt,
+		property.ID, property.CustomerID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *PropertyRepository) DeleteProperty(customerID, propertyID int) error {
+	result, err := database.DB.Exec("DELETE FROM customer_properties WHERE id = $1 AND customer_id = $2", propertyID, customerID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
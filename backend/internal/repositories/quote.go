@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"database/sql"
+
 	"cleaning-app-backend/internal/database"
 	"cleaning-app-backend/internal/models"
 	"time"
@@ -9,14 +11,14 @@ import (
 type QuoteRepository struct{}
 
 func (r *QuoteRepository) CreateQuote(quote *models.Quote) error {
-	query := `INSERT INTO quotes (service_id, square_meters, address, special_requirements, preferred_date, contact_email, contact_name, contact_phone, estimated_price, status, created_at, updated_at) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`
+	query := `INSERT INTO quotes (service_id, square_meters, address, special_requirements, preferred_date, contact_email, contact_name, contact_phone, estimated_price, status, customer_id, property_id, price_breakdown, created_at, updated_at) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
 
 	err := database.DB.QueryRow(
 		query,
 		quote.ServiceID, quote.SquareMeters, quote.Address, quote.SpecialRequirements,
 		quote.PreferredDate, quote.ContactEmail, quote.ContactName, quote.ContactPhone,
-		quote.EstimatedPrice, quote.Status, time.Now(), time.Now(),
+		quote.EstimatedPrice, quote.Status, quote.CustomerID, quote.PropertyID, quote.PriceBreakdown, time.Now(), time.Now(),
 	).Scan(&quote.ID)
 
 	quote.CreatedAt = time.Now()
@@ -26,20 +28,35 @@ func (r *QuoteRepository) CreateQuote(quote *models.Quote) error {
 }
 
 func (r *QuoteRepository) GetQuoteByID(id int) (*models.Quote, error) {
+	return r.getQuote("id=$1", id)
+}
+
+// GetQuoteByTokenHash finds the quote a mailed link was issued for
+func (r *QuoteRepository) GetQuoteByTokenHash(tokenHash string) (*models.Quote, error) {
+	return r.getQuote("access_token_hash=$1", tokenHash)
+}
+
+func (r *QuoteRepository) getQuote(where string, arg interface{}) (*models.Quote, error) {
 	var quote models.Quote
 	err := database.DB.QueryRow(
-		"SELECT id, service_id, square_meters, address, special_requirements, preferred_date, contact_email, contact_name, contact_phone, estimated_price, status, admin_notes, created_at, updated_at FROM quotes WHERE id=$1",
-		id,
-	).Scan(&quote.ID, &quote.ServiceID, &quote.SquareMeters, &quote.Address, &quote.SpecialRequirements, &quote.PreferredDate, &quote.ContactEmail, &quote.ContactName, &quote.ContactPhone, &quote.EstimatedPrice, &quote.Status, &quote.AdminNotes, &quote.CreatedAt, &quote.UpdatedAt)
+		`SELECT id, service_id, square_meters, COALESCE(address, ''), COALESCE(special_requirements, ''), COALESCE(preferred_date, ''),
+		        contact_email, contact_name, COALESCE(contact_phone, ''), estimated_price, status, COALESCE(admin_notes, ''),
+		        user_id, customer_id, property_id, price_breakdown, sent_at, expires_at, responded_at, rejection_reason, booking_id,
+		        created_at, updated_at
+		 FROM quotes WHERE `+where,
+		arg,
+	).Scan(&quote.ID, &quote.ServiceID, &quote.SquareMeters, &quote.Address, &quote.SpecialRequirements, &quote.PreferredDate,
+		&quote.ContactEmail, &quote.ContactName, &quote.ContactPhone, &quote.EstimatedPrice, &quote.Status, &quote.AdminNotes,
+		&quote.UserID, &quote.CustomerID, &quote.PropertyID, &quote.PriceBreakdown, &quote.SentAt, &quote.ExpiresAt,
+		&quote.RespondedAt, &quote.RejectionReason, &quote.BookingID,
+		&quote.CreatedAt, &quote.UpdatedAt)
 
 	return &quote, err
 }
 
 func (r *QuoteRepository) GetAllQuotes() ([]models.QuoteResponse, error) {
 	rows, err := database.DB.Query(
-		`SELECT q.id, q.service_id, s.name, q.square_meters, q.address, q.special_requirements, 
-		         q.preferred_date, q.contact_email, q.contact_name, q.contact_phone, 
-		         q.estimated_price, q.status, COALESCE(q.admin_notes, '') as admin_notes, q.created_at 
+		`SELECT `+quoteColumns+`
 		 FROM quotes q 
 		 JOIN services s ON q.service_id = s.id 
 		 ORDER BY q.created_at DESC`,
@@ -49,14 +66,42 @@ func (r *QuoteRepository) GetAllQuotes() ([]models.QuoteResponse, error) {
 	}
 	defer rows.Close()
 
-	var quotes []models.QuoteResponse
+	return scanQuotes(rows)
+}
+
+// GetQuotesByUserID lists the quotes a client requested or claimed
+func (r *QuoteRepository) GetQuotesByUserID(userID int) ([]models.QuoteResponse, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+quoteColumns+`
+		 FROM quotes q
+		 JOIN services s ON q.service_id = s.id
+		 WHERE q.user_id = $1
+		 ORDER BY q.created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanQuotes(rows)
+}
+
+const quoteColumns = `q.id, q.service_id, s.name, q.square_meters, q.address, q.special_requirements, 
+		         q.preferred_date, q.contact_email, q.contact_name, q.contact_phone, 
+		         q.estimated_price, q.price_breakdown, q.status, COALESCE(q.admin_notes, '') as admin_notes,
+		         q.sent_at, q.expires_at, q.rejection_reason, q.booking_id, q.created_at`
+
+func scanQuotes(rows *sql.Rows) ([]models.QuoteResponse, error) {
+	quotes := []models.QuoteResponse{}
 	for rows.Next() {
 		var quote models.QuoteResponse
 		err := rows.Scan(
 			&quote.ID, &quote.ServiceID, &quote.ServiceName, &quote.SquareMeters,
 			&quote.Address, &quote.SpecialRequirements, &quote.PreferredDate,
 			&quote.ContactEmail, &quote.ContactName, &quote.ContactPhone,
-			&quote.EstimatedPrice, &quote.Status, &quote.AdminNotes, &quote.CreatedAt,
+			&quote.EstimatedPrice, &quote.PriceBreakdown, &quote.Status, &quote.AdminNotes,
+			&quote.SentAt, &quote.ExpiresAt, &quote.RejectionReason, &quote.BookingID, &quote.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -76,4 +121,67 @@ func (r *QuoteRepository) UpdateQuote(quote *models.Quote) error {
 	)
 
 	return err
-}
\ No newline at end of file
+}
+
+// MarkSent records that a quote was mailed with a new link. The previous
+// link stops working. Accepted quotes can't be sent again.
+func (r *QuoteRepository) MarkSent(id int, tokenHash string, expiresAt time.Time) error {
+	result, err := database.DB.Exec(
+		`UPDATE quotes SET status = 'sent', access_token_hash = $2, sent_at = CURRENT_TIMESTAMP, expires_at = $3,
+		 responded_at = NULL, rejection_reason = '', updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $1 AND status <> 'accepted'`,
+		id, tokenHash, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ExpireQuotes marks sent quotes past their expiry as expired
+func (r *QuoteRepository) ExpireQuotes() error {
+	_, err := database.DB.Exec(
+		`UPDATE quotes SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+		 WHERE status = 'sent' AND expires_at <= CURRENT_TIMESTAMP`,
+	)
+	return err
+}
+
+// Respond moves a sent, unexpired quote to accepted or rejected. It reports
+// false when the quote was no longer open, so of two responses racing for
+// the same quote only one wins.
+func (r *QuoteRepository) Respond(id int, status, reason string) (bool, error) {
+	result, err := database.DB.Exec(
+		`UPDATE quotes SET status = $2, rejection_reason = $3, responded_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $1 AND status = 'sent' AND expires_at > CURRENT_TIMESTAMP`,
+		id, status, reason,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Reopen puts an accepted quote back to sent when its booking couldn't be
+// made
+func (r *QuoteRepository) Reopen(id int) error {
+	_, err := database.DB.Exec(
+		`UPDATE quotes SET status = 'sent', responded_at = NULL, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $1 AND status = 'accepted' AND booking_id IS NULL`,
+		id,
+	)
+	return err
+}
+
+// SetBooking links an accepted quote to the booking it became
+func (r *QuoteRepository) SetBooking(id, bookingID int) error {
+	_, err := database.DB.Exec(
+		"UPDATE quotes SET booking_id = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+		id, bookingID,
+	)
+	return err
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type RefreshTokenRepository struct{}
+
+func (r *RefreshTokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	token.CreatedAt = time.Now()
+	return database.DB.QueryRow(
+		query,
+		token.UserID, token.TokenHash, token.FamilyID, token.ExpiresAt, token.CreatedAt,
+	).Scan(&token.ID)
+}
+
+func (r *RefreshTokenRepository) GetRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := database.DB.QueryRow(
+		`SELECT id, user_id, token_hash, family_id, expires_at, revoked_at, replaced_by, created_at
+		 FROM refresh_tokens WHERE token_hash = $1`,
+		tokenHash,
+	).Scan(&token.ID, &token.UserID, &token.TokenHash, &token.FamilyID, &token.ExpiresAt,
+		&token.RevokedAt, &token.ReplacedBy, &token.CreatedAt)
+
+	return &token, err
+}
+
+// RotateRefreshToken revokes the current token and stores its replacement in a
+// single transaction. If the current token was already revoked (for example by
+// a concurrent refresh using the same token) nothing is written.
+func (r *RefreshTokenRepository) RotateRefreshToken(currentID int, next *models.RefreshToken) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL",
+		now, currentID,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("refresh token already used")
+	}
+
+	next.CreatedAt = now
+	err = tx.QueryRow(
+		`INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		next.UserID, next.TokenHash, next.FamilyID, next.ExpiresAt, next.CreatedAt,
+	).Scan(&next.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE refresh_tokens SET replaced_by = $1 WHERE id = $2", next.ID, currentID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *RefreshTokenRepository) RevokeTokenFamily(familyID string) error {
+	_, err := database.DB.Exec(
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL",
+		time.Now(), familyID,
+	)
+	return err
+}
+
+func (r *RefreshTokenRepository) RevokeUserTokens(userID int) error {
+	_, err := database.DB.Exec(
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		time.Now(), userID,
+	)
+	return err
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+	"database/sql"
 	"cleaning-app-backend/internal/database"
 	"cleaning-app-backend/internal/models"
 	"time"
@@ -24,9 +26,9 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 	var user models.User
 	err := database.DB.QueryRow(
-		"SELECT id, email, password_hash, first_name, last_name, phone, role, created_at FROM users WHERE email=$1",
+		"SELECT id, email, password_hash, first_name, last_name, phone, role, email_verified, created_at FROM users WHERE email=$1",
 		email,
-	).Scan(&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.CreatedAt)
+	).Scan(&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.EmailVerified, &user.CreatedAt)
 
 	return &user, err
 }
@@ -34,40 +36,90 @@ func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 func (r *UserRepository) GetUserByID(id int) (*models.User, error) {
 	var user models.User
 	err := database.DB.QueryRow(
-		"SELECT id, email, password_hash, first_name, last_name, phone, role, created_at FROM users WHERE id=$1",
+		"SELECT id, email, password_hash, first_name, last_name, phone, role, email_verified, created_at FROM users WHERE id=$1",
 		id,
-	).Scan(&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.CreatedAt)
+	).Scan(&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.EmailVerified, &user.CreatedAt)
 
 	return &user, err
 }
 
+func (r *UserRepository) GetUsersByRole(role string) ([]models.UserResponse, error) {
+	rows, err := database.DB.Query(
+		"SELECT id, email, first_name, last_name, COALESCE(phone, ''), role, email_verified, created_at FROM users WHERE role=$1 ORDER BY first_name, last_name",
+		role,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.UserResponse{}
+	for rows.Next() {
+		var user models.UserResponse
+		err := rows.Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.EmailVerified, &user.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) UpdatePassword(userID int, passwordHash string) error {
+	_, err := database.DB.Exec(
+		"UPDATE users SET password_hash=$1, updated_at=$2 WHERE id=$3",
+		passwordHash, time.Now(), userID,
+	)
+	return err
+}
+
+func (r *UserRepository) MarkEmailVerified(userID int) error {
+	_, err := database.DB.Exec(
+		"UPDATE users SET email_verified=true, email_verified_at=$1, updated_at=$1 WHERE id=$2",
+		time.Now(), userID,
+	)
+	return err
+}
+
 type ServiceRepository struct{}
 
 func (r *ServiceRepository) CreateService(service *models.Service) error {
-	query := `INSERT INTO services (name, description, base_price, duration_hours, service_type, created_at, updated_at) 
+	query := `INSERT INTO services (name, description, base_price, duration_hours, category_id, created_at, updated_at) 
 	          VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
 
 	err := database.DB.QueryRow(
 		query,
-		service.Name, service.Description, service.BasePrice, service.Duration, service.ServiceType,
+		service.Name, service.Description, service.BasePrice, service.Duration, service.CategoryID,
 		time.Now(), time.Now(),
 	).Scan(&service.ID)
 
 	return err
 }
 
+// GetServiceByID returns a service, archived or not
 func (r *ServiceRepository) GetServiceByID(id int) (*models.Service, error) {
 	var service models.Service
 	err := database.DB.QueryRow(
-		"SELECT id, name, description, base_price, duration_hours, service_type, created_at FROM services WHERE id=$1",
+		`SELECT s.id, s.name, s.description, s.base_price, s.duration_hours, s.category_id, c.slug, s.archived_at, s.created_at
+		 FROM services s JOIN service_categories c ON s.category_id = c.id WHERE s.id=$1`,
 		id,
-	).Scan(&service.ID, &service.Name, &service.Description, &service.BasePrice, &service.Duration, &service.ServiceType, &service.CreatedAt)
+	).Scan(&service.ID, &service.Name, &service.Description, &service.BasePrice, &service.Duration, &service.CategoryID,
+		&service.ServiceType, &service.ArchivedAt, &service.CreatedAt)
 
 	return &service, err
 }
 
-func (r *ServiceRepository) GetAllServices() ([]models.ServiceResponse, error) {
-	rows, err := database.DB.Query("SELECT id, name, description, base_price, duration_hours, service_type, created_at FROM services ORDER BY created_at DESC")
+// GetAllServices lists the catalog with the add-ons each service allows.
+// Archived services are left out unless asked for.
+func (r *ServiceRepository) GetAllServices(includeArchived bool) ([]models.ServiceResponse, error) {
+	rows, err := database.DB.Query(
+		`SELECT s.id, s.name, s.description, s.base_price, s.duration_hours, s.category_id, c.name, c.slug, s.archived_at, s.created_at
+		 FROM services s JOIN service_categories c ON s.category_id = c.id
+		 WHERE s.archived_at IS NULL OR $1
+		 ORDER BY c.sort_order, s.created_at DESC`,
+		includeArchived,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -75,61 +127,217 @@ func (r *ServiceRepository) GetAllServices() ([]models.ServiceResponse, error) {
 
 	var services []models.ServiceResponse
 	for rows.Next() {
-		var service models.ServiceResponse
-		err := rows.Scan(&service.ID, &service.Name, &service.Description, &service.BasePrice, &service.Duration, &service.ServiceType, &service.CreatedAt)
+		service := models.ServiceResponse{AddOns: []models.AddOn{}}
+		err := rows.Scan(&service.ID, &service.Name, &service.Description, &service.BasePrice, &service.Duration,
+			&service.CategoryID, &service.Category, &service.ServiceType, &service.ArchivedAt, &service.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
 		services = append(services, service)
 	}
+	rows.Close()
+
+	addOns, err := database.DB.Query(
+		`SELECT sa.service_id, ` + addOnColumns + ` FROM service_add_ons sa
+		 JOIN add_ons a ON sa.add_on_id = a.id
+		 WHERE a.active ORDER BY a.name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer addOns.Close()
+
+	index := map[int]int{}
+	for i, service := range services {
+		index[service.ID] = i
+	}
+	for addOns.Next() {
+		var serviceID int
+		var addOn models.AddOn
+		err := addOns.Scan(&serviceID, &addOn.ID, &addOn.Code, &addOn.Name, &addOn.Price, &addOn.DurationHours,
+			&addOn.Active, &addOn.CreatedAt, &addOn.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[serviceID]; ok {
+			services[i].AddOns = append(services[i].AddOns, addOn)
+		}
+	}
 
 	return services, nil
 }
 
 func (r *ServiceRepository) UpdateService(service *models.Service) error {
-	query := `UPDATE services SET name=$1, description=$2, base_price=$3, duration_hours=$4, service_type=$5, updated_at=$6 
+	query := `UPDATE services SET name=$1, description=$2, base_price=$3, duration_hours=$4, category_id=$5, updated_at=$6 
 	          WHERE id=$7`
 
-	_, err := database.DB.Exec(
+	result, err := database.DB.Exec(
 		query,
-		service.Name, service.Description, service.BasePrice, service.Duration, service.ServiceType,
+		service.Name, service.Description, service.BasePrice, service.Duration, service.CategoryID,
 		time.Now(), service.ID,
 	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
 
-	return err
+// SetArchived archives a service or brings it back
+func (r *ServiceRepository) SetArchived(id int, archived bool) error {
+	result, err := database.DB.Exec(
+		`UPDATE services SET archived_at = CASE WHEN $2 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END,
+		 updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		id, archived,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
-func (r *ServiceRepository) DeleteService(id int) error {
-	_, err := database.DB.Exec("DELETE FROM services WHERE id=$1", id)
-	return err
+// GetServiceAddOns lists the add-ons a service allows, active or not
+func (r *ServiceRepository) GetServiceAddOns(serviceID int) ([]models.AddOn, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+addOnColumns+` FROM add_ons a
+		 JOIN service_add_ons sa ON sa.add_on_id = a.id
+		 WHERE sa.service_id = $1 ORDER BY a.name`,
+		serviceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAddOns(rows)
+}
+
+// SetServiceAddOns replaces the add-ons a service allows
+func (r *ServiceRepository) SetServiceAddOns(serviceID int, addOnIDs []int) (err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec("DELETE FROM service_add_ons WHERE service_id = $1", serviceID); err != nil {
+		return err
+	}
+	for _, addOnID := range addOnIDs {
+		_, err = tx.Exec(
+			"INSERT INTO service_add_ons (service_id, add_on_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			serviceID, addOnID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const serviceCategoryColumns = `id, slug, name, description, sort_order, created_at, updated_at`
+
+func scanServiceCategory(scanner interface{ Scan(...interface{}) error }, category *models.ServiceCategory) error {
+	return scanner.Scan(&category.ID, &category.Slug, &category.Name, &category.Description, &category.SortOrder,
+		&category.CreatedAt, &category.UpdatedAt)
+}
+
+func (r *ServiceRepository) GetCategories() ([]models.ServiceCategory, error) {
+	rows, err := database.DB.Query(`SELECT ` + serviceCategoryColumns + ` FROM service_categories ORDER BY sort_order, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.ServiceCategory{}
+	for rows.Next() {
+		var category models.ServiceCategory
+		if err := scanServiceCategory(rows, &category); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+func (r *ServiceRepository) GetCategory(id int) (*models.ServiceCategory, error) {
+	var category models.ServiceCategory
+	if err := scanServiceCategory(database.DB.QueryRow(`SELECT `+serviceCategoryColumns+` FROM service_categories WHERE id = $1`, id), &category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *ServiceRepository) GetCategoryBySlug(slug string) (*models.ServiceCategory, error) {
+	var category models.ServiceCategory
+	if err := scanServiceCategory(database.DB.QueryRow(`SELECT `+serviceCategoryColumns+` FROM service_categories WHERE slug = $1`, slug), &category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *ServiceRepository) CreateCategory(category *models.ServiceCategory) error {
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	return database.DB.QueryRow(
+		`INSERT INTO service_categories (slug, name, description, sort_order, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		category.Slug, category.Name, category.Description, category.SortOrder, now, now,
+	).Scan(&category.ID)
+}
+
+func (r *ServiceRepository) UpdateCategory(category *models.ServiceCategory) error {
+	category.UpdatedAt = time.Now()
+	result, err := database.DB.Exec(
+		`UPDATE service_categories SET slug = $1, name = $2, description = $3, sort_order = $4, updated_at = $5 WHERE id = $6`,
+		category.Slug, category.Name, category.Description, category.SortOrder, category.UpdatedAt, category.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
 type BookingRepository struct{}
 
 func (r *BookingRepository) CreateBooking(booking *models.Booking) error {
-	query := `INSERT INTO bookings (user_id, service_id, scheduled_date, scheduled_time, address, square_meters, special_instructions, total_price, status, created_at, updated_at) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+	query := `INSERT INTO bookings (user_id, service_id, scheduled_date, scheduled_time, address, square_meters, special_instructions, total_price, duration_hours, status, customer_id, property_id, price_breakdown, created_at, updated_at) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
 
 	err := database.DB.QueryRow(
 		query,
 		booking.UserID, booking.ServiceID, booking.ScheduledDate, booking.ScheduledTime,
 		booking.Address, booking.SquareMeters, booking.SpecialInstructions,
-		booking.TotalPrice, booking.Status, time.Now(), time.Now(),
+		booking.TotalPrice, booking.DurationHours, booking.Status, booking.CustomerID, booking.PropertyID, booking.PriceBreakdown, time.Now(), time.Now(),
 	).Scan(&booking.ID)
 
 	return err
 }
 
 func (r *BookingRepository) CreateGuestBooking(booking *models.Booking) error {
-	query := `INSERT INTO bookings (user_id, service_id, scheduled_date, scheduled_time, address, square_meters, special_instructions, total_price, status, guest_name, guest_email, guest_phone, is_guest_booking, created_at, updated_at) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
+	query := `INSERT INTO bookings (user_id, service_id, scheduled_date, scheduled_time, address, square_meters, special_instructions, total_price, duration_hours, status, guest_name, guest_email, guest_phone, is_guest_booking, customer_id, property_id, price_breakdown, created_at, updated_at) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19) RETURNING id`
 
 	err := database.DB.QueryRow(
 		query,
 		booking.UserID, booking.ServiceID, booking.ScheduledDate, booking.ScheduledTime,
 		booking.Address, booking.SquareMeters, booking.SpecialInstructions,
-		booking.TotalPrice, booking.Status, booking.GuestName, booking.GuestEmail, 
-		booking.GuestPhone, booking.IsGuestBooking, time.Now(), time.Now(),
+		booking.TotalPrice, booking.DurationHours, booking.Status, booking.GuestName, booking.GuestEmail, 
+		booking.GuestPhone, booking.IsGuestBooking, booking.CustomerID, booking.PropertyID, booking.PriceBreakdown, time.Now(), time.Now(),
 	).Scan(&booking.ID)
 
 	return err
@@ -143,7 +351,7 @@ func (r *BookingRepository) GetGuestBooking(bookingID int, email string) (*model
 		         b.guest_name, b.guest_email, b.guest_phone, b.is_guest_booking, b.created_at 
 		 FROM bookings b 
 		 JOIN services s ON b.service_id = s.id 
-		 WHERE b.id = $1 AND b.guest_email = $2 AND b.is_guest_booking = true`,
+		 WHERE b.id = $1 AND b.guest_email = $2 AND b.is_guest_booking = true AND b.user_id IS NULL`,
 		bookingID, email,
 	).Scan(
 		&booking.ID, &booking.UserID, &booking.ServiceID, &booking.ServiceName,
@@ -160,17 +368,17 @@ func (r *BookingRepository) GetBookingByID(id int) (*models.Booking, error) {
 	var booking models.Booking
 	err := database.DB.QueryRow(
 		`SELECT id, user_id, service_id, scheduled_date, scheduled_time, address, square_meters, 
-		 COALESCE(special_instructions, '') as special_instructions, total_price, status,
+		 COALESCE(special_instructions, '') as special_instructions, total_price, duration_hours, status,
 		 COALESCE(guest_name, '') as guest_name, 
 		 COALESCE(guest_email, '') as guest_email, 
 		 COALESCE(guest_phone, '') as guest_phone,
 		 COALESCE(is_guest_booking, false) as is_guest_booking,
-		 created_at FROM bookings WHERE id=$1`,
+		 customer_id, property_id, price_breakdown, created_at FROM bookings WHERE id=$1`,
 		id,
 	).Scan(&booking.ID, &booking.UserID, &booking.ServiceID, &booking.ScheduledDate, &booking.ScheduledTime, 
 		&booking.Address, &booking.SquareMeters, &booking.SpecialInstructions, &booking.TotalPrice, 
-		&booking.Status, &booking.GuestName, &booking.GuestEmail, &booking.GuestPhone, 
-		&booking.IsGuestBooking, &booking.CreatedAt)
+		&booking.DurationHours, &booking.Status, &booking.GuestName, &booking.GuestEmail, &booking.GuestPhone, 
+		&booking.IsGuestBooking, &booking.CustomerID, &booking.PropertyID, &booking.PriceBreakdown, &booking.CreatedAt)
 
 	return &booking, err
 }
@@ -178,7 +386,7 @@ func (r *BookingRepository) GetBookingByID(id int) (*models.Booking, error) {
 func (r *BookingRepository) GetBookingsByUserID(userID int) ([]models.BookingResponse, error) {
 	rows, err := database.DB.Query(
 		`SELECT b.id, b.user_id, b.service_id, s.name, b.scheduled_date, b.scheduled_time, 
-		         b.address, b.square_meters, b.special_instructions, b.total_price, b.status, b.created_at 
+		         b.address, b.square_meters, b.special_instructions, b.total_price, b.duration_hours, b.status, b.property_id, b.created_at 
 		 FROM bookings b 
 		 JOIN services s ON b.service_id = s.id 
 		 WHERE b.user_id = $1 
@@ -197,7 +405,7 @@ func (r *BookingRepository) GetBookingsByUserID(userID int) ([]models.BookingRes
 			&booking.ID, &booking.UserID, &booking.ServiceID, &booking.ServiceName,
 			&booking.ScheduledDate, &booking.ScheduledTime, &booking.Address,
 			&booking.SquareMeters, &booking.SpecialInstructions, &booking.TotalPrice,
-			&booking.Status, &booking.CreatedAt,
+			&booking.DurationHours, &booking.Status, &booking.PropertyID, &booking.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -208,28 +416,56 @@ func (r *BookingRepository) GetBookingsByUserID(userID int) ([]models.BookingRes
 	return bookings, nil
 }
 
+// UpdateBooking saves a booking's details. Its status only changes through
+// ChangeBookingStatus and its date and time through RescheduleBooking, which
+// record the change.
 func (r *BookingRepository) UpdateBooking(booking *models.Booking) error {
-	query := `UPDATE bookings SET scheduled_date=$1, scheduled_time=$2, address=$3, square_meters=$4, special_instructions=$5, total_price=$6, status=$7, updated_at=$8 
-	          WHERE id=$9`
+	query := `UPDATE bookings SET address=$1, square_meters=$2, special_instructions=$3, total_price=$4, duration_hours=$5, updated_at=$6 
+	          WHERE id=$7`
 
 	_, err := database.DB.Exec(
 		query,
-		booking.ScheduledDate, booking.ScheduledTime, booking.Address, booking.SquareMeters,
-		booking.SpecialInstructions, booking.TotalPrice, booking.Status, time.Now(), booking.ID,
+		booking.Address, booking.SquareMeters,
+		booking.SpecialInstructions, booking.TotalPrice, booking.DurationHours, time.Now(), booking.ID,
 	)
 
 	return err
 }
 
+// Advisory lock namespace for per-day schedule locks
+const scheduleLockNamespace = 1
+
+// LockScheduleDate serializes booking writes for one day, across every API
+// instance, with a Postgres advisory lock. Call the returned function to
+// release it.
+func (r *BookingRepository) LockScheduleDate(date time.Time) (func(), error) {
+	ctx := context.Background()
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := date.Year()*10000 + int(date.Month())*100 + date.Day()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1, $2)", scheduleLockNamespace, key); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1, $2)", scheduleLockNamespace, key)
+		conn.Close()
+	}, nil
+}
+
 func (r *BookingRepository) GetAllBookings() ([]models.BookingResponse, error) {
 	rows, err := database.DB.Query(
 		`SELECT b.id, b.user_id, u.email, b.service_id, s.name, b.scheduled_date, b.scheduled_time, 
 		         b.address, b.square_meters, COALESCE(b.special_instructions, '') as special_instructions, 
-		         b.total_price, b.status, b.invoice_id,
+		         b.total_price, b.duration_hours, b.status, b.invoice_id,
 		         COALESCE(b.guest_name, '') as guest_name, 
 		         COALESCE(b.guest_email, '') as guest_email, 
 		         COALESCE(b.guest_phone, '') as guest_phone, 
-		         b.is_guest_booking, b.created_at 
+		         b.is_guest_booking, b.property_id, b.created_at 
 		 FROM bookings b 
 		 JOIN services s ON b.service_id = s.id 
 		 LEFT JOIN users u ON b.user_id = u.id
@@ -248,8 +484,8 @@ func (r *BookingRepository) GetAllBookings() ([]models.BookingResponse, error) {
 			&booking.ID, &booking.UserID, &userEmail, &booking.ServiceID, &booking.ServiceName,
 			&booking.ScheduledDate, &booking.ScheduledTime, &booking.Address,
 			&booking.SquareMeters, &booking.SpecialInstructions, &booking.TotalPrice,
-			&booking.Status, &booking.InvoiceID, &booking.GuestName, &booking.GuestEmail, &booking.GuestPhone,
-			&booking.IsGuestBooking, &booking.CreatedAt,
+			&booking.DurationHours, &booking.Status, &booking.InvoiceID, &booking.GuestName, &booking.GuestEmail, &booking.GuestPhone,
+			&booking.IsGuestBooking, &booking.PropertyID, &booking.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
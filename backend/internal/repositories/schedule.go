@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type ScheduleRepository struct{}
+
+func (r *ScheduleRepository) GetBusinessHours() ([]models.BusinessHours, error) {
+	rows, err := database.DB.Query(
+		`SELECT weekday, is_open, TO_CHAR(opens_at, 'HH24:MI'), TO_CHAR(closes_at, 'HH24:MI')
+		 FROM business_hours ORDER BY weekday`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hours := []models.BusinessHours{}
+	for rows.Next() {
+		var day models.BusinessHours
+		if err := rows.Scan(&day.Weekday, &day.IsOpen, &day.OpensAt, &day.ClosesAt); err != nil {
+			return nil, err
+		}
+		hours = append(hours, day)
+	}
+
+	return hours, nil
+}
+
+func (r *ScheduleRepository) GetBusinessHoursForWeekday(weekday int) (*models.BusinessHours, error) {
+	var day models.BusinessHours
+	err := database.DB.QueryRow(
+		`SELECT weekday, is_open, TO_CHAR(opens_at, 'HH24:MI'), TO_CHAR(closes_at, 'HH24:MI')
+		 FROM business_hours WHERE weekday = $1`,
+		weekday,
+	).Scan(&day.Weekday, &day.IsOpen, &day.OpensAt, &day.ClosesAt)
+
+	return &day, err
+}
+
+// SaveBusinessHours upserts the hours of the given weekdays
+func (r *ScheduleRepository) SaveBusinessHours(hours []models.BusinessHours) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, day := range hours {
+		_, err := tx.Exec(
+			`INSERT INTO business_hours (weekday, is_open, opens_at, closes_at, updated_at)
+			 VALUES ($1, $2, $3, $4, $5)
+			 ON CONFLICT (weekday) DO UPDATE
+			 SET is_open = EXCLUDED.is_open, opens_at = EXCLUDED.opens_at,
+			     closes_at = EXCLUDED.closes_at, updated_at = EXCLUDED.updated_at`,
+			day.Weekday, day.IsOpen, day.OpensAt, day.ClosesAt, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const scheduleExceptionColumns = `id, start_date, end_date, is_closed,
+		 COALESCE(TO_CHAR(opens_at, 'HH24:MI'), ''), COALESCE(TO_CHAR(closes_at, 'HH24:MI'), ''),
+		 COALESCE(reason, ''), created_at`
+
+func scanScheduleException(scanner interface{ Scan(...interface{}) error }, exception *models.ScheduleException) error {
+	return scanner.Scan(
+		&exception.ID, &exception.StartDate, &exception.EndDate, &exception.IsClosed,
+		&exception.OpensAt, &exception.ClosesAt, &exception.Reason, &exception.CreatedAt,
+	)
+}
+
+// GetScheduleExceptions returns the exceptions overlapping a date range
+func (r *ScheduleRepository) GetScheduleExceptions(from, to time.Time) ([]models.ScheduleException, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+scheduleExceptionColumns+`
+		 FROM schedule_exceptions
+		 WHERE end_date >= $1 AND start_date <= $2
+		 ORDER BY start_date, end_date`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exceptions := []models.ScheduleException{}
+	for rows.Next() {
+		var exception models.ScheduleException
+		if err := scanScheduleException(rows, &exception); err != nil {
+			return nil, err
+		}
+		exceptions = append(exceptions, exception)
+	}
+
+	return exceptions, nil
+}
+
+// GetScheduleExceptionForDate returns the exception in effect on a date. The
+// shortest matching range wins, so a one-off day can override a longer period.
+func (r *ScheduleRepository) GetScheduleExceptionForDate(date time.Time) (*models.ScheduleException, error) {
+	var exception models.ScheduleException
+	row := database.DB.QueryRow(
+		`SELECT `+scheduleExceptionColumns+`
+		 FROM schedule_exceptions
+		 WHERE $1 BETWEEN start_date AND end_date
+		 ORDER BY end_date - start_date, created_at DESC
+		 LIMIT 1`,
+		date,
+	)
+	err := scanScheduleException(row, &exception)
+
+	return &exception, err
+}
+
+func (r *ScheduleRepository) GetScheduleExceptionByID(id int) (*models.ScheduleException, error) {
+	var exception models.ScheduleException
+	row := database.DB.QueryRow(`SELECT `+scheduleExceptionColumns+` FROM schedule_exceptions WHERE id = $1`, id)
+	err := scanScheduleException(row, &exception)
+
+	return &exception, err
+}
+
+func (r *ScheduleRepository) CreateScheduleException(exception *models.ScheduleException) error {
+	query := `INSERT INTO schedule_exceptions (start_date, end_date, is_closed, opens_at, closes_at, reason, created_at, updated_at)
+	          VALUES ($1, $2, $3, NULLIF($4, '')::time, NULLIF($5, '')::time, $6, $7, $8) RETURNING id`
+
+	exception.CreatedAt = time.Now()
+	return database.DB.QueryRow(
+		query,
+		exception.StartDate, exception.EndDate, exception.IsClosed, exception.OpensAt, exception.ClosesAt,
+		exception.Reason, exception.CreatedAt, exception.CreatedAt,
+	).Scan(&exception.ID)
+}
+
+func (r *ScheduleRepository) UpdateScheduleException(exception *models.ScheduleException) error {
+	_, err := database.DB.Exec(
+		`UPDATE schedule_exceptions
+		 SET start_date = $1, end_date = $2, is_closed = $3, opens_at = NULLIF($4, '')::time,
+		     closes_at = NULLIF($5, '')::time, reason = $6, updated_at = $7
+		 WHERE id = $8`,
+		exception.StartDate, exception.EndDate, exception.IsClosed, exception.OpensAt, exception.ClosesAt,
+		exception.Reason, time.Now(), exception.ID,
+	)
+	return err
+}
+
+func (r *ScheduleRepository) DeleteScheduleException(id int) error {
+	_, err := database.DB.Exec("DELETE FROM schedule_exceptions WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type SeriesRepository struct{}
+
+const seriesColumns = `bs.id, bs.user_id, bs.service_id, s.name, bs.frequency, bs.start_date, bs.end_date,
+		 TO_CHAR(bs.scheduled_time, 'HH24:MI'), bs.address, bs.square_meters,
+		 COALESCE(bs.special_instructions, ''), bs.discount_percent, bs.status, bs.generated_until,
+		 bs.created_at, bs.updated_at`
+
+func scanSeries(scanner interface{ Scan(...interface{}) error }, series *models.BookingSeries) error {
+	return scanner.Scan(
+		&series.ID, &series.UserID, &series.ServiceID, &series.ServiceName, &series.Frequency,
+		&series.StartDate, &series.EndDate, &series.ScheduledTime, &series.Address, &series.SquareMeters,
+		&series.SpecialInstructions, &series.DiscountPercent, &series.Status, &series.GeneratedUntil,
+		&series.CreatedAt, &series.UpdatedAt,
+	)
+}
+
+func (r *SeriesRepository) CreateSeries(series *models.BookingSeries) error {
+	query := `INSERT INTO booking_series (user_id, service_id, frequency, start_date, end_date, scheduled_time,
+	          address, square_meters, special_instructions, discount_percent, status, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`
+
+	now := time.Now()
+	series.CreatedAt = now
+	series.UpdatedAt = now
+	return database.DB.QueryRow(
+		query,
+		series.UserID, series.ServiceID, series.Frequency, series.StartDate, series.EndDate, series.ScheduledTime,
+		series.Address, series.SquareMeters, series.SpecialInstructions, series.DiscountPercent, series.Status,
+		now, now,
+	).Scan(&series.ID)
+}
+
+func (r *SeriesRepository) GetSeriesByID(id int) (*models.BookingSeries, error) {
+	var series models.BookingSeries
+	row := database.DB.QueryRow(
+		`SELECT `+seriesColumns+`
+		 FROM booking_series bs
+		 JOIN services s ON bs.service_id = s.id
+		 WHERE bs.id = $1`,
+		id,
+	)
+	err := scanSeries(row, &series)
+
+	return &series, err
+}
+
+// GetSeries lists series, all of them when userID is 0
+func (r *SeriesRepository) GetSeries(userID int) ([]models.BookingSeries, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+seriesColumns+`
+		 FROM booking_series bs
+		 JOIN services s ON bs.service_id = s.id
+		 WHERE $1 = 0 OR bs.user_id = $1
+		 ORDER BY bs.status, bs.start_date`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSeriesRows(rows)
+}
+
+// GetSeriesToGenerate returns the active series whose occurrences have not
+// been generated up to the given date yet
+func (r *SeriesRepository) GetSeriesToGenerate(until time.Time) ([]models.BookingSeries, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+seriesColumns+`
+		 FROM booking_series bs
+		 JOIN services s ON bs.service_id = s.id
+		 WHERE bs.status = 'active'
+		   AND (bs.generated_until IS NULL OR bs.generated_until < $1)
+		   AND (bs.end_date IS NULL OR bs.generated_until IS NULL OR bs.generated_until < bs.end_date)
+		 ORDER BY bs.id`,
+		until,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSeriesRows(rows)
+}
+
+func scanSeriesRows(rows *sql.Rows) ([]models.BookingSeries, error) {
+	seriesList := []models.BookingSeries{}
+	for rows.Next() {
+		var series models.BookingSeries
+		if err := scanSeries(rows, &series); err != nil {
+			return nil, err
+		}
+		seriesList = append(seriesList, series)
+	}
+	return seriesList, nil
+}
+
+// EndSeries stops a series after endDate, optionally changing its status
+func (r *SeriesRepository) EndSeries(id int, endDate time.Time, status string) error {
+	_, err := database.DB.Exec(
+		"UPDATE booking_series SET end_date = $1, status = $2, updated_at = $3 WHERE id = $4",
+		endDate, status, time.Now(), id,
+	)
+	return err
+}
+
+func (r *SeriesRepository) SetGeneratedUntil(id int, until time.Time) error {
+	_, err := database.DB.Exec(
+		"UPDATE booking_series SET generated_until = $1, updated_at = $2 WHERE id = $3",
+		until, time.Now(), id,
+	)
+	return err
+}
+
+// CreateOccurrence stores an occurrence of a series as a booking. If the
+// series already has an occurrence for that date nothing is written and
+// sql.ErrNoRows is returned.
+func (r *SeriesRepository) CreateOccurrence(booking *models.Booking) error {
+	query := `INSERT INTO bookings (user_id, service_id, scheduled_date, scheduled_time, address, square_meters,
+	          special_instructions, total_price, duration_hours, status, series_id, occurrence_date, customer_id, property_id, price_breakdown, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	          ON CONFLICT (series_id, occurrence_date) DO NOTHING RETURNING id`
+
+	now := time.Now()
+	booking.CreatedAt = now
+	booking.UpdatedAt = now
+	return database.DB.QueryRow(
+		query,
+		booking.UserID, booking.ServiceID, booking.ScheduledDate, booking.ScheduledTime, booking.Address,
+		booking.SquareMeters, booking.SpecialInstructions, booking.TotalPrice, booking.DurationHours,
+		booking.Status, booking.SeriesID, booking.OccurrenceDate, booking.CustomerID, booking.PropertyID, booking.PriceBreakdown, now, now,
+	).Scan(&booking.ID)
+}
+
+// GetOccurrences returns the occurrences of a series scheduled on or after from
+func (r *SeriesRepository) GetOccurrences(seriesID int, from time.Time) ([]models.BookingResponse, error) {
+	rows, err := database.DB.Query(
+		`SELECT b.id, b.user_id, b.service_id, s.name, b.scheduled_date, b.scheduled_time,
+		         b.address, b.square_meters, COALESCE(b.special_instructions, ''), b.total_price,
+		         b.duration_hours, b.status, b.invoice_id, b.series_id, b.created_at
+		 FROM bookings b
+		 JOIN services s ON b.service_id = s.id
+		 WHERE b.series_id = $1 AND b.scheduled_date >= $2
+		 ORDER BY b.scheduled_date, b.scheduled_time`,
+		seriesID, from,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	occurrences := []models.BookingResponse{}
+	for rows.Next() {
+		var booking models.BookingResponse
+		err := rows.Scan(
+			&booking.ID, &booking.UserID, &booking.ServiceID, &booking.ServiceName,
+			&booking.ScheduledDate, &booking.ScheduledTime, &booking.Address,
+			&booking.SquareMeters, &booking.SpecialInstructions, &booking.TotalPrice,
+			&booking.DurationHours, &booking.Status, &booking.InvoiceID, &booking.SeriesID, &booking.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, booking)
+	}
+
+	return occurrences, nil
+}
+
+// GetOccurrence returns a single occurrence of a series
+func (r *SeriesRepository) GetOccurrence(seriesID, bookingID int) (*models.Booking, error) {
+	var booking models.Booking
+	err := database.DB.QueryRow(
+		`SELECT id, user_id, service_id, scheduled_date, scheduled_time, duration_hours, status,
+		        series_id, occurrence_date
+		 FROM bookings WHERE id = $1 AND series_id = $2`,
+		bookingID, seriesID,
+	).Scan(&booking.ID, &booking.UserID, &booking.ServiceID, &booking.ScheduledDate, &booking.ScheduledTime,
+		&booking.DurationHours, &booking.Status, &booking.SeriesID, &booking.OccurrenceDate)
+
+	return &booking, err
+}
+
+// GetOpenOccurrenceIDs returns the occurrences planned on or after a date
+// that have not started yet
+func (r *SeriesRepository) GetOpenOccurrenceIDs(seriesID int, from time.Time) ([]int, error) {
+	rows, err := database.DB.Query(
+		`SELECT id FROM bookings
+		 WHERE series_id = $1 AND occurrence_date >= $2 AND status IN ('pending', 'confirmed')
+		 ORDER BY occurrence_date`,
+		seriesID, from,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+)
+
+type UserTokenRepository struct{}
+
+func (r *UserTokenRepository) CreateUserToken(token *models.UserToken) error {
+	query := `INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	token.CreatedAt = time.Now()
+	return database.DB.QueryRow(
+		query,
+		token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt, token.CreatedAt,
+	).Scan(&token.ID)
+}
+
+func (r *UserTokenRepository) GetUserTokenByHash(tokenHash, purpose string) (*models.UserToken, error) {
+	var token models.UserToken
+	err := database.DB.QueryRow(
+		`SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at
+		 FROM user_tokens WHERE token_hash = $1 AND purpose = $2`,
+		tokenHash, purpose,
+	).Scan(&token.ID, &token.UserID, &token.Purpose, &token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+
+	return &token, err
+}
+
+// ConsumeUserToken marks a token as used. It fails if the token was already
+// used, so a token can only ever be redeemed once.
+func (r *UserTokenRepository) ConsumeUserToken(id int) error {
+	result, err := database.DB.Exec(
+		"UPDATE user_tokens SET used_at = $1 WHERE id = $2 AND used_at IS NULL",
+		time.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("token already used")
+	}
+
+	return nil
+}
+
+// InvalidateUserTokens marks all outstanding tokens of a purpose as used, so
+// only the most recently mailed link stays valid.
+func (r *UserTokenRepository) InvalidateUserTokens(userID int, purpose string) error {
+	_, err := database.DB.Exec(
+		"UPDATE user_tokens SET used_at = $1 WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL",
+		time.Now(), userID, purpose,
+	)
+	return err
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/mail"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+	"cleaning-app-backend/internal/utils"
+)
+
+// AccountService handles account recovery and email verification. Both flows
+// mail a single-use token to the user; only the token hash is stored.
+type AccountService struct {
+	userRepo  *repositories.UserRepository
+	tokenRepo *repositories.UserTokenRepository
+	mailer    mail.Sender
+	config    config.Config
+}
+
+func NewAccountService(cfg config.Config) *AccountService {
+	return &AccountService{
+		userRepo:  &repositories.UserRepository{},
+		tokenRepo: &repositories.UserTokenRepository{},
+		mailer:    mail.NewSender(cfg),
+		config:    cfg,
+	}
+}
+
+// RequestPasswordReset mails a reset link if an account exists for the email.
+// It does not report whether the account exists.
+func (s *AccountService) RequestPasswordReset(email string) error {
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	token, err := s.issueToken(user.ID, models.TokenPurposePasswordReset, s.config.PasswordResetExpiry)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.config.AppURL, url.QueryEscape(token))
+	return s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Premier Prime Cleaning password",
+		Body: fmt.Sprintf(`Hi %s,
+
+We received a request to reset your password. Use the link below to choose a new one:
+
+%s
+
+This link expires in %s and can only be used once. If you didn't request a reset, you can ignore this email.
+
+Premier Prime Cleaning Services`, user.FirstName, link, s.config.PasswordResetExpiry),
+	})
+}
+
+// VerifyPasswordResetToken checks a reset token without consuming it, so the
+// frontend can validate a link before showing the new password form.
+func (s *AccountService) VerifyPasswordResetToken(token string) error {
+	_, err := s.lookupToken(token, models.TokenPurposePasswordReset)
+	return err
+}
+
+// ResetPassword consumes a reset token and sets the new password. All refresh
+// tokens of the user are revoked so existing sessions have to log in again.
+func (s *AccountService) ResetPassword(token, newPassword string) error {
+	userToken, err := s.lookupToken(token, models.TokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+
+	if err := s.tokenRepo.ConsumeUserToken(userToken.ID); err != nil {
+		if err.Error() == "token already used" {
+			return errors.New("invalid or expired token")
+		}
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(userToken.UserID, hashedPassword); err != nil {
+		return errors.New("failed to update password")
+	}
+
+	if err := NewTokenService(s.config).RevokeAllForUser(userToken.UserID); err != nil {
+		log.Printf("Failed to revoke refresh tokens for user %d: %v", userToken.UserID, err)
+	}
+
+	return nil
+}
+
+// SendEmailVerification mails a verification link to the user.
+func (s *AccountService) SendEmailVerification(userID int) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.EmailVerified {
+		return errors.New("email already verified")
+	}
+
+	token, err := s.issueToken(user.ID, models.TokenPurposeEmailVerification, s.config.EmailVerificationExpiry)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", s.config.AppURL, url.QueryEscape(token))
+	return s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+Thanks for creating a Premier Prime Cleaning account. Please confirm your email address:
+
+%s
+
+This link expires in %s.
+
+Premier Prime Cleaning Services`, user.FirstName, link, s.config.EmailVerificationExpiry),
+	})
+}
+
+// VerifyEmail consumes a verification token and marks the email as verified.
+// It returns the verified user's ID.
+func (s *AccountService) VerifyEmail(token string) (int, error) {
+	userToken, err := s.lookupToken(token, models.TokenPurposeEmailVerification)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.tokenRepo.ConsumeUserToken(userToken.ID); err != nil {
+		if err.Error() == "token already used" {
+			return 0, errors.New("invalid or expired token")
+		}
+		return 0, err
+	}
+
+	if err := s.userRepo.MarkEmailVerified(userToken.UserID); err != nil {
+		return 0, errors.New("failed to verify email")
+	}
+
+	return userToken.UserID, nil
+}
+
+func (s *AccountService) issueToken(userID int, purpose, expiry string) (string, error) {
+	ttl, err := time.ParseDuration(expiry)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s expiry: %v", purpose, err)
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", errors.New("failed to generate token")
+	}
+
+	if err := s.tokenRepo.InvalidateUserTokens(userID, purpose); err != nil {
+		return "", err
+	}
+
+	err = s.tokenRepo.CreateUserToken(&models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", errors.New("failed to store token")
+	}
+
+	return token, nil
+}
+
+func (s *AccountService) lookupToken(token, purpose string) (*models.UserToken, error) {
+	userToken, err := s.tokenRepo.GetUserTokenByHash(utils.HashToken(token), purpose)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invalid or expired token")
+		}
+		return nil, err
+	}
+
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	return userToken, nil
+}
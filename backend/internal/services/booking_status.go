@@ -0,0 +1,90 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// bookingTransitions lists the statuses a booking may move to from each
+// status. All status changes go through BookingStatusService, so these are
+// the only rules.
+var bookingTransitions = map[string][]string{
+	"pending":     {"confirmed", "cancelled", "skipped"},
+	"confirmed":   {"in_progress", "cancelled", "skipped", "no_show"},
+	"in_progress": {"completed"},
+	"completed":   {},
+	"cancelled":   {},
+	"skipped":     {},
+	"no_show":     {},
+}
+
+// Statuses of bookings that won't take place, as they read on invoices
+var bookingCallOffs = map[string]string{
+	"cancelled": "cancelled",
+	"skipped":   "skipped",
+	"no_show":   "was a no-show",
+}
+
+// CanTransition reports whether a booking may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, allowed := range bookingTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// BookingStatusService changes booking statuses according to
+// bookingTransitions and keeps the status history
+type BookingStatusService struct {
+	repo *repositories.BookingStatusRepository
+}
+
+func NewBookingStatusService() *BookingStatusService {
+	return &BookingStatusService{
+		repo: &repositories.BookingStatusRepository{},
+	}
+}
+
+// ChangeStatus moves a booking to a new status and records who did it and why
+func (s *BookingStatusService) ChangeStatus(bookingID int, to string, actor models.StatusActor, reason string) error {
+	if _, ok := bookingTransitions[to]; !ok {
+		return errors.New("invalid status")
+	}
+
+	_, err := s.repo.ChangeBookingStatus(bookingID, to, actor, reason, func(from string) error {
+		if !CanTransition(from, to) {
+			return errors.New("invalid status transition")
+		}
+		return nil
+	})
+	if err == sql.ErrNoRows {
+		return errors.New("booking not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	// A booking that won't take place isn't owed; any fee is billed apart.
+	// The status has changed either way; a failed invoice update is fixed
+	// by hand.
+	if label, ok := bookingCallOffs[to]; ok {
+		invoiceService := NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{})
+		if err := invoiceService.SettleCancelledBooking(bookingID, fmt.Sprintf("Booking #%d %s", bookingID, label)); err != nil {
+			log.Printf("Failed to settle the invoice of booking %d: %v", bookingID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *BookingStatusService) GetHistory(bookingID int) ([]models.BookingStatusChange, error) {
+	return s.repo.GetStatusHistory(bookingID)
+}
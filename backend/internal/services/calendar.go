@@ -3,19 +3,45 @@ package services
 import (
 	"time"
 
+	"cleaning-app-backend/internal/models"
 	"cleaning-app-backend/internal/repositories"
 )
 
 type CalendarService struct {
-	repo *repositories.CalendarRepository
+	repo           *repositories.CalendarRepository
+	assignmentRepo *repositories.AssignmentRepository
 }
 
 func NewCalendarService() *CalendarService {
 	return &CalendarService{
-		repo: &repositories.CalendarRepository{},
+		repo:           &repositories.CalendarRepository{},
+		assignmentRepo: &repositories.AssignmentRepository{},
 	}
 }
 
+// CalendarFilter narrows calendar events to one cleaner or crew. Zero values
+// mean no filtering.
+type CalendarFilter struct {
+	AssigneeID int
+	CrewID     int
+}
+
+func (f CalendarFilter) matches(assignees []models.Assignee) bool {
+	if f.AssigneeID == 0 && f.CrewID == 0 {
+		return true
+	}
+	for _, assignee := range assignees {
+		if f.AssigneeID != 0 && assignee.UserID != f.AssigneeID {
+			continue
+		}
+		if f.CrewID != 0 && (assignee.CrewID == nil || *assignee.CrewID != f.CrewID) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
 type CalendarEvent struct {
 	ID             int       `json:"id"`
 	Title          string    `json:"title"`
@@ -31,6 +57,7 @@ type CalendarEvent struct {
 	SquareMeters   float64   `json:"square_meters"`
 	TotalPrice     float64   `json:"total_price"`
 	IsGuestBooking bool      `json:"is_guest_booking"`
+	Assignees      []models.Assignee `json:"assignees"`
 }
 
 type DaySchedule struct {
@@ -47,6 +74,7 @@ type AvailableSlot struct {
 	Time        string `json:"time"`
 	Available   bool   `json:"available"`
 	Duration    int    `json:"duration"` // in minutes
+	FreeCrews   int    `json:"free_crews"`
 	ServiceType string `json:"service_type,omitempty"`
 }
 
@@ -68,14 +96,27 @@ type BookingStats struct {
 	} `json:"data"`
 }
 
-func (s *CalendarService) GetCalendarEvents(startDate, endDate time.Time) ([]CalendarEvent, error) {
+func (s *CalendarService) GetCalendarEvents(startDate, endDate time.Time, filter CalendarFilter) ([]CalendarEvent, error) {
 	bookings, err := s.repo.GetBookingsByDateRange(startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]CalendarEvent, len(bookings))
-	for i, booking := range bookings {
+	assignees, err := s.assignmentRepo.GetAssigneesByDateRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]CalendarEvent, 0, len(bookings))
+	for _, booking := range bookings {
+		bookingAssignees := assignees[booking.ID]
+		if bookingAssignees == nil {
+			bookingAssignees = []models.Assignee{}
+		}
+		if !filter.matches(bookingAssignees) {
+			continue
+		}
+
 		// Parse the scheduled time - handle PostgreSQL time format
 		var startTime time.Time
 		var err error
@@ -107,20 +148,7 @@ func (s *CalendarService) GetCalendarEvents(startDate, endDate time.Time) ([]Cal
 			time.UTC, // Use UTC for consistent API responses
 		)
 
-		// Estimate end time based on service duration
-		var duration time.Duration
-		switch booking.ServiceName {
-		case "Basic House Cleaning":
-			duration = 2 * time.Hour
-		case "Deep House Cleaning":
-			duration = 4 * time.Hour
-		case "Office Cleaning":
-			duration = 3 * time.Hour
-		default:
-			duration = 2 * time.Hour // Default duration
-		}
-
-		end := start.Add(duration)
+		end := start.Add(time.Duration(booking.DurationHours * float64(time.Hour)))
 
 		// Determine color based on status
 		color := s.getStatusColor(booking.Status)
@@ -131,7 +159,7 @@ func (s *CalendarService) GetCalendarEvents(startDate, endDate time.Time) ([]Cal
 			customerName = "Registered User" // We'd need to join with users table for actual name
 		}
 
-		events[i] = CalendarEvent{
+		events = append(events, CalendarEvent{
 			ID:             booking.ID,
 			Title:          booking.ServiceName + " - " + customerName,
 			Start:          start,
@@ -146,14 +174,15 @@ func (s *CalendarService) GetCalendarEvents(startDate, endDate time.Time) ([]Cal
 			SquareMeters:   booking.SquareMeters,
 			TotalPrice:     booking.TotalPrice,
 			IsGuestBooking: booking.IsGuestBooking,
-		}
+			Assignees:      bookingAssignees,
+		})
 	}
 
 	return events, nil
 }
 
-func (s *CalendarService) GetDaySchedule(date time.Time) (*DaySchedule, error) {
-	events, err := s.GetCalendarEvents(date, date)
+func (s *CalendarService) GetDaySchedule(date time.Time, filter CalendarFilter) (*DaySchedule, error) {
+	events, err := s.GetCalendarEvents(date, date, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -181,40 +210,22 @@ func (s *CalendarService) GetDaySchedule(date time.Time) (*DaySchedule, error) {
 	return schedule, nil
 }
 
-func (s *CalendarService) GetAvailableSlots(date time.Time, serviceID int) ([]AvailableSlot, error) {
-	// Get existing bookings for the date
-	events, err := s.GetCalendarEvents(date, date)
-	if err != nil {
-		return nil, err
-	}
-
-	// Define business hours (9 AM to 6 PM)
-	businessStart := 9
-	businessEnd := 18
-	slotDuration := 60 // minutes
+// GetAvailableSlots returns the start times on a date that can take the
+// service for a property of the given size. Without a service, slots are for
+// a one hour job.
+func (s *CalendarService) GetAvailableSlots(date time.Time, serviceID int, squareMeters float64) ([]AvailableSlot, error) {
+	scheduling := NewSchedulingService()
 
-	slots := make([]AvailableSlot, 0)
-
-	for hour := businessStart; hour < businessEnd; hour++ {
-		slotTime := time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, date.Location())
-		
-		// Check if this slot conflicts with existing bookings
-		available := true
-		for _, event := range events {
-			if slotTime.Before(event.End) && slotTime.Add(time.Hour).After(event.Start) {
-				available = false
-				break
-			}
+	durationHours := 1.0
+	if serviceID != 0 {
+		var err error
+		durationHours, err = scheduling.DurationForService(serviceID, squareMeters)
+		if err != nil {
+			return nil, err
 		}
-
-		slots = append(slots, AvailableSlot{
-			Time:      slotTime.Format("15:04"),
-			Available: available,
-			Duration:  slotDuration,
-		})
 	}
 
-	return slots, nil
+	return scheduling.GetAvailableSlots(date, durationHours)
 }
 
 func (s *CalendarService) GetBookingStats(period string) (*BookingStats, error) {
@@ -232,8 +243,11 @@ func (s *CalendarService) GetBookingStats(period string) (*BookingStats, error)
 	return stats, nil
 }
 
-func (s *CalendarService) RescheduleBooking(bookingID int, newDate, newTime, reason string) error {
-	return s.repo.RescheduleBooking(bookingID, newDate, newTime, reason)
+// RescheduleBooking moves a booking from the calendar. It goes through the
+// same checks as a customer reschedule except for the cutoff.
+func (s *CalendarService) RescheduleBooking(bookingID int, newDate, newTime, reason string, actor models.StatusActor) error {
+	req := &models.BookingRescheduleRequest{ScheduledDate: newDate, ScheduledTime: newTime, Reason: reason}
+	return NewBookingService().RescheduleBooking(bookingID, req, actor)
 }
 
 func (s *CalendarService) getStatusColor(status string) string {
@@ -248,6 +262,10 @@ func (s *CalendarService) getStatusColor(status string) string {
 		return "#8BC34A" // Light Green
 	case "cancelled":
 		return "#F44336" // Red
+	case "skipped":
+		return "#BDBDBD" // Light Gray
+	case "no_show":
+		return "#795548" // Brown
 	default:
 		return "#9E9E9E" // Gray
 	}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// CancellationService applies the cancellation policy. Cancelling a confirmed
+// booking inside the free window costs the late cancellation fee, a no-show
+// costs the no-show fee. Fees are billed on an invoice of their own; the
+// booking's invoice is settled by the status change whatever the fee.
+type CancellationService struct {
+	repo           *repositories.CancellationRepository
+	invoiceService *InvoiceService
+}
+
+func NewCancellationService() *CancellationService {
+	return &CancellationService{
+		repo:           &repositories.CancellationRepository{},
+		invoiceService: NewInvoiceService(repositories.NewInvoiceRepository(database.DB), &repositories.BookingRepository{}),
+	}
+}
+
+func (s *CancellationService) GetPolicy() (*models.CancellationPolicy, error) {
+	policy, err := s.repo.GetPolicy()
+	if err == sql.ErrNoRows {
+		return nil, errors.New("cancellation policy not configured")
+	}
+	return policy, err
+}
+
+func (s *CancellationService) UpdatePolicy(req *models.CancellationPolicyRequest) (*models.CancellationPolicy, error) {
+	policy := &models.CancellationPolicy{
+		FreeCancellationHours:      *req.FreeCancellationHours,
+		LateCancellationFeePercent: *req.LateCancellationFeePercent,
+		NoShowFeePercent:           *req.NoShowFeePercent,
+		RescheduleCutoffHours:      *req.RescheduleCutoffHours,
+	}
+	if err := s.repo.SavePolicy(policy); err != nil {
+		return nil, errors.New("failed to save cancellation policy")
+	}
+	return policy, nil
+}
+
+// LateCancellationPercent returns the share of the price owed for cancelling
+// the booking at the given time. Only confirmed bookings cost anything; a
+// pending booking was never promised a crew.
+func (s *CancellationService) LateCancellationPercent(booking *models.Booking, at time.Time) (float64, error) {
+	if booking.Status != "confirmed" {
+		return 0, nil
+	}
+
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return 0, err
+	}
+
+	start, err := bookingStart(booking)
+	if err != nil {
+		return 0, err
+	}
+
+	if start.Sub(at) >= time.Duration(policy.FreeCancellationHours)*time.Hour {
+		return 0, nil
+	}
+	return policy.LateCancellationFeePercent, nil
+}
+
+// ChargeLateCancellation bills the late cancellation fee for a booking that
+// was just cancelled. percent comes from LateCancellationPercent, evaluated
+// before the status changed.
+func (s *CancellationService) ChargeLateCancellation(booking *models.Booking, percent float64) (*models.CancellationFee, error) {
+	return s.charge(booking, percent, fmt.Sprintf("Late cancellation fee (%g%% of booking #%d)", percent, booking.ID))
+}
+
+// ChargeNoShow bills the no-show fee for a booking marked as no_show
+func (s *CancellationService) ChargeNoShow(booking *models.Booking) (*models.CancellationFee, error) {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	return s.charge(booking, policy.NoShowFeePercent, fmt.Sprintf("No-show fee (%g%% of booking #%d)", policy.NoShowFeePercent, booking.ID))
+}
+
+func (s *CancellationService) charge(booking *models.Booking, percent float64, description string) (*models.CancellationFee, error) {
+	fee := &models.CancellationFee{
+		Amount:  math.Round(booking.TotalPrice*percent) / 100,
+		Percent: percent,
+	}
+	if fee.Amount <= 0 {
+		return fee, nil
+	}
+
+	invoiceID, err := s.invoiceService.BillFee(booking, description, fee.Amount)
+	if err != nil {
+		return fee, err
+	}
+	fee.InvoiceID = invoiceID
+
+	return fee, nil
+}
+
+// bookingStart is when the crew is due at the booking, in local time
+func bookingStart(booking *models.Booking) (time.Time, error) {
+	minutes, err := parseClock(booking.ScheduledTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date := booking.ScheduledDate
+	return time.Date(date.Year(), date.Month(), date.Day(), minutes/60, minutes%60, 0, 0, time.Local), nil
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+	"cleaning-app-backend/internal/utils"
+)
+
+// CleanerService backs the field staff API. Cleaners only ever see and update
+// bookings that an admin assigned to them.
+type CleanerService struct {
+	repo        *repositories.AssignmentRepository
+	crewRepo    *repositories.CrewRepository
+	userRepo    *repositories.UserRepository
+	bookingRepo *repositories.BookingRepository
+}
+
+func NewCleanerService() *CleanerService {
+	return &CleanerService{
+		repo:        &repositories.AssignmentRepository{},
+		crewRepo:    &repositories.CrewRepository{},
+		userRepo:    &repositories.UserRepository{},
+		bookingRepo: &repositories.BookingRepository{},
+	}
+}
+
+// Statuses a cleaner may set on an assigned job. Whether the job may move
+// there from its current status is decided by the booking state machine.
+var cleanerStatuses = map[string]bool{
+	"in_progress": true,
+	"completed":   true,
+	"no_show":     true,
+}
+
+func (s *CleanerService) GetJobsForDate(userID int, date time.Time) ([]models.CleanerJob, error) {
+	return s.repo.GetAssignedJobs(userID, date)
+}
+
+func (s *CleanerService) GetJob(userID, bookingID int) (*models.CleanerJob, error) {
+	job, err := s.repo.GetAssignedJob(userID, bookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("job not found")
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// UpdateJobStatus moves an assigned job to in_progress or completed, or marks
+// it as a no-show when nobody let the crew in, which charges the no-show fee
+func (s *CleanerService) UpdateJobStatus(userID, bookingID int, status string) (*models.CleanerJob, error) {
+	if !cleanerStatuses[status] {
+		return nil, errors.New("invalid status")
+	}
+
+	job, err := s.GetJob(userID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := models.StatusActor{UserID: &userID, Role: models.RoleCleaner}
+	if err := NewBookingStatusService().ChangeStatus(bookingID, status, actor, ""); err != nil {
+		if err.Error() == "invalid status transition" {
+			return nil, err
+		}
+		return nil, errors.New("failed to update job status")
+	}
+
+	if status == "no_show" {
+		s.chargeNoShow(bookingID)
+	}
+
+	job.Status = status
+	return job, nil
+}
+
+func (s *CleanerService) chargeNoShow(bookingID int) {
+	booking, err := s.bookingRepo.GetBookingByID(bookingID)
+	if err == nil {
+		_, err = NewCancellationService().ChargeNoShow(booking)
+	}
+	if err != nil {
+		log.Printf("Failed to bill no-show fee for booking %d: %v", bookingID, err)
+	}
+}
+
+// AssignmentConflictError is returned when an assignment or reschedule would
+// put a cleaner on two overlapping bookings
+type AssignmentConflictError struct {
+	Conflicts []models.Assignee
+}
+
+func (e *AssignmentConflictError) Error() string {
+	return "cleaner already booked at this time"
+}
+
+// AssignCleaners replaces the cleaners assigned to a booking. Used both for
+// the first assignment and for reassigning a booking to other staff.
+func (s *CleanerService) AssignCleaners(bookingID int, req *models.BookingAssignmentRequest, adminID int) ([]models.Assignee, error) {
+	assignees, err := s.prepareAssignees(bookingID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetAssignees(bookingID, assignees, adminID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAssignees(bookingID)
+}
+
+// AddCleaners assigns additional cleaners to a booking
+func (s *CleanerService) AddCleaners(bookingID int, req *models.BookingAssignmentRequest, adminID int) ([]models.Assignee, error) {
+	if len(req.UserIDs) == 0 && req.CrewID == nil {
+		return nil, errors.New("no assignees given")
+	}
+
+	assignees, err := s.prepareAssignees(bookingID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddAssignees(bookingID, assignees, adminID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAssignees(bookingID)
+}
+
+func (s *CleanerService) RemoveCleaner(bookingID, userID int) error {
+	if err := s.repo.RemoveAssignee(bookingID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("assignment not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *CleanerService) GetAssignees(bookingID int) ([]models.Assignee, error) {
+	return s.repo.GetAssignees(bookingID)
+}
+
+// CheckReschedule verifies that the cleaners assigned to a booking are free at
+// the new date and time for the booking's new duration
+func (s *CleanerService) CheckReschedule(bookingID int, newDate, newTime string, durationHours float64) error {
+	assignees, err := s.repo.GetAssignees(bookingID)
+	if err != nil {
+		return err
+	}
+	if len(assignees) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int, len(assignees))
+	for i, assignee := range assignees {
+		userIDs[i] = assignee.UserID
+	}
+
+	return s.checkConflicts(bookingID, userIDs, &newDate, &newTime, &durationHours)
+}
+
+// prepareAssignees expands a crew into its members, checks that everyone is a
+// cleaner and rejects the assignment if any of them is already booked
+func (s *CleanerService) prepareAssignees(bookingID int, req *models.BookingAssignmentRequest) ([]models.Assignee, error) {
+	booking, err := s.bookingRepo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+
+	assignees := []models.Assignee{}
+	seen := make(map[int]bool)
+
+	if req.CrewID != nil {
+		crew, err := s.crewRepo.GetCrewByID(*req.CrewID)
+		if err != nil {
+			return nil, errors.New("crew not found")
+		}
+		if !crew.IsActive {
+			return nil, errors.New("crew is inactive")
+		}
+		for _, member := range crew.Members {
+			seen[member.UserID] = true
+			assignees = append(assignees, models.Assignee{UserID: member.UserID, CrewID: &crew.ID})
+		}
+	}
+
+	for _, userID := range req.UserIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		assignees = append(assignees, models.Assignee{UserID: userID})
+	}
+
+	userIDs := make([]int, len(assignees))
+	for i, assignee := range assignees {
+		user, err := s.userRepo.GetUserByID(assignee.UserID)
+		if err != nil || user.Role != models.RoleCleaner {
+			return nil, errors.New("assignees must be cleaners")
+		}
+		userIDs[i] = assignee.UserID
+	}
+
+	// Cancelled, skipped and missed bookings don't occupy anyone
+	if booking.Status != "cancelled" && booking.Status != "skipped" && booking.Status != "no_show" && len(userIDs) > 0 {
+		if err := s.checkConflicts(bookingID, userIDs, nil, nil, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return assignees, nil
+}
+
+func (s *CleanerService) checkConflicts(bookingID int, userIDs []int, newDate, newTime *string, newDuration *float64) error {
+	conflicts, err := s.repo.GetConflictingAssignees(bookingID, userIDs, newDate, newTime, newDuration)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &AssignmentConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+func (s *CleanerService) GetCleaners() ([]models.UserResponse, error) {
+	return s.userRepo.GetUsersByRole(models.RoleCleaner)
+}
+
+// CreateCleaner creates a staff account with the cleaner role
+func (s *CleanerService) CreateCleaner(req *models.CleanerCreateRequest) (*models.UserResponse, error) {
+	if _, err := s.userRepo.GetUserByEmail(req.Email); err == nil {
+		return nil, errors.New("user with this email already exists")
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, errors.New("failed to hash password")
+	}
+
+	user := &models.User{
+		Email:     req.Email,
+		Password:  hashedPassword,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Phone:     req.Phone,
+		Role:      models.RoleCleaner,
+	}
+
+	if err := s.userRepo.CreateUser(user); err != nil {
+		return nil, errors.New("failed to create user")
+	}
+
+	return &models.UserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Phone:     user.Phone,
+		Role:      user.Role,
+		CreatedAt: time.Now(),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// CrewService manages crews, named groups of cleaners that are usually sent
+// out together and can be assigned to a booking in one go
+type CrewService struct {
+	repo     *repositories.CrewRepository
+	userRepo *repositories.UserRepository
+}
+
+func NewCrewService() *CrewService {
+	return &CrewService{
+		repo:     &repositories.CrewRepository{},
+		userRepo: &repositories.UserRepository{},
+	}
+}
+
+func (s *CrewService) GetAllCrews() ([]models.Crew, error) {
+	return s.repo.GetAllCrews()
+}
+
+func (s *CrewService) CreateCrew(req *models.CrewRequest) (*models.Crew, error) {
+	crew := &models.Crew{
+		Name:     req.Name,
+		Color:    req.Color,
+		IsActive: true,
+		Members:  []models.Assignee{},
+	}
+	if crew.Color == "" {
+		crew.Color = "#2196F3"
+	}
+	if req.IsActive != nil {
+		crew.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.CreateCrew(crew); err != nil {
+		return nil, errors.New("failed to create crew")
+	}
+
+	return crew, nil
+}
+
+func (s *CrewService) UpdateCrew(id int, req *models.CrewRequest) (*models.Crew, error) {
+	crew, err := s.repo.GetCrewByID(id)
+	if err != nil {
+		return nil, errors.New("crew not found")
+	}
+
+	crew.Name = req.Name
+	if req.Color != "" {
+		crew.Color = req.Color
+	}
+	if req.IsActive != nil {
+		crew.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateCrew(crew); err != nil {
+		return nil, errors.New("failed to update crew")
+	}
+
+	return crew, nil
+}
+
+func (s *CrewService) DeleteCrew(id int) error {
+	if _, err := s.repo.GetCrewByID(id); err != nil {
+		return errors.New("crew not found")
+	}
+	return s.repo.DeleteCrew(id)
+}
+
+// SetCrewMembers replaces the members of a crew. Only cleaners can be members.
+func (s *CrewService) SetCrewMembers(id int, userIDs []int) (*models.Crew, error) {
+	if _, err := s.repo.GetCrewByID(id); err != nil {
+		return nil, errors.New("crew not found")
+	}
+
+	for _, userID := range userIDs {
+		user, err := s.userRepo.GetUserByID(userID)
+		if err != nil || user.Role != models.RoleCleaner {
+			return nil, errors.New("crew members must be cleaners")
+		}
+	}
+
+	if err := s.repo.SetCrewMembers(id, userIDs); err != nil {
+		return nil, errors.New("failed to update crew members")
+	}
+
+	return s.repo.GetCrewByID(id)
+}
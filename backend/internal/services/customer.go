@@ -0,0 +1,319 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// CustomerService keeps one record per customer however they reach us.
+// Records are found by account first and otherwise by email (or a contact's
+// email) among those no account owns, and created on first contact.
+// Duplicates that slip through, such as a client writing in from a second
+// address, are merged by an admin.
+type CustomerService struct {
+	repo         *repositories.CustomerRepository
+	propertyRepo *repositories.PropertyRepository
+	userRepo     *repositories.UserRepository
+	paymentRepo  *repositories.PaymentRepository
+}
+
+func NewCustomerService() *CustomerService {
+	return &CustomerService{
+		repo:         &repositories.CustomerRepository{},
+		propertyRepo: &repositories.PropertyRepository{},
+		userRepo:     &repositories.UserRepository{},
+		paymentRepo:  &repositories.PaymentRepository{},
+	}
+}
+
+// ForUser returns the customer ID of a client account, creating the customer
+// on first use. A verified account takes over the unowned customer already
+// on file for its email; an unverified one could be using someone else's
+// address, so it gets a record of its own until it verifies.
+func (s *CustomerService) ForUser(userID int) (int, error) {
+	customer, err := s.repo.GetCustomerByUserID(userID)
+	if err == nil {
+		return customer.ID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return 0, errors.New("user not found")
+	}
+
+	if user.EmailVerified {
+		existing, err := s.repo.FindUnownedByEmail(user.Email)
+		if err == nil {
+			if err := s.repo.AttachUser(existing.ID, user.ID); err == nil {
+				return existing.ID, nil
+			}
+		} else if err != nil && err != sql.ErrNoRows {
+			return 0, err
+		}
+	}
+
+	customer = &models.Customer{
+		UserID: &user.ID,
+		Name:   strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Email:  user.Email,
+		Phone:  user.Phone,
+	}
+	if err := s.repo.CreateCustomer(customer); err != nil {
+		// A concurrent request may have created it first; user_id is unique
+		if existing, lookupErr := s.repo.GetCustomerByUserID(userID); lookupErr == nil {
+			return existing.ID, nil
+		}
+		return 0, err
+	}
+	return customer.ID, nil
+}
+
+// ForContact returns the customer ID for someone without an account, found by
+// email among the customers no account owns and created if there is none yet.
+// Anyone can type an email, so it never links to an account's customer.
+func (s *CustomerService) ForContact(name, email, phone string) (int, error) {
+	if email != "" {
+		customer, err := s.repo.FindUnownedByEmail(email)
+		if err == nil {
+			return customer.ID, nil
+		}
+		if err != sql.ErrNoRows {
+			return 0, err
+		}
+	}
+
+	customer := &models.Customer{Name: name, Email: email, Phone: phone}
+	if err := s.repo.CreateCustomer(customer); err != nil {
+		return 0, err
+	}
+	return customer.ID, nil
+}
+
+// link resolves the customer of a new record. Linking is best effort: the
+// record is worth keeping even if it can't be linked, and an admin can merge
+// it later.
+func (s *CustomerService) link(userID *int, name, email, phone string) *int {
+	var id int
+	var err error
+	if userID != nil {
+		id, err = s.ForUser(*userID)
+	} else {
+		id, err = s.ForContact(name, email, phone)
+	}
+	if err != nil {
+		log.Printf("Failed to link customer for %s: %v", email, err)
+		return nil
+	}
+	return &id
+}
+
+// AbsorbEmail merges the unowned customers on file for an email into the
+// customer of a client account, after the client claimed that email's guest
+// history
+func (s *CustomerService) AbsorbEmail(userID int, email string) error {
+	customerID, err := s.ForUser(userID)
+	if err != nil {
+		return err
+	}
+
+	duplicateIDs, err := s.repo.GetUnownedIDsByEmail(email)
+	if err != nil {
+		return err
+	}
+	for _, duplicateID := range duplicateIDs {
+		if duplicateID == customerID {
+			continue
+		}
+		if err := s.repo.MergeCustomers(customerID, duplicateID, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CustomerService) SearchCustomers(query, tag string, page, limit int) ([]models.CustomerSummary, int, error) {
+	return s.repo.SearchCustomers(strings.TrimSpace(query), tag, limit, (page-1)*limit)
+}
+
+func (s *CustomerService) GetCustomer(id int) (*models.CustomerDetail, error) {
+	customer, err := s.repo.GetCustomerByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+
+	detail := &models.CustomerDetail{Customer: *customer}
+	if detail.Contacts, err = s.repo.GetContacts(id); err != nil {
+		return nil, err
+	}
+	if detail.Properties, err = s.propertyRepo.GetProperties(id); err != nil {
+		return nil, err
+	}
+	if detail.Notes, err = s.repo.GetNotes(id); err != nil {
+		return nil, err
+	}
+	if detail.Stats, err = s.repo.GetStats(id); err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+func (s *CustomerService) CreateCustomer(req *models.CustomerRequest) (*models.Customer, error) {
+	customer := &models.Customer{}
+	applyCustomerRequest(customer, req)
+	if err := s.repo.CreateCustomer(customer); err != nil {
+		return nil, errors.New("failed to create customer")
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) UpdateCustomer(id int, req *models.CustomerRequest) (*models.Customer, error) {
+	customer, err := s.repo.GetCustomerByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+
+	applyCustomerRequest(customer, req)
+	if err := s.repo.UpdateCustomer(customer); err != nil {
+		return nil, errors.New("failed to update customer")
+	}
+	return customer, nil
+}
+
+func applyCustomerRequest(customer *models.Customer, req *models.CustomerRequest) {
+	customer.Name = req.Name
+	customer.Company = req.Company
+	customer.Email = req.Email
+	customer.Phone = req.Phone
+	customer.BillingAddress = req.BillingAddress
+	customer.BillingCity = req.BillingCity
+	customer.BillingState = req.BillingState
+	customer.BillingZipCode = req.BillingZipCode
+	customer.BillingCountry = req.BillingCountry
+	customer.TaxExempt = req.TaxExempt
+	customer.TaxExemptReason = req.TaxExemptReason
+	customer.Tags = normalizeTags(req.Tags)
+}
+
+// normalizeTags lower-cases tags and drops duplicates so filtering by tag is
+// predictable
+func normalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+func (s *CustomerService) AddContact(customerID int, req *models.CustomerContactRequest) (*models.CustomerContact, error) {
+	if _, err := s.activeCustomer(customerID); err != nil {
+		return nil, err
+	}
+
+	contact := &models.CustomerContact{
+		CustomerID: customerID,
+		Name:       req.Name,
+		Email:      req.Email,
+		Phone:      req.Phone,
+		Label:      req.Label,
+	}
+	if err := s.repo.AddContact(contact); err != nil {
+		return nil, errors.New("failed to add contact")
+	}
+	return contact, nil
+}
+
+func (s *CustomerService) DeleteContact(customerID, contactID int) error {
+	err := s.repo.DeleteContact(customerID, contactID)
+	if err == sql.ErrNoRows {
+		return errors.New("contact not found")
+	}
+	return err
+}
+
+func (s *CustomerService) AddNote(customerID int, body string, authorID int) (*models.CustomerNote, error) {
+	if _, err := s.activeCustomer(customerID); err != nil {
+		return nil, err
+	}
+
+	note := &models.CustomerNote{CustomerID: customerID, AuthorID: &authorID, Body: body}
+	if err := s.repo.AddNote(note); err != nil {
+		return nil, errors.New("failed to add note")
+	}
+	return note, nil
+}
+
+func (s *CustomerService) GetTimeline(customerID, limit int) ([]models.TimelineEvent, error) {
+	if _, err := s.repo.GetCustomerByID(customerID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+	return s.repo.GetTimeline(customerID, limit)
+}
+
+// GetCredit returns a customer's credit and the entries that make it up
+func (s *CustomerService) GetCredit(customerID int) ([]models.CustomerCredit, float64, error) {
+	if _, err := s.repo.GetCustomerByID(customerID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, errors.New("customer not found")
+		}
+		return nil, 0, err
+	}
+	return s.paymentRepo.GetCustomerCredits(customerID)
+}
+
+// MergeCustomers folds a duplicate into a customer. The customer keeps its own
+// profile; the duplicate's records, tags and email move over.
+func (s *CustomerService) MergeCustomers(customerID, duplicateID, adminID int) (*models.CustomerDetail, error) {
+	if customerID == duplicateID {
+		return nil, errors.New("cannot merge a customer into itself")
+	}
+
+	err := s.repo.MergeCustomers(customerID, duplicateID, &adminID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+
+	return s.GetCustomer(customerID)
+}
+
+// activeCustomer loads a customer that has not been merged away; new records
+// belong on the customer it was merged into
+func (s *CustomerService) activeCustomer(id int) (*models.Customer, error) {
+	customer, err := s.repo.GetCustomerByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+	if customer.MergedIntoID != nil {
+		return nil, errors.New("customer already merged")
+	}
+	return customer, nil
+}
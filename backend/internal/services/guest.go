@@ -1,6 +1,7 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
 	"time"
 
@@ -21,23 +22,26 @@ func NewQuoteService() *QuoteService {
 func (s *QuoteService) CreateQuote(quoteReq *models.QuoteRequest) (*models.QuoteResponse, error) {
 	// Calculate estimated price
 	serviceService := NewServiceService()
-	service, err := serviceService.GetServiceByID(quoteReq.ServiceID)
+	service, err := serviceService.GetBookableService(quoteReq.ServiceID)
 	if err != nil {
-		return nil, errors.New("service not found")
+		return nil, err
 	}
 
-	// Basic pricing calculation - can be enhanced with more complex logic
-	basePrice := service.BasePrice
-	sizeMultiplier := quoteReq.SquareMeters / 50.0 // Base rate per 50 sqm
-	if sizeMultiplier < 1 {
-		sizeMultiplier = 1
+	breakdown, err := NewPricingService().Price(models.PriceInput{
+		ServiceID:    quoteReq.ServiceID,
+		SquareMeters: quoteReq.SquareMeters,
+		Bedrooms:     quoteReq.Bedrooms,
+		Bathrooms:    quoteReq.Bathrooms,
+		AddOns:       quoteReq.AddOns,
+		Condition:    quoteReq.Condition,
+		Frequency:    quoteReq.Frequency,
+	})
+	if err != nil {
+		return nil, err
 	}
-	
-	estimatedPrice := basePrice * sizeMultiplier
-	
-	// Add complexity factors
-	if len(quoteReq.SpecialRequirements) > 100 {
-		estimatedPrice *= 1.2 // 20% increase for complex requirements
+	customerID := NewCustomerService().link(nil, quoteReq.ContactName, quoteReq.ContactEmail, quoteReq.ContactPhone)
+	if _, err := NewPromoService().Apply(quoteReq.PromoCode, quoteReq.ServiceID, customerID, breakdown); err != nil {
+		return nil, err
 	}
 
 	quote := &models.Quote{
@@ -49,9 +53,12 @@ func (s *QuoteService) CreateQuote(quoteReq *models.QuoteRequest) (*models.Quote
 		ContactEmail:        quoteReq.ContactEmail,
 		ContactName:         quoteReq.ContactName,
 		ContactPhone:        quoteReq.ContactPhone,
-		EstimatedPrice:      estimatedPrice,
-		Status:              "pending",
+		EstimatedPrice:      breakdown.Total,
+		PriceBreakdown:      breakdown,
+		Status:              models.QuoteStatusPending,
+		CustomerID:          customerID,
 	}
+	quote.PropertyID = NewPropertyService().forAddress(quote.CustomerID, quote.Address, quote.SquareMeters)
 
 	err = s.repo.CreateQuote(quote)
 	if err != nil {
@@ -70,6 +77,7 @@ func (s *QuoteService) CreateQuote(quoteReq *models.QuoteRequest) (*models.Quote
 		ContactName:         quote.ContactName,
 		ContactPhone:        quote.ContactPhone,
 		EstimatedPrice:      quote.EstimatedPrice,
+		PriceBreakdown:      quote.PriceBreakdown,
 		Status:              quote.Status,
 		CreatedAt:           quote.CreatedAt,
 	}
@@ -77,30 +85,63 @@ func (s *QuoteService) CreateQuote(quoteReq *models.QuoteRequest) (*models.Quote
 	return quoteResp, nil
 }
 
-func (s *QuoteService) GetInstantEstimate(serviceID int, squareMeters float64) (float64, error) {
-	serviceService := NewServiceService()
-	service, err := serviceService.GetServiceByID(serviceID)
+// GetInstantEstimate prices a job the way booking it would. A promo code's
+// per-customer limits can't be checked without knowing the customer, so they
+// are left to the booking.
+func (s *QuoteService) GetInstantEstimate(req *models.EstimateRequest) (*models.PriceBreakdown, error) {
+	if _, err := NewServiceService().GetBookableService(req.ServiceID); err != nil {
+		return nil, err
+	}
+	breakdown, err := NewPricingService().Price(models.PriceInput{
+		ServiceID:    req.ServiceID,
+		SquareMeters: req.SquareMeters,
+		Bedrooms:     req.Bedrooms,
+		Bathrooms:    req.Bathrooms,
+		AddOns:       req.AddOns,
+		Condition:    req.Condition,
+		Frequency:    req.Frequency,
+	})
 	if err != nil {
-		return 0, errors.New("service not found")
+		return nil, err
 	}
-
-	// Simple estimation algorithm
-	basePrice := service.BasePrice
-	sizeMultiplier := squareMeters / 50.0
-	if sizeMultiplier < 1 {
-		sizeMultiplier = 1
+	if _, err := NewPromoService().Apply(req.PromoCode, req.ServiceID, nil, breakdown); err != nil {
+		return nil, err
 	}
-
-	estimate := basePrice * sizeMultiplier
-
-	return estimate, nil
+	return breakdown, nil
 }
 
 func (s *QuoteService) GetAllQuotes() ([]models.QuoteResponse, error) {
+	if err := s.repo.ExpireQuotes(); err != nil {
+		return nil, err
+	}
 	return s.repo.GetAllQuotes()
 }
 
+func (s *QuoteService) GetQuotesByUserID(userID int) ([]models.QuoteResponse, error) {
+	if err := s.repo.ExpireQuotes(); err != nil {
+		return nil, err
+	}
+	return s.repo.GetQuotesByUserID(userID)
+}
+
+// UpdateQuote edits a quote's price and notes. Admins can put a quote back to
+// pending or reject it; it becomes sent only by sending it and accepted only
+// by the customer. An accepted quote has its booking and can't be changed.
 func (s *QuoteService) UpdateQuote(id int, estimatedPrice float64, status, adminNotes string) error {
+	current, err := s.repo.GetQuoteByID(id)
+	if err == sql.ErrNoRows {
+		return errors.New("quote not found")
+	}
+	if err != nil {
+		return err
+	}
+	if current.Status == models.QuoteStatusAccepted {
+		return errors.New("quote already accepted")
+	}
+	if status != current.Status && status != models.QuoteStatusPending && status != models.QuoteStatusRejected {
+		return errors.New("invalid quote status change")
+	}
+
 	quote := &models.Quote{
 		ID:             id,
 		EstimatedPrice: estimatedPrice,
@@ -130,6 +171,7 @@ func (s *ContactService) CreateContactMessage(messageReq *models.ContactMessageR
 		Status:   "new",
 		Priority: "medium", // Default priority
 		Category: messageReq.Category,
+		CustomerID: NewCustomerService().link(nil, messageReq.Name, messageReq.Email, messageReq.Phone),
 	}
 
 	err := s.repo.CreateContactMessage(message)
@@ -211,17 +253,35 @@ func (s *ContactService) UpdateFAQ(id int, faqReq *models.FAQRequest) error {
 	return s.repo.UpdateFAQ(faq)
 }
 
-func (s *ContactService) DeleteFAQ(id int) error {
-	return s.repo.DeleteFAQ(id)
+// GetAllFAQs lists FAQs for admins, inactive ones included
+func (s *ContactService) GetAllFAQs(includeArchived bool) ([]models.FAQResponse, error) {
+	return s.repo.GetAllFAQs(includeArchived)
+}
+
+// ArchiveFAQ takes an FAQ off the public list. It can be restored later.
+func (s *ContactService) ArchiveFAQ(id int) error {
+	return s.setFAQArchived(id, true)
+}
+
+func (s *ContactService) RestoreFAQ(id int) error {
+	return s.setFAQArchived(id, false)
+}
+
+func (s *ContactService) setFAQArchived(id int, archived bool) error {
+	err := s.repo.SetFAQArchived(id, archived)
+	if err == sql.ErrNoRows {
+		return errors.New("FAQ not found")
+	}
+	return err
 }
 
 // Enhanced booking service methods for guest bookings
 func (s *BookingService) CreateGuestBooking(bookingReq *models.GuestBookingRequest) (*models.BookingResponse, error) {
 	// Get service details
 	serviceService := NewServiceService()
-	service, err := serviceService.GetServiceByID(bookingReq.ServiceID)
+	service, err := serviceService.GetBookableService(bookingReq.ServiceID)
 	if err != nil {
-		return nil, errors.New("service not found")
+		return nil, err
 	}
 
 	// Parse date
@@ -230,10 +290,22 @@ func (s *BookingService) CreateGuestBooking(bookingReq *models.GuestBookingReque
 		return nil, errors.New("invalid date format. Use YYYY-MM-DD")
 	}
 
-	// Calculate total price
-	totalPrice := service.BasePrice * bookingReq.SquareMeters / 50
-	if totalPrice < service.BasePrice {
-		totalPrice = service.BasePrice // Minimum price
+	breakdown, err := NewPricingService().Price(models.PriceInput{
+		ServiceID:    bookingReq.ServiceID,
+		SquareMeters: bookingReq.SquareMeters,
+		Bedrooms:     bookingReq.Bedrooms,
+		Bathrooms:    bookingReq.Bathrooms,
+		AddOns:       bookingReq.AddOns,
+		Condition:    bookingReq.Condition,
+	})
+	if err != nil {
+		return nil, err
+	}
+	customerID := NewCustomerService().link(nil, bookingReq.GuestName, bookingReq.GuestEmail, bookingReq.GuestPhone)
+	promos := NewPromoService()
+	promo, err := promos.Apply(bookingReq.PromoCode, bookingReq.ServiceID, customerID, breakdown)
+	if err != nil {
+		return nil, err
 	}
 
 	booking := &models.Booking{
@@ -244,17 +316,23 @@ func (s *BookingService) CreateGuestBooking(bookingReq *models.GuestBookingReque
 		Address:             bookingReq.Address,
 		SquareMeters:        bookingReq.SquareMeters,
 		SpecialInstructions: bookingReq.SpecialInstructions,
-		TotalPrice:          totalPrice,
+		TotalPrice:          breakdown.Total,
+		PriceBreakdown:      breakdown,
+		DurationHours:       JobDurationHours(service.Duration, bookingReq.SquareMeters) + breakdown.AddOnHours,
 		Status:              "pending",
 		GuestName:           bookingReq.GuestName,
 		GuestEmail:          bookingReq.GuestEmail,
 		GuestPhone:          bookingReq.GuestPhone,
 		IsGuestBooking:      true,
+		CustomerID:          customerID,
 	}
+	booking.PropertyID = NewPropertyService().forAddress(booking.CustomerID, booking.Address, booking.SquareMeters)
 
-	err = s.repo.CreateGuestBooking(booking)
+	err = promos.redeem(promo, booking, func() error {
+		return s.createInSlot(booking, s.repo.CreateGuestBooking)
+	})
 	if err != nil {
-		return nil, errors.New("failed to create booking")
+		return nil, err
 	}
 
 	// Get the created booking with service name
@@ -269,7 +347,9 @@ func (s *BookingService) CreateGuestBooking(bookingReq *models.GuestBookingReque
 		SquareMeters:        booking.SquareMeters,
 		SpecialInstructions: booking.SpecialInstructions,
 		TotalPrice:          booking.TotalPrice,
+		DurationHours:       booking.DurationHours,
 		Status:              booking.Status,
+		PriceBreakdown:      booking.PriceBreakdown,
 		GuestName:           booking.GuestName,
 		GuestEmail:          booking.GuestEmail,
 		GuestPhone:          booking.GuestPhone,
@@ -279,7 +359,3 @@ func (s *BookingService) CreateGuestBooking(bookingReq *models.GuestBookingReque
 
 	return bookingResp, nil
 }
-
-func (s *BookingService) GetGuestBooking(bookingID int, email string) (*models.BookingResponse, error) {
-	return s.repo.GetGuestBooking(bookingID, email)
-}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// GuestClaimService moves guest history into client accounts. Clients claim
+// what was booked with their own email once they have verified it; anything
+// made with another email needs an admin to merge it.
+type GuestClaimService struct {
+	repo     *repositories.GuestClaimRepository
+	userRepo *repositories.UserRepository
+}
+
+func NewGuestClaimService() *GuestClaimService {
+	return &GuestClaimService{
+		repo:     &repositories.GuestClaimRepository{},
+		userRepo: &repositories.UserRepository{},
+	}
+}
+
+// ClaimVerifiedEmail links the guest history of the user's own email to their
+// account. An unverified email could belong to someone else, so it is refused.
+func (s *GuestClaimService) ClaimVerifiedEmail(userID int) (*models.GuestClaim, error) {
+	user, err := s.clientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.EmailVerified {
+		return nil, errors.New("email not verified")
+	}
+
+	return s.claim(user.ID, user.Email, nil)
+}
+
+// MergeGuestHistory links the guest history of any email to a client account
+// on an admin's behalf
+func (s *GuestClaimService) MergeGuestHistory(userID int, email string, adminID int) (*models.GuestClaim, error) {
+	user, err := s.clientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.claim(user.ID, email, &adminID)
+}
+
+// claim moves the guest history and folds the email's customer records into
+// the client's. The claim stands even if the customers can't be merged; an
+// admin can merge them by hand.
+func (s *GuestClaimService) claim(userID int, email string, claimedBy *int) (*models.GuestClaim, error) {
+	claim, err := s.repo.ClaimGuestHistory(userID, email, claimedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := NewCustomerService().AbsorbEmail(userID, email); err != nil {
+		log.Printf("Failed to merge customers of %s into user %d: %v", email, userID, err)
+	}
+	return claim, nil
+}
+
+func (s *GuestClaimService) GetClaims(userID int) ([]models.GuestClaim, error) {
+	return s.repo.GetClaims(userID)
+}
+
+// clientUser loads the user, who must be a client: staff accounts don't own
+// bookings
+func (s *GuestClaimService) clientUser(userID int) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Role != models.RoleClient {
+		return nil, errors.New("user is not a client")
+	}
+	return user, nil
+}
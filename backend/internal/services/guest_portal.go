@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/mail"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/payments"
+	"cleaning-app-backend/internal/repositories"
+	"cleaning-app-backend/internal/utils"
+)
+
+// GuestPortalService lets guests manage a booking without an account. Access
+// is granted by a signed link mailed with the booking confirmation; the link
+// names the booking and expires after GUEST_LINK_EXPIRY. Nothing is stored
+// per link, so every link issued for a booking stays valid until it expires
+// or the booking's email changes.
+type GuestPortalService struct {
+	bookingService *BookingService
+	bookingRepo    *repositories.BookingRepository
+	invoiceRepo    *repositories.InvoiceRepository
+	invoiceService *InvoiceService
+	mailer         mail.Sender
+	config         config.Config
+}
+
+func NewGuestPortalService(cfg config.Config) *GuestPortalService {
+	invoiceRepo := repositories.NewInvoiceRepository(database.DB)
+	bookingRepo := &repositories.BookingRepository{}
+	return &GuestPortalService{
+		bookingService: NewBookingService(),
+		bookingRepo:    bookingRepo,
+		invoiceRepo:    invoiceRepo,
+		invoiceService: NewInvoiceService(invoiceRepo, bookingRepo),
+		mailer:         mail.NewSender(cfg),
+		config:         cfg,
+	}
+}
+
+// IssueToken signs a new access token for a guest booking
+func (s *GuestPortalService) IssueToken(bookingID int, email string) (string, error) {
+	ttl, err := time.ParseDuration(s.config.GuestLinkExpiry)
+	if err != nil {
+		return "", fmt.Errorf("invalid guest link expiry: %v", err)
+	}
+	return utils.SignGuestToken(bookingID, email, time.Now().Add(ttl), s.config.JWTSecret), nil
+}
+
+// SendConfirmation mails the booking confirmation with the guest's portal link
+func (s *GuestPortalService) SendConfirmation(booking *models.BookingResponse) error {
+	token, err := s.IssueToken(booking.ID, booking.GuestEmail)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mail.Message{
+		To:      booking.GuestEmail,
+		Subject: fmt.Sprintf("Your cleaning booking #%d", booking.ID),
+		Body: fmt.Sprintf(`Hi %s,
+
+Thanks for booking %s with us on %s at %s.
+
+You can view, reschedule or cancel your booking and pay its invoice here:
+
+%s
+
+Keep this link private: anyone who has it can manage your booking. It expires in %s.
+
+Premier Prime Cleaning Services`, booking.GuestName, booking.ServiceName, booking.ScheduledDate.Format("January 2, 2006"),
+			booking.ScheduledTime, s.link(token), s.config.GuestLinkExpiry),
+	})
+}
+
+// ResendLink mails a fresh portal link for a guest booking. It does not report
+// whether the booking exists or the email matches.
+func (s *GuestPortalService) ResendLink(bookingID int, email string) error {
+	booking, err := s.bookingRepo.GetGuestBooking(bookingID, email)
+	if err != nil {
+		return nil
+	}
+
+	token, err := s.IssueToken(booking.ID, booking.GuestEmail)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mail.Message{
+		To:      booking.GuestEmail,
+		Subject: fmt.Sprintf("Manage your cleaning booking #%d", booking.ID),
+		Body: fmt.Sprintf(`Hi %s,
+
+Here is a new link to manage your booking:
+
+%s
+
+It expires in %s. If you didn't ask for it, you can ignore this email.
+
+Premier Prime Cleaning Services`, booking.GuestName, s.link(token), s.config.GuestLinkExpiry),
+	})
+}
+
+// Authenticate returns the guest booking a token grants access to
+func (s *GuestPortalService) Authenticate(token string) (*models.Booking, error) {
+	bookingID, expiresAt, err := utils.ParseGuestToken(token)
+	if err != nil || time.Now().After(expiresAt) {
+		return nil, errors.New("invalid or expired link")
+	}
+
+	// A claimed booking is managed from the client's account from then on
+	booking, err := s.bookingRepo.GetBookingByID(bookingID)
+	if err != nil || !booking.IsGuestBooking || booking.UserID != nil {
+		return nil, errors.New("invalid or expired link")
+	}
+	if !utils.VerifyGuestToken(token, booking.GuestEmail, s.config.JWTSecret) {
+		return nil, errors.New("invalid or expired link")
+	}
+
+	return booking, nil
+}
+
+func (s *GuestPortalService) GetBooking(booking *models.Booking) (*models.BookingResponse, error) {
+	return s.bookingRepo.GetGuestBooking(booking.ID, booking.GuestEmail)
+}
+
+// Reschedule moves the booking under the same rules as an account holder's
+func (s *GuestPortalService) Reschedule(booking *models.Booking, req *models.BookingRescheduleRequest) error {
+	actor := models.StatusActor{Role: models.ActorGuest}
+	return s.bookingService.reschedule(booking, req.ScheduledDate, req.ScheduledTime, actor, req.Reason)
+}
+
+// Cancel cancels the booking, charging the late cancellation fee if due
+func (s *GuestPortalService) Cancel(booking *models.Booking, reason string) (*models.CancellationFee, error) {
+	actor := models.StatusActor{Role: models.ActorGuest}
+	return s.bookingService.cancel(booking, actor, reason, false)
+}
+
+// GetInvoice returns the booking's own invoice
+func (s *GuestPortalService) GetInvoice(booking *models.Booking) (*models.InvoiceResponse, error) {
+	invoice, err := s.invoiceRepo.GetInvoiceByBookingID(booking.ID)
+	if err != nil && err.Error() == "invoice not found for booking" {
+		return nil, errors.New("invoice not found")
+	}
+	return invoice, err
+}
+
+// GetInvoices returns every invoice billed for the booking: its own and any
+// fee or late-fee invoices
+func (s *GuestPortalService) GetInvoices(booking *models.Booking) ([]models.InvoiceResponse, error) {
+	return s.invoiceRepo.GetInvoicesByBookingID(booking.ID)
+}
+
+// GetBookingInvoice returns one of the invoices billed for the booking
+func (s *GuestPortalService) GetBookingInvoice(booking *models.Booking, invoiceID int) (*models.InvoiceResponse, error) {
+	invoice, err := s.invoiceRepo.GetInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.BookingID != booking.ID || invoice.ArchivedAt != nil {
+		return nil, errors.New("invoice not found")
+	}
+	return invoice, nil
+}
+
+// InvoicePDF returns the PDF of an invoice of the booking
+func (s *GuestPortalService) InvoicePDF(invoice *models.InvoiceResponse) (*models.InvoiceResponse, *models.InvoiceDocument, error) {
+	return s.invoiceService.InvoicePDF(invoice.ID)
+}
+
+// PayInvoice charges the balance of an invoice of the booking through the
+// payment gateway and records the payment. The invoice is locked while it is
+// charged so a double submit can't pay it twice.
+func (s *GuestPortalService) PayInvoice(booking *models.Booking, invoice *models.InvoiceResponse, paymentToken string) (*models.InvoiceResponse, error) {
+	gateway, err := payments.NewGateway(s.config)
+	if err != nil {
+		if err != payments.ErrUnavailable {
+			log.Printf("Payment gateway misconfigured: %v", err)
+		}
+		return nil, payments.ErrUnavailable
+	}
+
+	unlock, err := s.invoiceRepo.LockInvoice(invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Re-read under the lock, another request may have paid it meanwhile
+	invoice, err = s.invoiceRepo.GetInvoiceByID(invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch invoice.Status {
+	case models.InvoiceStatusPending, models.InvoiceStatusPartiallyPaid, models.InvoiceStatusOverdue:
+	default:
+		return nil, errors.New("invoice is not payable")
+	}
+	if invoice.BalanceDue <= 0 {
+		return nil, errors.New("invoice is not payable")
+	}
+
+	receipt, err := gateway.Charge(payments.Charge{
+		InvoiceNumber: invoice.InvoiceNumber,
+		Amount:        invoice.BalanceDue,
+		Email:         booking.GuestEmail,
+		Token:         paymentToken,
+	})
+	if err != nil {
+		log.Printf("Payment for invoice %s failed: %v", invoice.InvoiceNumber, err)
+		return nil, errors.New("payment failed")
+	}
+
+	_, err = s.invoiceService.RecordPayment(invoice.ID, &models.PaymentRequest{
+		Amount:    invoice.BalanceDue,
+		Method:    receipt.Method,
+		Reference: receipt.Reference,
+	}, nil)
+	if err != nil {
+		// The money was collected; the payment has to be recorded by hand
+		log.Printf("Invoice %s was charged %.2f (reference %s) but the payment was not recorded: %v", invoice.InvoiceNumber, invoice.BalanceDue, receipt.Reference, err)
+		return nil, errors.New("failed to record payment")
+	}
+
+	return s.invoiceRepo.GetInvoiceByID(invoice.ID)
+}
+
+func (s *GuestPortalService) link(token string) string {
+	return fmt.Sprintf("%s/guest/booking?token=%s", s.config.AppURL, url.QueryEscape(token))
+}
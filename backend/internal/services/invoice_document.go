@@ -0,0 +1,124 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/pdf"
+)
+
+// InvoicePDF returns an invoice with its PDF. Every version sent out is kept:
+// the stored PDF is served until the invoice changes, and only then is a new
+// version rendered and stored. A change that doesn't show on the invoice,
+// like archiving it, doesn't make a new version.
+func (s *InvoiceService) InvoicePDF(id int) (*models.InvoiceResponse, *models.InvoiceDocument, error) {
+	invoice, err := s.invoiceRepo.GetInvoiceByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	latest, err := s.invoiceRepo.GetLatestDocument(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if latest != nil && latest.InvoiceUpdatedAt.Equal(invoice.UpdatedAt) {
+		return invoice, latest, nil
+	}
+
+	unlock, err := s.invoiceRepo.LockInvoice(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	// Another request may have stored this version while we waited
+	if invoice, err = s.invoiceRepo.GetInvoiceByID(id); err != nil {
+		return nil, nil, err
+	}
+	if latest, err = s.invoiceRepo.GetLatestDocument(id); err != nil {
+		return nil, nil, err
+	}
+	if latest != nil && latest.InvoiceUpdatedAt.Equal(invoice.UpdatedAt) {
+		return invoice, latest, nil
+	}
+
+	rendered := pdf.Invoice(invoice)
+	sum := sha256.Sum256(rendered)
+	hash := hex.EncodeToString(sum[:])
+	if latest != nil && latest.SHA256 == hash {
+		return invoice, latest, nil
+	}
+
+	doc := &models.InvoiceDocument{
+		InvoiceID:        id,
+		PDF:              rendered,
+		SHA256:           hash,
+		InvoiceUpdatedAt: invoice.UpdatedAt,
+	}
+	if err := s.invoiceRepo.CreateDocument(doc); err != nil {
+		return nil, nil, err
+	}
+	return invoice, doc, nil
+}
+
+// ClientInvoicePDF returns the PDF of one of a client's invoices
+func (s *InvoiceService) ClientInvoicePDF(id, userID int) (*models.InvoiceResponse, *models.InvoiceDocument, error) {
+	owned, err := s.invoiceRepo.IsInvoiceOwner(id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !owned {
+		return nil, nil, errors.New("invoice not found")
+	}
+	return s.InvoicePDF(id)
+}
+
+// CreditNotePDF returns a credit note with its PDF. A credit note can't
+// change, so it is rendered once and the stored PDF is served from then on.
+func (s *InvoiceService) CreditNotePDF(id int) (*models.CreditNote, *models.CreditNoteDocument, error) {
+	note, err := s.creditNoteRepo.GetCreditNoteByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := s.creditNoteRepo.GetDocument(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc != nil {
+		return note, doc, nil
+	}
+
+	invoice, err := s.invoiceRepo.GetInvoiceByID(note.InvoiceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rendered := pdf.CreditNote(note, invoice)
+	sum := sha256.Sum256(rendered)
+	doc, err = s.creditNoteRepo.CreateDocument(&models.CreditNoteDocument{
+		CreditNoteID: id,
+		PDF:          rendered,
+		SHA256:       hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return note, doc, nil
+}
+
+// ClientCreditNotePDF returns the PDF of a credit note of one of a client's
+// invoices
+func (s *InvoiceService) ClientCreditNotePDF(id, userID int) (*models.CreditNote, *models.CreditNoteDocument, error) {
+	note, err := s.creditNoteRepo.GetCreditNoteByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	owned, err := s.invoiceRepo.IsInvoiceOwner(note.InvoiceID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !owned {
+		return nil, nil, errors.New("credit note not found")
+	}
+	return s.CreditNotePDF(id)
+}
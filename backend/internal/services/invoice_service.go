@@ -1,30 +1,57 @@
 package services
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"time"
 	"strings"
 
+	"cleaning-app-backend/internal/config"
 	"cleaning-app-backend/internal/models"
 	"cleaning-app-backend/internal/repositories"
 )
 
 type InvoiceService struct {
-	invoiceRepo *repositories.InvoiceRepository
-	bookingRepo *repositories.BookingRepository
+	invoiceRepo    *repositories.InvoiceRepository
+	bookingRepo    *repositories.BookingRepository
+	paymentRepo    *repositories.PaymentRepository
+	creditNoteRepo *repositories.CreditNoteRepository
+	lateFeeRepo    *repositories.LateFeeRepository
+	customerRepo   *repositories.CustomerRepository
+	userRepo       *repositories.UserRepository
+	properties     *PropertyService
+	taxID          string
 }
 
 func NewInvoiceService(invoiceRepo *repositories.InvoiceRepository, bookingRepo *repositories.BookingRepository) *InvoiceService {
+	// LoadConfig only falls back to defaults, it never fails
+	cfg, _ := config.LoadConfig()
 	return &InvoiceService{
-		invoiceRepo: invoiceRepo,
-		bookingRepo: bookingRepo,
+		invoiceRepo:    invoiceRepo,
+		bookingRepo:    bookingRepo,
+		paymentRepo:    &repositories.PaymentRepository{},
+		creditNoteRepo: &repositories.CreditNoteRepository{},
+		lateFeeRepo:    &repositories.LateFeeRepository{},
+		customerRepo:   &repositories.CustomerRepository{},
+		userRepo:       &repositories.UserRepository{},
+		properties:     NewPropertyService(),
+		taxID:          cfg.FloridaTaxID,
 	}
 }
 
-// CreateInvoiceFromBooking creates an invoice from a booking
+// CreateInvoiceFromBooking creates an invoice from a booking, itemized the
+// way the booking was priced. Without a billing address in the request it is
+// billed to the booking's or the customer's billing address, or else the
+// service address.
 func (s *InvoiceService) CreateInvoiceFromBooking(bookingID int, request *models.InvoiceCreateRequest) (*models.InvoiceResponse, error) {
 	// Get booking details
 	booking, err := s.bookingRepo.GetBookingByID(bookingID)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("booking not found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get booking: %v", err)
 	}
@@ -32,26 +59,35 @@ func (s *InvoiceService) CreateInvoiceFromBooking(bookingID int, request *models
 	// Check if invoice already exists for this booking
 	existingInvoice, _ := s.invoiceRepo.GetInvoiceByBookingID(bookingID)
 	if existingInvoice != nil {
-		return nil, fmt.Errorf("invoice already exists for booking ID %d", bookingID)
+		return nil, errors.New("invoice already exists for booking")
 	}
 
-	// Generate invoice number
-	invoiceNumber, err := s.invoiceRepo.GenerateInvoiceNumber()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate invoice number: %v", err)
+	// Service address, from the booking's property where it has one
+	serviceCity, serviceState, serviceZip := s.properties.serviceLocation(booking.PropertyID, booking.Address)
+	customer := s.billTo(booking)
+
+	billingAddress, billingCity, billingState, billingZip := request.BillingAddress, request.BillingCity, request.BillingState, request.BillingZipCode
+	billingCountry := request.BillingCountry
+	if billingAddress == "" {
+		billingAddress, billingCity, billingState, billingZip, billingCountry = billingAddressOf(booking, customer, serviceCity, serviceState, serviceZip)
 	}
 
-	// Parse service address
-	serviceCity, serviceState, serviceZip := parseAddress(booking.Address)
+	// A customer on file as tax exempt stays exempt without being asked again,
+	// but only on their own account's bookings: a guest booking is linked by
+	// an email anyone could have typed
+	taxExempt, taxExemptReason := request.TaxExempt, request.TaxExemptReason
+	if !taxExempt && customer.TaxExempt && ownsCustomer(booking, customer) {
+		taxExempt, taxExemptReason = true, customer.TaxExemptReason
+	}
 
 	// Calculate tax
 	subtotal := booking.TotalPrice
 	var taxRate float64 = 0.0
 	var taxAmount float64 = 0.0
 
-	if !request.TaxExempt {
+	if !taxExempt {
 		taxRate = models.FloridaStateTaxRate + models.FloridaDiscretionaryTax // 7% total
-		taxAmount = subtotal * taxRate
+		taxAmount = roundCents(subtotal * taxRate)
 	}
 
 	totalAmount := subtotal + taxAmount
@@ -59,17 +95,16 @@ func (s *InvoiceService) CreateInvoiceFromBooking(bookingID int, request *models
 	// Create invoice
 	invoice := &models.Invoice{
 		BookingID:          bookingID,
-		InvoiceNumber:      invoiceNumber,
 		IssueDate:          time.Now(),
 		DueDate:            time.Now().AddDate(0, 0, models.DefaultDueDays),
-		CustomerName:       getCustomerName(booking),
-		CustomerEmail:      getCustomerEmail(booking),
-		CustomerPhone:      getCustomerPhone(booking),
-		BillingAddress:     request.BillingAddress,
-		BillingCity:        request.BillingCity,
-		BillingState:       request.BillingState,
-		BillingZipCode:     request.BillingZipCode,
-		BillingCountry:     getDefaultCountry(request.BillingCountry),
+		CustomerName:       customer.Name,
+		CustomerEmail:      customer.Email,
+		CustomerPhone:      customer.Phone,
+		BillingAddress:     billingAddress,
+		BillingCity:        billingCity,
+		BillingState:       billingState,
+		BillingZipCode:     billingZip,
+		BillingCountry:     getDefaultCountry(billingCountry),
 		ServiceAddress:     booking.Address,
 		ServiceCity:        serviceCity,
 		ServiceState:       serviceState,
@@ -80,9 +115,9 @@ func (s *InvoiceService) CreateInvoiceFromBooking(bookingID int, request *models
 		TotalAmount:       totalAmount,
 		Status:            models.InvoiceStatusPending,
 		PaymentMethod:     request.PaymentMethod,
-		FloridaTaxID:      "FL-TAX-ID-123456", // Should be configurable
-		TaxExempt:         request.TaxExempt,
-		TaxExemptReason:   request.TaxExemptReason,
+		FloridaTaxID:      s.taxID,
+		TaxExempt:         taxExempt,
+		TaxExemptReason:   taxExemptReason,
 		Notes:             request.Notes,
 		Terms:             getDefaultTerms(),
 		CreatedAt:         time.Now(),
@@ -103,7 +138,7 @@ func (s *InvoiceService) CreateInvoiceFromBooking(bookingID int, request *models
 			}
 			items = append(items, item)
 		}
-	} else {
+	} else if items = breakdownItems(booking.PriceBreakdown, booking.TotalPrice); items == nil {
 		// Create default item from booking
 		// Get service name from service ID
 		serviceName := "Cleaning Service" // Default name
@@ -122,16 +157,67 @@ func (s *InvoiceService) CreateInvoiceFromBooking(bookingID int, request *models
 	if err != nil {
 		return nil, fmt.Errorf("failed to create invoice: %v", err)
 	}
+	// The invoice is issued either way; the link only saves looking it up
+	if err := s.invoiceRepo.LinkBooking(bookingID, invoice.ID); err != nil {
+		log.Printf("Failed to link invoice %s to booking %d: %v", invoice.InvoiceNumber, bookingID, err)
+	}
 
 	// Return the created invoice
 	return s.invoiceRepo.GetInvoiceByID(invoice.ID)
 }
 
+// breakdownItems itemizes a booking's invoice the way it was priced. It
+// returns nil when the booking has no breakdown or its price was changed
+// since, so the items wouldn't add up to it.
+func breakdownItems(breakdown *models.PriceBreakdown, totalPrice float64) []models.InvoiceItem {
+	if breakdown == nil || breakdown.Total != totalPrice {
+		return nil
+	}
+
+	var items []models.InvoiceItem
+	for _, line := range breakdown.Lines {
+		items = append(items, models.InvoiceItem{
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			TotalPrice:  line.Amount,
+			Taxable:     true,
+		})
+	}
+	adjustments := []struct {
+		description string
+		amount      float64
+	}{
+		{fmt.Sprintf("Condition adjustment (%s)", breakdown.Condition), breakdown.ConditionAdjustment},
+		{"Minimum charge adjustment", breakdown.MinimumAdjustment},
+		{fmt.Sprintf("Recurring discount (%s, %g%%)", breakdown.Frequency, breakdown.DiscountPercent), -breakdown.Discount},
+		{fmt.Sprintf("Promo code %s", breakdown.PromoCode), -breakdown.PromoDiscount},
+	}
+	for _, adjustment := range adjustments {
+		if adjustment.amount == 0 {
+			continue
+		}
+		items = append(items, models.InvoiceItem{
+			Description: adjustment.description,
+			Quantity:    1,
+			UnitPrice:   adjustment.amount,
+			TotalPrice:  adjustment.amount,
+			Taxable:     true,
+		})
+	}
+	return items
+}
+
 // GetInvoice retrieves an invoice by ID
 func (s *InvoiceService) GetInvoice(id int) (*models.InvoiceResponse, error) {
 	return s.invoiceRepo.GetInvoiceByID(id)
 }
 
+// GetInvoicesByUserID retrieves a client's invoices
+func (s *InvoiceService) GetInvoicesByUserID(userID int) ([]models.InvoiceResponse, error) {
+	return s.invoiceRepo.GetInvoicesByUserID(userID)
+}
+
 // GetAllInvoices retrieves all invoices with pagination
 func (s *InvoiceService) GetAllInvoices(page, limit int) ([]models.InvoiceResponse, int, error) {
 	offset := (page - 1) * limit
@@ -144,26 +230,154 @@ func (s *InvoiceService) GetInvoicesByStatus(status string, page, limit int) ([]
 	return s.invoiceRepo.GetInvoicesByStatus(status, limit, offset)
 }
 
-// UpdateInvoice updates an invoice
+// UpdateInvoice changes an invoice's status. What an issued invoice says
+// can't be changed; it is corrected with a credit note. Setting it to paid
+// records a payment of the balance, and cancelling it voids it.
 func (s *InvoiceService) UpdateInvoice(id int, updates *models.InvoiceUpdateRequest) error {
-	return s.invoiceRepo.UpdateInvoice(id, updates)
+	if updates.Notes != "" {
+		return errors.New("invoice is issued")
+	}
+
+	invoice, err := s.invoiceRepo.GetInvoiceByID(id)
+	if err != nil {
+		return err
+	}
+	if updates.Status == "" || updates.Status == invoice.Status {
+		return nil
+	}
+
+	switch updates.Status {
+	case models.InvoiceStatusPaid:
+		_, err := s.RecordPayment(id, &models.PaymentRequest{
+			Method:    updates.PaymentMethod,
+			Reference: updates.PaymentReference,
+			PaidAt:    updates.PaymentDate,
+		}, nil)
+		return err
+	case models.InvoiceStatusCancelled, models.InvoiceStatusVoid:
+		return s.VoidInvoice(id, "Cancelled")
+	}
+
+	// An open invoice can be marked overdue, and back
+	status := updates.Status
+	switch {
+	case status == models.InvoiceStatusOverdue &&
+		(invoice.Status == models.InvoiceStatusPending || invoice.Status == models.InvoiceStatusPartiallyPaid):
+	case status == models.InvoiceStatusPending && invoice.Status == models.InvoiceStatusOverdue:
+		if invoice.AmountPaid > 0 {
+			status = models.InvoiceStatusPartiallyPaid
+		}
+	default:
+		return errors.New("invoice status can't change")
+	}
+	return s.invoiceRepo.UpdateStatus(id, status)
 }
 
-// MarkAsPaid marks an invoice as paid
+// VoidInvoice voids an invoice nothing has been paid or credited on
+func (s *InvoiceService) VoidInvoice(id int, reason string) error {
+	return s.invoiceRepo.VoidInvoice(id, reason)
+}
+
+// MarkAsPaid records a payment of an invoice's balance
 func (s *InvoiceService) MarkAsPaid(id int, paymentMethod, paymentReference string) error {
-	now := time.Now()
-	updates := &models.InvoiceUpdateRequest{
-		Status:           models.InvoiceStatusPaid,
-		PaymentMethod:    paymentMethod,
-		PaymentDate:      &now,
-		PaymentReference: paymentReference,
+	_, err := s.RecordPayment(id, &models.PaymentRequest{
+		Method:    paymentMethod,
+		Reference: paymentReference,
+	}, nil)
+	return err
+}
+
+// RecordPayment records a payment of an invoice by the given user, or by the
+// system when recordedBy is nil
+func (s *InvoiceService) RecordPayment(id int, request *models.PaymentRequest, recordedBy *int) (*models.Payment, error) {
+	paidAt := time.Now()
+	if request.PaidAt != nil {
+		paidAt = *request.PaidAt
+	}
+	payment := &models.Payment{
+		InvoiceID:  id,
+		Amount:     request.Amount,
+		Method:     request.Method,
+		Reference:  request.Reference,
+		PaidAt:     paidAt,
+		Notes:      request.Notes,
+		RecordedBy: recordedBy,
+	}
+	if err := s.paymentRepo.RecordPayment(payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// GetPayments lists the payments of an invoice
+func (s *InvoiceService) GetPayments(id int) ([]models.Payment, error) {
+	invoice, err := s.invoiceRepo.GetInvoiceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return invoice.Payments, nil
+}
+
+// RecordRefund records money given back from a payment
+func (s *InvoiceService) RecordRefund(paymentID int, request *models.RefundRequest, recordedBy *int) (*models.Refund, error) {
+	refundedAt := time.Now()
+	if request.RefundedAt != nil {
+		refundedAt = *request.RefundedAt
+	}
+	refund := &models.Refund{
+		PaymentID:  paymentID,
+		Amount:     request.Amount,
+		Method:     request.Method,
+		Reference:  request.Reference,
+		Reason:     request.Reason,
+		RefundedAt: refundedAt,
+		RecordedBy: recordedBy,
+	}
+	if err := s.paymentRepo.RecordRefund(refund); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// CreateCreditNote issues a credit note for an invoice
+func (s *InvoiceService) CreateCreditNote(invoiceID int, request *models.CreditNoteRequest, createdBy *int) (*models.CreditNote, error) {
+	note := &models.CreditNote{
+		InvoiceID: invoiceID,
+		Reason:    request.Reason,
+		CreatedBy: createdBy,
 	}
-	return s.invoiceRepo.UpdateInvoice(id, updates)
+	if !request.Full {
+		for _, item := range request.Items {
+			note.Items = append(note.Items, models.CreditNoteItem{
+				Description: item.Description,
+				Quantity:    item.Quantity,
+				UnitPrice:   item.UnitPrice,
+				TotalPrice:  math.Round(item.Quantity*item.UnitPrice*100) / 100,
+				Taxable:     item.Taxable,
+			})
+		}
+	}
+
+	if err := s.creditNoteRepo.CreateCreditNote(note, request.Full); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// GetCreditNote returns a credit note with its items
+func (s *InvoiceService) GetCreditNote(id int) (*models.CreditNote, error) {
+	return s.creditNoteRepo.GetCreditNoteByID(id)
 }
 
-// DeleteInvoice deletes an invoice
-func (s *InvoiceService) DeleteInvoice(id int) error {
-	return s.invoiceRepo.DeleteInvoice(id)
+// ArchiveInvoice takes an invoice out of the lists. The record is kept and
+// can be restored.
+func (s *InvoiceService) ArchiveInvoice(id int) error {
+	return s.invoiceRepo.SetArchived(id, true)
+}
+
+// RestoreInvoice brings an archived invoice back
+func (s *InvoiceService) RestoreInvoice(id int) error {
+	return s.invoiceRepo.SetArchived(id, false)
 }
 
 // GetInvoicesByDateRange retrieves invoices within a date range
@@ -185,30 +399,164 @@ func (s *InvoiceService) GetInvoicesByDateRange(startDate, endDate time.Time) ([
 	return filtered, nil
 }
 
-// Helper functions
+// SettleCancelledBooking settles the open invoice of a booking that won't take
+// place, so it isn't chased as overdue. An invoice nothing was paid or
+// credited on is voided; one partly paid is credited in full, leaving what
+// was paid owed back to the customer. Paid invoices are left alone.
+func (s *InvoiceService) SettleCancelledBooking(bookingID int, reason string) error {
+	invoice, err := s.invoiceRepo.GetInvoiceByBookingID(bookingID)
+	if err != nil {
+		if err.Error() == "invoice not found for booking" {
+			return nil
+		}
+		return err
+	}
 
-func getCustomerName(booking *models.Booking) string {
-	if booking.IsGuestBooking {
-		return booking.GuestName
+	switch invoice.Status {
+	case models.InvoiceStatusPending, models.InvoiceStatusPartiallyPaid, models.InvoiceStatusOverdue:
+	default:
+		return nil
 	}
-	// For registered users, you'd get from user table
-	return "Registered Customer"
+
+	if invoice.AmountPaid == 0 && invoice.AmountCredited == 0 {
+		return s.invoiceRepo.VoidInvoice(invoice.ID, reason)
+	}
+	note := &models.CreditNote{InvoiceID: invoice.ID, Reason: reason}
+	return s.creditNoteRepo.CreateCreditNote(note, true)
 }
 
-func getCustomerEmail(booking *models.Booking) string {
-	if booking.IsGuestBooking {
-		return booking.GuestEmail
+// BillFee bills a fee for a booking that won't take place on a new invoice.
+// The booking's own invoice was settled when it was cancelled; see
+// SettleCancelledBooking.
+func (s *InvoiceService) BillFee(booking *models.Booking, description string, amount float64) (*int, error) {
+	item := models.InvoiceItem{
+		Description: description,
+		Quantity:    1,
+		UnitPrice:   amount,
+		TotalPrice:  amount,
+		Taxable:     false, // fees are not a taxable service
 	}
-	// For registered users, you'd get from user table
-	return "customer@email.com"
+
+	serviceCity, serviceState, serviceZip := s.properties.serviceLocation(booking.PropertyID, booking.Address)
+	customer := s.billTo(booking)
+	billingAddress, billingCity, billingState, billingZip, billingCountry := billingAddressOf(booking, customer, serviceCity, serviceState, serviceZip)
+
+	invoice := &models.Invoice{
+		BookingID:      booking.ID,
+		Kind:           models.InvoiceKindFee,
+		IssueDate:      time.Now(),
+		DueDate:        time.Now().AddDate(0, 0, models.DefaultDueDays),
+		CustomerName:   customer.Name,
+		CustomerEmail:  customer.Email,
+		CustomerPhone:  customer.Phone,
+		BillingAddress: billingAddress,
+		BillingCity:    billingCity,
+		BillingState:   billingState,
+		BillingZipCode: billingZip,
+		BillingCountry: getDefaultCountry(billingCountry),
+		ServiceAddress: booking.Address,
+		ServiceCity:    serviceCity,
+		ServiceState:   serviceState,
+		ServiceZipCode: serviceZip,
+		Subtotal:       amount,
+		TotalAmount:    amount,
+		Status:         models.InvoiceStatusPending,
+		FloridaTaxID:   s.taxID,
+		Notes:          description,
+		Terms:          getDefaultTerms(),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := s.invoiceRepo.CreateInvoice(invoice, []models.InvoiceItem{item}); err != nil {
+		return nil, fmt.Errorf("failed to create invoice: %v", err)
+	}
+	// The fee is billed either way; reporting a failure would invite billing
+	// it again
+	if err := s.invoiceRepo.LinkBooking(booking.ID, invoice.ID); err != nil {
+		log.Printf("Failed to link fee invoice %s to booking %d: %v", invoice.InvoiceNumber, booking.ID, err)
+	}
+
+	return &invoice.ID, nil
+}
+
+// MarkOverdue moves invoices still owed after their due date to overdue and
+// returns how many it moved
+func (s *InvoiceService) MarkOverdue() (int64, error) {
+	return s.invoiceRepo.MarkOverdue()
 }
 
-func getCustomerPhone(booking *models.Booking) string {
-	if booking.IsGuestBooking {
-		return booking.GuestPhone
+// BillLateFees bills every overdue invoice a late fee of rate (a fraction) of
+// what it owes for each full month past due not billed yet, and returns the
+// fee invoices issued. Months already billed are never billed again.
+func (s *InvoiceService) BillLateFees(rate float64, today time.Time) ([]models.Invoice, error) {
+	candidates, err := s.lateFeeRepo.GetCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	billed := []models.Invoice{}
+	for _, candidate := range candidates {
+		if repositories.MonthsPastDue(candidate.DueDate, today) <= candidate.BilledMonths {
+			continue
+		}
+		invoice, err := s.lateFeeRepo.BillLateFees(candidate.InvoiceID, rate, today)
+		if err != nil {
+			return billed, fmt.Errorf("failed to bill late fees on invoice %d: %v", candidate.InvoiceID, err)
+		}
+		if invoice != nil {
+			billed = append(billed, *invoice)
+		}
+	}
+	return billed, nil
+}
+
+// Helper functions
+
+// billTo returns who a booking's invoice is addressed to: its customer record,
+// with any gaps filled from the client account or the guest details
+func (s *InvoiceService) billTo(booking *models.Booking) models.Customer {
+	var customer models.Customer
+	if booking.CustomerID != nil {
+		if found, err := s.customerRepo.GetCustomerByID(*booking.CustomerID); err == nil {
+			customer = *found
+		}
 	}
-	// For registered users, you'd get from user table
-	return ""
+
+	name, email, phone := booking.GuestName, booking.GuestEmail, booking.GuestPhone
+	if booking.UserID != nil {
+		if user, err := s.userRepo.GetUserByID(*booking.UserID); err == nil {
+			name, email, phone = strings.TrimSpace(user.FirstName+" "+user.LastName), user.Email, user.Phone
+		}
+	}
+	if customer.Name == "" {
+		customer.Name = name
+	}
+	if customer.Email == "" {
+		customer.Email = email
+	}
+	if customer.Phone == "" {
+		customer.Phone = phone
+	}
+	return customer
+}
+
+// billingAddressOf picks where a booking is billed: the booking's own billing
+// address, else the customer's billing profile, else the service address
+func billingAddressOf(booking *models.Booking, customer models.Customer, serviceCity, serviceState, serviceZip string) (address, city, state, zip, country string) {
+	if booking.BillingAddress != "" {
+		return booking.BillingAddress, booking.BillingCity, booking.BillingState, booking.BillingZipCode, booking.BillingCountry
+	}
+	if customer.BillingAddress != "" {
+		return customer.BillingAddress, customer.BillingCity, customer.BillingState, customer.BillingZipCode, customer.BillingCountry
+	}
+	return booking.Address, serviceCity, serviceState, serviceZip, ""
+}
+
+// ownsCustomer tells whether a booking was made by the account that owns the
+// customer it is billed to
+func ownsCustomer(booking *models.Booking, customer models.Customer) bool {
+	return booking.UserID != nil && customer.UserID != nil && *booking.UserID == *customer.UserID
 }
 
 func getDefaultCountry(country string) string {
@@ -218,8 +566,9 @@ func getDefaultCountry(country string) string {
 	return country
 }
 
+// parseAddress guesses the city, state and zip of a one-line address. It is
+// only used for bookings without a structured property, such as old ones.
 func parseAddress(address string) (city, state, zip string) {
-	// Simple address parsing - in production you'd use a proper address parser
 	parts := strings.Split(address, ",")
 	if len(parts) >= 2 {
 		city = strings.TrimSpace(parts[len(parts)-2])
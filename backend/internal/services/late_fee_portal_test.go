@@ -0,0 +1,177 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/database"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+
+	_ "github.com/lib/pq"
+)
+
+// openTestDatabase connects to the database named by TEST_DATABASE_URL, which
+// must have every migration applied. Issued invoices can't be deleted, so use
+// a throwaway database: the records a test creates are left behind.
+func openTestDatabase(t *testing.T) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping database: %v", err)
+	}
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		db.Close()
+	})
+}
+
+// createOverdueGuestInvoice stores a completed guest booking and its own
+// invoice of 100.00, issued three months ago and 70 days past due
+func createOverdueGuestInvoice(t *testing.T, email string) (*models.Booking, *models.Invoice) {
+	t.Helper()
+
+	var bookingID int
+	err := database.DB.QueryRow(
+		`INSERT INTO bookings (service_id, guest_name, guest_email, guest_phone, is_guest_booking,
+		                       scheduled_date, scheduled_time, address, square_meters, total_price, duration_hours, status)
+		 VALUES ((SELECT id FROM services ORDER BY id LIMIT 1), 'Late Payer', $1, '555-0100', true,
+		         $2, '09:00', '1 Test St, Miami, FL 33101', 50, 100, 2, 'completed')
+		 RETURNING id`,
+		email, time.Now().AddDate(0, -3, 0),
+	).Scan(&bookingID)
+	if err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	booking, err := (&repositories.BookingRepository{}).GetBookingByID(bookingID)
+	if err != nil {
+		t.Fatalf("get booking: %v", err)
+	}
+
+	issued := time.Now().AddDate(0, -3, 0)
+	invoice := &models.Invoice{
+		BookingID:      bookingID,
+		IssueDate:      issued,
+		DueDate:        time.Now().AddDate(0, 0, -70),
+		CustomerName:   "Late Payer",
+		CustomerEmail:  email,
+		CustomerPhone:  "555-0100",
+		BillingAddress: "1 Test St",
+		BillingCity:    "Miami",
+		BillingState:   "FL",
+		BillingZipCode: "33101",
+		BillingCountry: "United States",
+		ServiceAddress: "1 Test St",
+		ServiceCity:    "Miami",
+		ServiceState:   "FL",
+		ServiceZipCode: "33101",
+		Subtotal:       100,
+		TotalAmount:    100,
+		Status:         models.InvoiceStatusPending,
+		FloridaTaxID:   "92-396658",
+		Terms:          getDefaultTerms(),
+		CreatedAt:      issued,
+		UpdatedAt:      issued,
+	}
+	items := []models.InvoiceItem{{Description: "Cleaning", Quantity: 1, UnitPrice: 100, TotalPrice: 100, Taxable: false}}
+	if err := repositories.NewInvoiceRepository(database.DB).CreateInvoice(invoice, items); err != nil {
+		t.Fatalf("create invoice: %v", err)
+	}
+	return booking, invoice
+}
+
+func TestLateFeesArePayableThroughGuestPortal(t *testing.T) {
+	openTestDatabase(t)
+
+	email := fmt.Sprintf("late-payer-%d@example.com", time.Now().UnixNano())
+	booking, original := createOverdueGuestInvoice(t, email)
+
+	invoiceRepo := repositories.NewInvoiceRepository(database.DB)
+	invoiceService := NewInvoiceService(invoiceRepo, &repositories.BookingRepository{})
+	if _, err := invoiceService.MarkOverdue(); err != nil {
+		t.Fatalf("MarkOverdue() error = %v", err)
+	}
+	lateFee, err := (&repositories.LateFeeRepository{}).BillLateFees(original.ID, 0.015, time.Now())
+	if err != nil {
+		t.Fatalf("BillLateFees() error = %v", err)
+	}
+	if lateFee == nil {
+		t.Fatal("BillLateFees() billed nothing on an invoice 70 days past due")
+	}
+	if lateFee.Kind != models.InvoiceKindLateFee || lateFee.ParentInvoiceID == nil || *lateFee.ParentInvoiceID != original.ID {
+		t.Fatalf("late fee invoice kind %q, parent %v; want late_fee of invoice %d", lateFee.Kind, lateFee.ParentInvoiceID, original.ID)
+	}
+	if lateFee.TotalAmount != 3 {
+		t.Errorf("late fees for two months at 1.5%% of 100.00 = %.2f, want 3.00", lateFee.TotalAmount)
+	}
+
+	overdue, err := invoiceRepo.GetInvoiceByID(original.ID)
+	if err != nil {
+		t.Fatalf("GetInvoiceByID() error = %v", err)
+	}
+	if len(overdue.LateFeeInvoices) != 1 || overdue.LateFeeInvoices[0].ID != lateFee.ID {
+		t.Errorf("overdue invoice lists late fee invoices %+v, want invoice %d", overdue.LateFeeInvoices, lateFee.ID)
+	}
+
+	portal := NewGuestPortalService(config.Config{JWTSecret: "test-secret", GuestLinkExpiry: "1h", PaymentGateway: "test"})
+	token, err := portal.IssueToken(booking.ID, email)
+	if err != nil {
+		t.Fatalf("IssueToken() error = %v", err)
+	}
+	guestBooking, err := portal.Authenticate(token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	// The booking's own invoice stays the one the portal shows by default
+	own, err := portal.GetInvoice(guestBooking)
+	if err != nil {
+		t.Fatalf("GetInvoice() error = %v", err)
+	}
+	if own.ID != original.ID {
+		t.Errorf("GetInvoice() = invoice %d, want the booking's own invoice %d", own.ID, original.ID)
+	}
+
+	invoices, err := portal.GetInvoices(guestBooking)
+	if err != nil {
+		t.Fatalf("GetInvoices() error = %v", err)
+	}
+	if len(invoices) != 2 || invoices[0].ID != original.ID || invoices[1].ID != lateFee.ID {
+		t.Fatalf("GetInvoices() returned %d invoices, want the overdue invoice and its late fees", len(invoices))
+	}
+
+	// Pay the overdue invoice, then the late fees, which must still be reachable
+	for _, id := range []int{original.ID, lateFee.ID} {
+		invoice, err := portal.GetBookingInvoice(guestBooking, id)
+		if err != nil {
+			t.Fatalf("GetBookingInvoice(%d) error = %v", id, err)
+		}
+		paid, err := portal.PayInvoice(guestBooking, invoice, "tok_test")
+		if err != nil {
+			t.Fatalf("PayInvoice(%d) error = %v", id, err)
+		}
+		if paid.Status != models.InvoiceStatusPaid || paid.BalanceDue != 0 {
+			t.Errorf("invoice %d after payment: status %q, balance %.2f; want paid, 0.00", id, paid.Status, paid.BalanceDue)
+		}
+	}
+
+	// An invoice of another booking can't be reached through this link
+	_, other := createOverdueGuestInvoice(t, email)
+	if _, err := portal.GetBookingInvoice(guestBooking, other.ID); err == nil || err.Error() != "invoice not found" {
+		t.Errorf("GetBookingInvoice() of another booking's invoice error = %v, want invoice not found", err)
+	}
+}
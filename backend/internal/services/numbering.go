@@ -0,0 +1,54 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// NumberingService configures how documents are numbered. The numbers
+// themselves are taken by repositories.NextDocumentNumber when a document is
+// stored.
+type NumberingService struct {
+	repo *repositories.NumberingRepository
+}
+
+func NewNumberingService() *NumberingService {
+	return &NumberingService{repo: &repositories.NumberingRepository{}}
+}
+
+func (s *NumberingService) GetSchemes() ([]models.NumberingScheme, error) {
+	schemes, err := s.repo.GetSchemes()
+	if err != nil {
+		return nil, err
+	}
+	for i := range schemes {
+		schemes[i].Example = schemes[i].Number(time.Now(), 1)
+	}
+	return schemes, nil
+}
+
+// UpdateScheme changes the format or reset of a document type's numbers.
+// Numbers already issued keep theirs.
+func (s *NumberingService) UpdateScheme(documentType string, req *models.NumberingSchemeRequest) (*models.NumberingScheme, error) {
+	scheme := &models.NumberingScheme{
+		DocumentType: documentType,
+		Format:       req.Format,
+		Reset:        req.Reset,
+	}
+	if err := scheme.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveScheme(scheme); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("numbering scheme not found")
+		}
+		return nil, errors.New("failed to save numbering scheme")
+	}
+	scheme.Example = scheme.Number(time.Now(), 1)
+	return scheme, nil
+}
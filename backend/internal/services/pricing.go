@@ -0,0 +1,308 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// Discount per frequency, in percent of the regular price
+var frequencyDiscounts = map[string]float64{
+	models.FrequencyWeekly:   15,
+	models.FrequencyBiweekly: 10,
+	models.FrequencyMonthly:  5,
+}
+
+// Price multiplier per job condition
+var conditionMultipliers = map[string]float64{
+	models.ConditionStandard: 1,
+	models.ConditionHeavy:    1.25,
+	models.ConditionExtreme:  1.5,
+}
+
+// PricingService prices every job, whether booked, recurring or quoted, so
+// the same job costs the same wherever it is priced
+type PricingService struct {
+	repo        *repositories.PricingRepository
+	serviceRepo *repositories.ServiceRepository
+}
+
+func NewPricingService() *PricingService {
+	return &PricingService{
+		repo:        &repositories.PricingRepository{},
+		serviceRepo: &repositories.ServiceRepository{},
+	}
+}
+
+// Price itemizes the price of a job
+func (s *PricingService) Price(input models.PriceInput) (*models.PriceBreakdown, error) {
+	service, err := s.serviceRepo.GetServiceByID(input.ServiceID)
+	if err != nil {
+		return nil, errors.New("service not found")
+	}
+
+	rules, err := s.repo.GetServicePricing(service.ID)
+	if err != nil {
+		return nil, err
+	}
+	addOnLines, addOnHours, err := s.addOnLines(service.ID, input.AddOns)
+	if err != nil {
+		return nil, err
+	}
+
+	return priceJob(service, rules, input, addOnLines, addOnHours)
+}
+
+// priceJob works out the breakdown of a job from its service's pricing rules
+// and its priced add-ons
+func priceJob(service *models.Service, rules *models.ServicePricing, input models.PriceInput, addOnLines []models.PriceLine, addOnHours float64) (*models.PriceBreakdown, error) {
+	if input.SquareMeters <= 0 {
+		return nil, errors.New("square meters must be greater than 0")
+	}
+
+	condition := input.Condition
+	if condition == "" {
+		condition = models.ConditionStandard
+	}
+	multiplier, ok := conditionMultipliers[condition]
+	if !ok {
+		return nil, errors.New("condition must be standard, heavy or extreme")
+	}
+	discountPercent := 0.0
+	if input.Frequency != "" {
+		if discountPercent, ok = frequencyDiscounts[input.Frequency]; !ok {
+			return nil, errors.New("frequency must be weekly, biweekly or monthly")
+		}
+	}
+
+	breakdown := &models.PriceBreakdown{
+		Lines:               []models.PriceLine{sizeLine(service, rules.SizeTiers, input.SquareMeters)},
+		Condition:           condition,
+		ConditionMultiplier: multiplier,
+		Frequency:           input.Frequency,
+		DiscountPercent:     discountPercent,
+	}
+
+	if rules.PricePerBedroom > 0 && input.Bedrooms > 0 {
+		breakdown.Lines = append(breakdown.Lines, models.PriceLine{
+			Type:        models.PriceLineBedrooms,
+			Description: "Bedrooms",
+			Quantity:    float64(input.Bedrooms),
+			UnitPrice:   rules.PricePerBedroom,
+			Amount:      roundCents(float64(input.Bedrooms) * rules.PricePerBedroom),
+		})
+	}
+	if rules.PricePerBathroom > 0 && input.Bathrooms > 0 {
+		breakdown.Lines = append(breakdown.Lines, models.PriceLine{
+			Type:        models.PriceLineBathrooms,
+			Description: "Bathrooms",
+			Quantity:    input.Bathrooms,
+			UnitPrice:   rules.PricePerBathroom,
+			Amount:      roundCents(input.Bathrooms * rules.PricePerBathroom),
+		})
+	}
+
+	breakdown.Lines = append(breakdown.Lines, addOnLines...)
+	breakdown.AddOnHours = addOnHours
+
+	for _, line := range breakdown.Lines {
+		breakdown.Subtotal += line.Amount
+	}
+	breakdown.Subtotal = roundCents(breakdown.Subtotal)
+	breakdown.ConditionAdjustment = roundCents(breakdown.Subtotal * (multiplier - 1))
+	price := breakdown.Subtotal + breakdown.ConditionAdjustment
+
+	breakdown.MinimumCharge = service.BasePrice
+	if rules.MinimumCharge != nil {
+		breakdown.MinimumCharge = *rules.MinimumCharge
+	}
+	if price < breakdown.MinimumCharge {
+		breakdown.MinimumAdjustment = roundCents(breakdown.MinimumCharge - price)
+		price = breakdown.MinimumCharge
+	}
+
+	breakdown.Discount = roundCents(price * discountPercent / 100)
+	breakdown.Total = roundCents(price - breakdown.Discount)
+
+	return breakdown, nil
+}
+
+// sizeLine charges the size of the job: by tier when the service has tiers,
+// otherwise at the base price per 50 square meters
+func sizeLine(service *models.Service, tiers []models.SizeTier, squareMeters float64) models.PriceLine {
+	line := models.PriceLine{
+		Type:        models.PriceLineSize,
+		Description: fmt.Sprintf("%s, %g m²", service.Name, squareMeters),
+		Quantity:    1,
+	}
+
+	if len(tiers) == 0 {
+		line.UnitPrice = roundCents(service.BasePrice * squareMeters / 50)
+	} else {
+		largest := tiers[len(tiers)-1]
+		line.UnitPrice = roundCents(largest.Price * squareMeters / largest.UpToSquareMeters)
+		for _, tier := range tiers {
+			if squareMeters <= tier.UpToSquareMeters {
+				line.UnitPrice = tier.Price
+				break
+			}
+		}
+	}
+
+	line.Amount = line.UnitPrice
+	return line
+}
+
+// addOnLines prices the requested add-ons and adds up the time they take.
+// Add-ons that are unknown, inactive or not allowed with the service are an
+// error rather than silently left out.
+func (s *PricingService) addOnLines(serviceID int, codes []string) ([]models.PriceLine, float64, error) {
+	codes = uniqueCodes(codes)
+	if len(codes) == 0 {
+		return nil, 0, nil
+	}
+
+	addOns, err := s.repo.GetServiceAddOnsByCode(serviceID, codes)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(addOns) != len(codes) {
+		found := map[string]bool{}
+		for _, addOn := range addOns {
+			found[addOn.Code] = true
+		}
+		for _, code := range codes {
+			if !found[code] {
+				return nil, 0, fmt.Errorf("add-on not available for this service: %s", code)
+			}
+		}
+	}
+
+	hours := 0.0
+	lines := make([]models.PriceLine, len(addOns))
+	for i, addOn := range addOns {
+		hours += addOn.DurationHours
+		lines[i] = models.PriceLine{
+			Type:        models.PriceLineAddOn,
+			Code:        addOn.Code,
+			Description: addOn.Name,
+			Quantity:    1,
+			UnitPrice:   addOn.Price,
+			Amount:      addOn.Price,
+		}
+	}
+	return lines, hours, nil
+}
+
+func uniqueCodes(codes []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, code := range codes {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		unique = append(unique, code)
+	}
+	return unique
+}
+
+func (s *PricingService) GetServicePricing(serviceID int) (*models.ServicePricing, error) {
+	if _, err := s.serviceRepo.GetServiceByID(serviceID); err != nil {
+		return nil, errors.New("service not found")
+	}
+	return s.repo.GetServicePricing(serviceID)
+}
+
+func (s *PricingService) UpdateServicePricing(serviceID int, req *models.ServicePricingRequest) (*models.ServicePricing, error) {
+	if _, err := s.serviceRepo.GetServiceByID(serviceID); err != nil {
+		return nil, errors.New("service not found")
+	}
+
+	tiers := append([]models.SizeTier{}, req.SizeTiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].UpToSquareMeters < tiers[j].UpToSquareMeters })
+	for i := 1; i < len(tiers); i++ {
+		if tiers[i].UpToSquareMeters == tiers[i-1].UpToSquareMeters {
+			return nil, errors.New("size tiers must have different sizes")
+		}
+	}
+
+	pricing := &models.ServicePricing{
+		ServiceID:        serviceID,
+		PricePerBedroom:  req.PricePerBedroom,
+		PricePerBathroom: req.PricePerBathroom,
+		MinimumCharge:    req.MinimumCharge,
+		SizeTiers:        tiers,
+	}
+	if err := s.repo.SaveServicePricing(pricing); err != nil {
+		return nil, errors.New("failed to save pricing")
+	}
+	return pricing, nil
+}
+
+func (s *PricingService) GetAddOns(activeOnly bool) ([]models.AddOn, error) {
+	return s.repo.GetAddOns(activeOnly)
+}
+
+func (s *PricingService) CreateAddOn(req *models.AddOnRequest) (*models.AddOn, error) {
+	addOn := &models.AddOn{Active: true}
+	if err := s.applyAddOnRequest(addOn, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateAddOn(addOn); err != nil {
+		return nil, errors.New("failed to create add-on")
+	}
+	return addOn, nil
+}
+
+func (s *PricingService) UpdateAddOn(id int, req *models.AddOnRequest) (*models.AddOn, error) {
+	addOn, err := s.repo.GetAddOn(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("add-on not found")
+		}
+		return nil, err
+	}
+	if err := s.applyAddOnRequest(addOn, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateAddOn(addOn); err != nil {
+		return nil, errors.New("failed to update add-on")
+	}
+	return addOn, nil
+}
+
+// applyAddOnRequest copies the request onto an add-on. Codes are what
+// bookings refer to add-ons by, so they must stay unique.
+func (s *PricingService) applyAddOnRequest(addOn *models.AddOn, req *models.AddOnRequest) error {
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	existing, err := s.repo.GetAddOns(false)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.Code == code && other.ID != addOn.ID {
+			return errors.New("add-on code already in use")
+		}
+	}
+
+	addOn.Code = code
+	addOn.Name = req.Name
+	addOn.Price = req.Price
+	addOn.DurationHours = req.DurationHours
+	if req.Active != nil {
+		addOn.Active = *req.Active
+	}
+	return nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
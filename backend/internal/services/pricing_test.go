@@ -0,0 +1,175 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"cleaning-app-backend/internal/models"
+)
+
+var testService = &models.Service{ID: 1, Name: "Standard Cleaning", BasePrice: 100}
+
+var testTiers = []models.SizeTier{
+	{UpToSquareMeters: 50, Price: 80},
+	{UpToSquareMeters: 100, Price: 140},
+}
+
+func TestSizeLine(t *testing.T) {
+	tests := []struct {
+		name         string
+		tiers        []models.SizeTier
+		squareMeters float64
+		want         float64
+	}{
+		{"base price per 50 m² without tiers", nil, 75, 150},
+		{"smallest tier", testTiers, 30, 80},
+		{"tier upper bound is inclusive", testTiers, 50, 80},
+		{"next tier", testTiers, 51, 140},
+		{"largest tier", testTiers, 100, 140},
+		{"largest tier extrapolated pro rata", testTiers, 150, 210},
+		{"extrapolation rounded to cents", testTiers, 101, 141.4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := sizeLine(testService, tt.tiers, tt.squareMeters)
+			if line.UnitPrice != tt.want || line.Amount != tt.want {
+				t.Errorf("sizeLine(%g m²) = %.2f (amount %.2f), want %.2f", tt.squareMeters, line.UnitPrice, line.Amount, tt.want)
+			}
+			if line.Type != models.PriceLineSize || line.Quantity != 1 {
+				t.Errorf("sizeLine(%g m²) = %+v, want one size line", tt.squareMeters, line)
+			}
+		})
+	}
+}
+
+func TestPriceJob(t *testing.T) {
+	minimum := func(amount float64) *float64 { return &amount }
+
+	tests := []struct {
+		name      string
+		rules     models.ServicePricing
+		input     models.PriceInput
+		addOns    []models.PriceLine
+		subtotal  float64
+		condition float64
+		minimum   float64
+		discount  float64
+		total     float64
+	}{
+		{
+			name:     "size, bedrooms, bathrooms and add-ons add up",
+			rules:    models.ServicePricing{PricePerBedroom: 10, PricePerBathroom: 15, SizeTiers: testTiers},
+			input:    models.PriceInput{SquareMeters: 80, Bedrooms: 2, Bathrooms: 1.5},
+			addOns:   []models.PriceLine{{Type: models.PriceLineAddOn, Code: "fridge", Quantity: 1, UnitPrice: 25, Amount: 25}},
+			subtotal: 207.5,
+			total:    207.5,
+		},
+		{
+			name:      "condition multiplies the subtotal",
+			rules:     models.ServicePricing{SizeTiers: testTiers},
+			input:     models.PriceInput{SquareMeters: 80, Condition: models.ConditionHeavy},
+			subtotal:  140,
+			condition: 35,
+			total:     175,
+		},
+		{
+			name:     "base price is the minimum charge",
+			input:    models.PriceInput{SquareMeters: 20},
+			subtotal: 40,
+			minimum:  60,
+			total:    100,
+		},
+		{
+			name:     "minimum charge of the rules",
+			rules:    models.ServicePricing{MinimumCharge: minimum(120), SizeTiers: testTiers},
+			input:    models.PriceInput{SquareMeters: 30},
+			subtotal: 80,
+			minimum:  40,
+			total:    120,
+		},
+		{
+			name:      "minimum applies after the condition",
+			rules:     models.ServicePricing{MinimumCharge: minimum(90), SizeTiers: testTiers},
+			input:     models.PriceInput{SquareMeters: 30, Condition: models.ConditionExtreme},
+			subtotal:  80,
+			condition: 40,
+			total:     120,
+		},
+		{
+			name:     "frequency discount comes off the minimum charge",
+			rules:    models.ServicePricing{MinimumCharge: minimum(120), SizeTiers: testTiers},
+			input:    models.PriceInput{SquareMeters: 30, Frequency: models.FrequencyWeekly},
+			subtotal: 80,
+			minimum:  40,
+			discount: 18,
+			total:    102,
+		},
+		{
+			name:      "frequency discount comes off the adjusted price",
+			rules:     models.ServicePricing{SizeTiers: testTiers},
+			input:     models.PriceInput{SquareMeters: 150, Condition: models.ConditionHeavy, Frequency: models.FrequencyBiweekly},
+			subtotal:  210,
+			condition: 52.5,
+			discount:  26.25,
+			total:     236.25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := tt.rules
+			breakdown, err := priceJob(testService, &rules, tt.input, tt.addOns, 0)
+			if err != nil {
+				t.Fatalf("priceJob() error = %v", err)
+			}
+			got := []float64{breakdown.Subtotal, breakdown.ConditionAdjustment, breakdown.MinimumAdjustment, breakdown.Discount, breakdown.Total}
+			want := []float64{tt.subtotal, tt.condition, tt.minimum, tt.discount, tt.total}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("priceJob() subtotal, condition, minimum, discount, total = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPriceJobRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input models.PriceInput
+		want  string
+	}{
+		{"no size", models.PriceInput{}, "square meters must be greater than 0"},
+		{"unknown condition", models.PriceInput{SquareMeters: 50, Condition: "filthy"}, "condition must be standard, heavy or extreme"},
+		{"unknown frequency", models.PriceInput{SquareMeters: 50, Frequency: "daily"}, "frequency must be weekly, biweekly or monthly"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := priceJob(testService, &models.ServicePricing{}, tt.input, nil, 0)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("priceJob() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		codes []string
+		want  []string
+	}{
+		{"nil", nil, []string{}},
+		{"normalized", []string{" Fridge ", "OVEN"}, []string{"fridge", "oven"}},
+		{"duplicates dropped in order", []string{"oven", "fridge", "Oven", "fridge "}, []string{"oven", "fridge"}},
+		{"blanks dropped", []string{"", "  ", "windows"}, []string{"windows"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uniqueCodes(tt.codes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("uniqueCodes(%q) = %q, want %q", tt.codes, got, tt.want)
+			}
+		})
+	}
+}
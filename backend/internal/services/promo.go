@@ -0,0 +1,264 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// PromoService manages promo codes and takes them off the price of bookings,
+// quotes and estimates
+type PromoService struct {
+	repo *repositories.PromoRepository
+}
+
+func NewPromoService() *PromoService {
+	return &PromoService{
+		repo: &repositories.PromoRepository{},
+	}
+}
+
+// Apply checks a promo code against a priced job and takes its discount off
+// the breakdown. An empty code applies nothing. The customer's own limits are
+// only checked when the customer is known; bookings check them again when
+// the code is redeemed.
+func (s *PromoService) Apply(code string, serviceID int, customerID *int, breakdown *models.PriceBreakdown) (*models.PromoCode, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, nil
+	}
+
+	promo, err := s.repo.GetPromoCodeByCode(code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("promo code is invalid")
+		}
+		return nil, err
+	}
+	if !promo.Active {
+		return nil, errors.New("promo code is invalid")
+	}
+
+	today := time.Now().Format("2006-01-02")
+	if promo.StartsOn != nil && today < promo.StartsOn.Format("2006-01-02") {
+		return nil, errors.New("promo code is not active yet")
+	}
+	if promo.EndsOn != nil && today > promo.EndsOn.Format("2006-01-02") {
+		return nil, errors.New("promo code has expired")
+	}
+	if !promoCoversService(promo, serviceID) {
+		return nil, errors.New("promo code does not apply to this service")
+	}
+	if breakdown.Total < promo.MinOrder {
+		return nil, fmt.Errorf("promo code requires a minimum order of $%.2f", promo.MinOrder)
+	}
+	if promo.MaxUses != nil && promo.Uses >= *promo.MaxUses {
+		return nil, errors.New("promo code usage limit reached")
+	}
+	if customerID != nil {
+		if err := s.checkCustomer(promo, customerID); err != nil {
+			return nil, err
+		}
+	}
+
+	discount := promo.DiscountValue
+	if promo.DiscountType == models.PromoPercent {
+		discount = roundCents(breakdown.Total * promo.DiscountValue / 100)
+	}
+	if discount > breakdown.Total {
+		discount = breakdown.Total
+	}
+	breakdown.PromoCode = promo.Code
+	breakdown.PromoDiscount = discount
+	breakdown.Total = roundCents(breakdown.Total - discount)
+
+	return promo, nil
+}
+
+func promoCoversService(promo *models.PromoCode, serviceID int) bool {
+	if len(promo.ServiceIDs) == 0 {
+		return true
+	}
+	for _, id := range promo.ServiceIDs {
+		if int(id) == serviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCustomer checks the limits a code puts on each customer. Codes with
+// such limits can't be redeemed by a customer we couldn't identify.
+func (s *PromoService) checkCustomer(promo *models.PromoCode, customerID *int) error {
+	if promo.MaxUsesPerCustomer == nil && !promo.FirstBookingOnly {
+		return nil
+	}
+	if customerID == nil {
+		return errors.New("promo code is not valid for this customer")
+	}
+
+	if promo.MaxUsesPerCustomer != nil {
+		uses, err := s.repo.CountCustomerUses(promo.ID, *customerID)
+		if err != nil {
+			return err
+		}
+		if uses >= *promo.MaxUsesPerCustomer {
+			return errors.New("promo code already used")
+		}
+	}
+	if promo.FirstBookingOnly {
+		bookings, err := s.repo.CountCustomerBookings(*customerID)
+		if err != nil {
+			return err
+		}
+		if bookings > 0 {
+			return errors.New("promo code is only valid on a first booking")
+		}
+	}
+	return nil
+}
+
+// redeem creates a booking made with a promo code and records the use. The
+// code's limits are checked again under its lock, so two bookings can't both
+// take its last use.
+func (s *PromoService) redeem(promo *models.PromoCode, booking *models.Booking, create func() error) error {
+	if promo == nil {
+		return create()
+	}
+
+	unlock, err := s.repo.LockPromoCode(promo.ID)
+	if err != nil {
+		return errors.New("failed to create booking")
+	}
+	defer unlock()
+
+	current, err := s.repo.GetPromoCode(promo.ID)
+	if err != nil {
+		return err
+	}
+	if current.MaxUses != nil && current.Uses >= *current.MaxUses {
+		return errors.New("promo code usage limit reached")
+	}
+	if err := s.checkCustomer(current, booking.CustomerID); err != nil {
+		return err
+	}
+
+	if err := create(); err != nil {
+		return err
+	}
+
+	redemption := &models.PromoRedemption{
+		PromoCodeID: promo.ID,
+		BookingID:   booking.ID,
+		CustomerID:  booking.CustomerID,
+		Discount:    booking.PriceBreakdown.PromoDiscount,
+	}
+	if err := s.repo.CreateRedemption(redemption); err != nil {
+		log.Printf("Failed to record promo code %s on booking %d: %v", promo.Code, booking.ID, err)
+	}
+	return nil
+}
+
+func (s *PromoService) GetPromoCodes() ([]models.PromoCode, error) {
+	return s.repo.GetPromoCodes()
+}
+
+// GetPromoCode returns a promo code with the bookings it was used on
+func (s *PromoService) GetPromoCode(id int) (*models.PromoCodeDetail, error) {
+	promo, err := s.repo.GetPromoCode(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("promo code not found")
+		}
+		return nil, err
+	}
+
+	redemptions, err := s.repo.GetRedemptions(id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PromoCodeDetail{PromoCode: *promo, Redemptions: redemptions}, nil
+}
+
+func (s *PromoService) CreatePromoCode(req *models.PromoCodeRequest) (*models.PromoCode, error) {
+	promo := &models.PromoCode{Active: true}
+	if err := s.applyPromoRequest(promo, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePromoCode(promo); err != nil {
+		return nil, errors.New("failed to create promo code")
+	}
+	return promo, nil
+}
+
+func (s *PromoService) UpdatePromoCode(id int, req *models.PromoCodeRequest) (*models.PromoCode, error) {
+	promo, err := s.repo.GetPromoCode(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("promo code not found")
+		}
+		return nil, err
+	}
+	if err := s.applyPromoRequest(promo, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdatePromoCode(promo); err != nil {
+		return nil, errors.New("failed to update promo code")
+	}
+	return promo, nil
+}
+
+// applyPromoRequest copies the request onto a promo code. Codes are stored in
+// upper case and must be unique whatever the case.
+func (s *PromoService) applyPromoRequest(promo *models.PromoCode, req *models.PromoCodeRequest) error {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	existing, err := s.repo.GetPromoCodeByCode(code)
+	if err == nil && existing.ID != promo.ID {
+		return errors.New("promo code already in use")
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if req.DiscountType == models.PromoPercent && req.DiscountValue > 100 {
+		return errors.New("percentage discount must be at most 100")
+	}
+
+	var startsOn, endsOn *time.Time
+	if req.StartsOn != "" {
+		date, _ := time.Parse("2006-01-02", req.StartsOn)
+		startsOn = &date
+	}
+	if req.EndsOn != "" {
+		date, _ := time.Parse("2006-01-02", req.EndsOn)
+		endsOn = &date
+	}
+	if startsOn != nil && endsOn != nil && endsOn.Before(*startsOn) {
+		return errors.New("ends_on must not be before starts_on")
+	}
+
+	promo.Code = code
+	promo.Description = req.Description
+	promo.DiscountType = req.DiscountType
+	promo.DiscountValue = req.DiscountValue
+	promo.MinOrder = req.MinOrder
+	promo.StartsOn = startsOn
+	promo.EndsOn = endsOn
+	promo.MaxUses = req.MaxUses
+	promo.MaxUsesPerCustomer = req.MaxUsesPerCustomer
+	promo.FirstBookingOnly = req.FirstBookingOnly
+	promo.ServiceIDs = req.ServiceIDs
+	if promo.ServiceIDs == nil {
+		promo.ServiceIDs = []int64{}
+	}
+	if req.Active != nil {
+		promo.Active = *req.Active
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// PropertyService manages the places a customer has cleaned. Clients pick a
+// saved property when booking instead of typing the address again; a typed
+// address is saved as a property the first time it is booked, so it can be
+// picked next time.
+type PropertyService struct {
+	repo      *repositories.PropertyRepository
+	customers *CustomerService
+}
+
+func NewPropertyService() *PropertyService {
+	return &PropertyService{
+		repo:      &repositories.PropertyRepository{},
+		customers: NewCustomerService(),
+	}
+}
+
+func (s *PropertyService) GetProperties(customerID int) ([]models.CustomerProperty, error) {
+	return s.repo.GetProperties(customerID)
+}
+
+// GetProperty returns a property of the customer
+func (s *PropertyService) GetProperty(customerID, propertyID int) (*models.CustomerProperty, error) {
+	property, err := s.repo.GetProperty(propertyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("property not found")
+		}
+		return nil, err
+	}
+	if property.CustomerID != customerID {
+		return nil, errors.New("property not found")
+	}
+	return property, nil
+}
+
+func (s *PropertyService) AddProperty(customerID int, req *models.CustomerPropertyRequest) (*models.CustomerProperty, error) {
+	if _, err := s.customers.activeCustomer(customerID); err != nil {
+		return nil, err
+	}
+
+	property := &models.CustomerProperty{CustomerID: customerID}
+	applyPropertyRequest(property, req)
+	if err := s.repo.CreateProperty(property); err != nil {
+		return nil, errors.New("failed to add property")
+	}
+	return property, nil
+}
+
+func (s *PropertyService) UpdateProperty(customerID, propertyID int, req *models.CustomerPropertyRequest) (*models.CustomerProperty, error) {
+	property, err := s.GetProperty(customerID, propertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyPropertyRequest(property, req)
+	if err := s.repo.UpdateProperty(property); err != nil {
+		return nil, errors.New("failed to update property")
+	}
+	return property, nil
+}
+
+// DeleteProperty removes a saved property. Bookings made for it keep their
+// address.
+func (s *PropertyService) DeleteProperty(customerID, propertyID int) error {
+	err := s.repo.DeleteProperty(customerID, propertyID)
+	if err == sql.ErrNoRows {
+		return errors.New("property not found")
+	}
+	return err
+}
+
+// forAddress returns the customer's property at a typed address, saving the
+// address as a new property if there is none. Like customer linking it is
+// best effort.
+func (s *PropertyService) forAddress(customerID *int, address string, squareMeters float64) *int {
+	address = strings.TrimSpace(address)
+	if customerID == nil || address == "" {
+		return nil
+	}
+
+	property, err := s.repo.FindByAddress(*customerID, address)
+	if err == nil {
+		return &property.ID
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("Failed to look up property of customer %d: %v", *customerID, err)
+		return nil
+	}
+
+	property = &models.CustomerProperty{CustomerID: *customerID, Street: address, Address: address}
+	if squareMeters > 0 {
+		property.SquareMeters = &squareMeters
+	}
+	if err := s.repo.CreateProperty(property); err != nil {
+		log.Printf("Failed to save property of customer %d: %v", *customerID, err)
+		return nil
+	}
+	return &property.ID
+}
+
+// rooms returns the bedroom and bathroom counts on file for a property, which
+// price the job when the booking doesn't give them
+func (s *PropertyService) rooms(propertyID *int) (int, float64) {
+	if propertyID == nil {
+		return 0, 0
+	}
+	property, err := s.repo.GetProperty(*propertyID)
+	if err != nil {
+		return 0, 0
+	}
+
+	bedrooms, bathrooms := 0, 0.0
+	if property.Bedrooms != nil {
+		bedrooms = *property.Bedrooms
+	}
+	if property.Bathrooms != nil {
+		bathrooms = *property.Bathrooms
+	}
+	return bedrooms, bathrooms
+}
+
+// serviceLocation returns the city, state and zip of a booking's address,
+// from its property when that has them
+func (s *PropertyService) serviceLocation(propertyID *int, address string) (city, state, zip string) {
+	if propertyID != nil {
+		if property, err := s.repo.GetProperty(*propertyID); err == nil && property.City != "" {
+			return property.City, property.State, property.ZipCode
+		}
+	}
+	return parseAddress(address)
+}
+
+func applyPropertyRequest(property *models.CustomerProperty, req *models.CustomerPropertyRequest) {
+	property.Label = strings.TrimSpace(req.Label)
+	property.Street = strings.TrimSpace(req.Street)
+	property.Unit = strings.TrimSpace(req.Unit)
+	property.City = strings.TrimSpace(req.City)
+	property.State = strings.TrimSpace(req.State)
+	property.ZipCode = strings.TrimSpace(req.ZipCode)
+	property.AccessNotes = req.AccessNotes
+	property.GateCode = req.GateCode
+	property.Pets = req.Pets
+	property.SquareMeters = req.SquareMeters
+	property.Bedrooms = req.Bedrooms
+	property.Bathrooms = req.Bathrooms
+	property.Address = formatAddress(property)
+}
+
+// formatAddress writes a property's address on one line, e.g.
+// "120 Ocean Dr, Unit 4, Miami Beach, FL 33139"
+func formatAddress(property *models.CustomerProperty) string {
+	parts := []string{property.Street}
+	if property.Unit != "" {
+		parts = append(parts, property.Unit)
+	}
+	if property.City != "" {
+		parts = append(parts, property.City)
+	}
+	if region := strings.TrimSpace(property.State + " " + property.ZipCode); region != "" {
+		parts = append(parts, region)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/mail"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+	"cleaning-app-backend/internal/utils"
+)
+
+// QuotePortalService sends quotes to customers and handles their answer.
+// Sending a quote mails a link holding a random token; only its hash is kept
+// on the quote, and sending again replaces it. The customer accepts by
+// picking a slot, which books the job at the quoted price, or rejects the
+// quote. Links stop working once the quote is answered or expires,
+// QUOTE_EXPIRY_DAYS after it was sent.
+type QuotePortalService struct {
+	repo           *repositories.QuoteRepository
+	bookingService *BookingService
+	mailer         mail.Sender
+	config         config.Config
+}
+
+func NewQuotePortalService(cfg config.Config) *QuotePortalService {
+	return &QuotePortalService{
+		repo:           &repositories.QuoteRepository{},
+		bookingService: NewBookingService(),
+		mailer:         mail.NewSender(cfg),
+		config:         cfg,
+	}
+}
+
+// Send mails a quote to its customer with a fresh link and marks it sent
+func (s *QuotePortalService) Send(id int) (*models.Quote, error) {
+	quote, err := s.repo.GetQuoteByID(id)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("quote not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status == models.QuoteStatusAccepted {
+		return nil, errors.New("quote already accepted")
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().AddDate(0, 0, s.config.QuoteExpiryDays)
+	if err := s.repo.MarkSent(id, utils.HashToken(token), expiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("quote already accepted")
+		}
+		return nil, err
+	}
+
+	service, err := NewServiceService().GetServiceByID(quote.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.mailer.Send(mail.Message{
+		To:      quote.ContactEmail,
+		Subject: fmt.Sprintf("Your cleaning quote #%d", quote.ID),
+		Body: fmt.Sprintf(`Hi %s,
+
+Here is your quote for %s at %s: $%.2f.
+
+To book it, open the link below and pick a date and time that suits you. You can also decline it there.
+
+%s
+
+The quote is valid until %s.
+
+Premier Prime Cleaning Services`, quote.ContactName, service.Name, quote.Address, quote.EstimatedPrice,
+			s.link(token), expiresAt.Format("January 2, 2006")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetQuoteByID(id)
+}
+
+// Authenticate returns the quote a link was issued for. Answered and expired
+// quotes can still be viewed through it.
+func (s *QuotePortalService) Authenticate(token string) (*models.Quote, error) {
+	if err := s.repo.ExpireQuotes(); err != nil {
+		return nil, err
+	}
+
+	quote, err := s.repo.GetQuoteByTokenHash(utils.HashToken(token))
+	if err != nil {
+		return nil, errors.New("invalid or expired link")
+	}
+	return quote, nil
+}
+
+// GetQuote shows the customer their quote. Admin notes stay internal.
+func (s *QuotePortalService) GetQuote(quote *models.Quote) (*models.QuoteResponse, error) {
+	service, err := NewServiceService().GetServiceByID(quote.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.QuoteResponse{
+		ID:                  quote.ID,
+		ServiceID:           quote.ServiceID,
+		ServiceName:         service.Name,
+		SquareMeters:        quote.SquareMeters,
+		Address:             quote.Address,
+		SpecialRequirements: quote.SpecialRequirements,
+		PreferredDate:       quote.PreferredDate,
+		ContactEmail:        quote.ContactEmail,
+		ContactName:         quote.ContactName,
+		ContactPhone:        quote.ContactPhone,
+		EstimatedPrice:      quote.EstimatedPrice,
+		PriceBreakdown:      quote.PriceBreakdown,
+		Status:              quote.Status,
+		SentAt:              quote.SentAt,
+		ExpiresAt:           quote.ExpiresAt,
+		RejectionReason:     quote.RejectionReason,
+		BookingID:           quote.BookingID,
+		CreatedAt:           quote.CreatedAt,
+	}, nil
+}
+
+// Accept books the quoted job in the slot the customer picked, at the quoted
+// price. The quote is marked accepted first so it can only be booked once; if
+// the booking fails it is open again. A promo code the quote was priced with
+// is redeemed with the booking.
+func (s *QuotePortalService) Accept(quote *models.Quote, req *models.QuoteAcceptRequest) (*models.BookingResponse, error) {
+	service, err := NewServiceService().GetBookableService(quote.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduledDate, err := time.Parse("2006-01-02", req.ScheduledDate)
+	if err != nil {
+		return nil, errors.New("invalid date format. Use YYYY-MM-DD")
+	}
+
+	var addOnHours float64
+	if quote.PriceBreakdown != nil {
+		addOnHours = quote.PriceBreakdown.AddOnHours
+	}
+	instructions := req.SpecialInstructions
+	if instructions == "" {
+		instructions = quote.SpecialRequirements
+	}
+	booking := &models.Booking{
+		UserID:              quote.UserID,
+		ServiceID:           quote.ServiceID,
+		ScheduledDate:       scheduledDate,
+		ScheduledTime:       req.ScheduledTime,
+		Address:             quote.Address,
+		SquareMeters:        quote.SquareMeters,
+		SpecialInstructions: instructions,
+		TotalPrice:          quote.EstimatedPrice,
+		PriceBreakdown:      quote.PriceBreakdown,
+		DurationHours:       JobDurationHours(service.Duration, quote.SquareMeters) + addOnHours,
+		Status:              "pending",
+		CustomerID:          quote.CustomerID,
+		PropertyID:          quote.PropertyID,
+	}
+	create := s.bookingService.repo.CreateBooking
+	if booking.UserID == nil {
+		booking.GuestName = quote.ContactName
+		booking.GuestEmail = quote.ContactEmail
+		booking.GuestPhone = quote.ContactPhone
+		booking.IsGuestBooking = true
+		create = s.bookingService.repo.CreateGuestBooking
+	}
+
+	promos := NewPromoService()
+	var promo *models.PromoCode
+	if quote.PriceBreakdown != nil && quote.PriceBreakdown.PromoCode != "" {
+		// A code renamed since the quote was priced can't be redeemed, but
+		// the quoted price stands
+		promo, err = promos.repo.GetPromoCodeByCode(quote.PriceBreakdown.PromoCode)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	accepted, err := s.repo.Respond(quote.ID, models.QuoteStatusAccepted, "")
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, errors.New("quote is no longer open")
+	}
+
+	err = promos.redeem(promo, booking, func() error {
+		return s.bookingService.createInSlot(booking, create)
+	})
+	if err != nil {
+		if reopenErr := s.repo.Reopen(quote.ID); reopenErr != nil {
+			log.Printf("Failed to reopen quote %d: %v", quote.ID, reopenErr)
+		}
+		return nil, err
+	}
+	if err := s.repo.SetBooking(quote.ID, booking.ID); err != nil {
+		log.Printf("Failed to link quote %d to booking %d: %v", quote.ID, booking.ID, err)
+	}
+
+	if booking.UserID != nil {
+		return s.bookingService.GetBookingByID(booking.ID, *booking.UserID)
+	}
+	return s.bookingService.repo.GetGuestBooking(booking.ID, booking.GuestEmail)
+}
+
+// Reject records that the customer declined the quote
+func (s *QuotePortalService) Reject(quote *models.Quote, reason string) error {
+	rejected, err := s.repo.Respond(quote.ID, models.QuoteStatusRejected, reason)
+	if err != nil {
+		return err
+	}
+	if !rejected {
+		return errors.New("quote is no longer open")
+	}
+	return nil
+}
+
+func (s *QuotePortalService) link(token string) string {
+	return fmt.Sprintf("%s/quote?token=%s", s.config.AppURL, url.QueryEscape(token))
+}
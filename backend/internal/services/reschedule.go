@@ -0,0 +1,133 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"cleaning-app-backend/internal/models"
+)
+
+// Bookings can only be moved before the crew starts
+var reschedulableStatuses = map[string]bool{
+	"pending":   true,
+	"confirmed": true,
+}
+
+// RescheduleBooking moves a booking for its owner. Admins may move any
+// booking and are not held to the reschedule cutoff.
+func (s *BookingService) RescheduleBooking(id int, req *models.BookingRescheduleRequest, actor models.StatusActor) error {
+	booking, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return errors.New("booking not found")
+	}
+
+	if actor.Role != models.RoleAdmin {
+		if actor.UserID == nil || booking.UserID == nil || *booking.UserID != *actor.UserID {
+			return errors.New("booking not found")
+		}
+	}
+
+	return s.reschedule(booking, req.ScheduledDate, req.ScheduledTime, actor, req.Reason)
+}
+
+func (s *BookingService) GetReschedules(id int) ([]models.BookingReschedule, error) {
+	return s.rescheduleRepo.GetReschedules(id)
+}
+
+// reschedule checks that the new slot is free for the booking's whole duration
+// and that its cleaners can come along, then moves it and records the move.
+// Customers can't move a booking once the cutoff before its current start
+// has passed.
+func (s *BookingService) reschedule(booking *models.Booking, date, clock string, actor models.StatusActor, reason string) error {
+	return s.move(booking, date, clock, actor, reason, false)
+}
+
+// move reschedules a booking like reschedule. With saveDetails its address,
+// size, instructions, price and duration are saved along with the move, so an
+// admin edit is never left half applied.
+func (s *BookingService) move(booking *models.Booking, date, clock string, actor models.StatusActor, reason string, saveDetails bool) error {
+	if !reschedulableStatuses[booking.Status] {
+		return errors.New("booking can no longer be rescheduled")
+	}
+
+	newDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return errors.New("invalid date format. Use YYYY-MM-DD")
+	}
+	minutes, err := parseClock(clock)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	newStart := time.Date(newDate.Year(), newDate.Month(), newDate.Day(), minutes/60, minutes%60, 0, 0, time.Local)
+	if newStart.Before(now) {
+		return errors.New("cannot reschedule into the past")
+	}
+
+	if actor.Role != models.RoleAdmin {
+		policy, err := NewCancellationService().GetPolicy()
+		if err != nil {
+			return err
+		}
+		start, err := bookingStart(booking)
+		if err != nil {
+			return err
+		}
+		if start.Sub(now) < time.Duration(policy.RescheduleCutoffHours)*time.Hour {
+			return errors.New("too late to reschedule")
+		}
+	}
+
+	unlock, err := s.repo.LockScheduleDate(newDate)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.checkSlot(booking, newDate, clock); err != nil {
+		return err
+	}
+
+	var details *models.Booking
+	if saveDetails {
+		details = booking
+	}
+	err = s.rescheduleRepo.RescheduleBooking(booking.ID, newDate, s.normalizeTimeFormat(clock), actor, reason, details, func(status string) error {
+		if !reschedulableStatuses[status] {
+			return errors.New("booking can no longer be rescheduled")
+		}
+		return nil
+	})
+	if err == sql.ErrNoRows {
+		return errors.New("booking not found")
+	}
+
+	return err
+}
+
+// checkSlot checks that a booking fits at a date and time for its whole
+// duration, with its assigned cleaners free to come along. Call it holding the
+// day's schedule lock.
+func (s *BookingService) checkSlot(booking *models.Booking, date time.Time, clock string) error {
+	if err := NewSchedulingService().CheckAvailability(date, clock, booking.DurationHours, booking.ID); err != nil {
+		return err
+	}
+	return NewCleanerService().CheckReschedule(booking.ID, date.Format("2006-01-02"), clock, booking.DurationHours)
+}
+
+// saveLonger saves a booking whose job grew where it is, once the longer job
+// still fits its slot
+func (s *BookingService) saveLonger(booking *models.Booking) error {
+	unlock, err := s.repo.LockScheduleDate(booking.ScheduledDate)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.checkSlot(booking, booking.ScheduledDate, booking.ScheduledTime); err != nil {
+		return err
+	}
+	return s.repo.UpdateBooking(booking)
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// Scheduling rules. A service's duration_hours covers a job of up to
+// referenceSquareMeters; bigger jobs take proportionally longer. Opening hours
+// come from the admin-managed business_hours and schedule_exceptions tables.
+const (
+	referenceSquareMeters = 100.0
+	slotIntervalMinutes   = 30
+)
+
+// JobDurationHours returns the expected length of a job, rounded up to the
+// next half hour
+func JobDurationHours(serviceHours, squareMeters float64) float64 {
+	hours := serviceHours
+	if squareMeters > referenceSquareMeters {
+		hours = serviceHours * squareMeters / referenceSquareMeters
+	}
+	return math.Ceil(hours*2) / 2
+}
+
+// SchedulingService decides which start times can take a job. A start time is
+// offered only if the whole job fits inside the day's opening hours and, at
+// every moment of it, fewer jobs are running than there are crews.
+type SchedulingService struct {
+	calendarRepo *repositories.CalendarRepository
+	crewRepo     *repositories.CrewRepository
+	serviceRepo  *repositories.ServiceRepository
+	scheduleRepo *repositories.ScheduleRepository
+}
+
+func NewSchedulingService() *SchedulingService {
+	return &SchedulingService{
+		calendarRepo: &repositories.CalendarRepository{},
+		crewRepo:     &repositories.CrewRepository{},
+		serviceRepo:  &repositories.ServiceRepository{},
+		scheduleRepo: &repositories.ScheduleRepository{},
+	}
+}
+
+// DurationForService returns the job length for a service and property size
+func (s *SchedulingService) DurationForService(serviceID int, squareMeters float64) (float64, error) {
+	service, err := s.serviceRepo.GetServiceByID(serviceID)
+	if err != nil {
+		return 0, errors.New("service not found")
+	}
+	return JobDurationHours(service.Duration, squareMeters), nil
+}
+
+// Capacity returns how many jobs can run at the same time. Until crews are
+// set up the business is treated as a single team.
+func (s *SchedulingService) Capacity() (int, error) {
+	crews, err := s.crewRepo.CountAvailableCrews()
+	if err != nil {
+		return 0, err
+	}
+	if crews < 1 {
+		return 1, nil
+	}
+	return crews, nil
+}
+
+// GetAvailableSlots lists every start time of the day for a job of the given
+// length. Closed days have no slots.
+func (s *SchedulingService) GetAvailableSlots(date time.Time, durationHours float64) ([]AvailableSlot, error) {
+	opens, closes, isOpen, err := s.OpeningHours(date)
+	if err != nil {
+		return nil, err
+	}
+	if !isOpen {
+		return []AvailableSlot{}, nil
+	}
+
+	windows, err := s.calendarRepo.GetBookingWindows(date, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := s.Capacity()
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(math.Ceil(durationHours * 60))
+
+	// Start times that already passed can't be booked
+	earliest := opens
+	now := time.Now()
+	if date.Format("2006-01-02") == now.Format("2006-01-02") {
+		earliest = now.Hour()*60 + now.Minute() + 1
+	}
+
+	slots := make([]AvailableSlot, 0)
+	for start := opens; start < closes; start += slotIntervalMinutes {
+		free := 0
+		if start >= earliest && start+length <= closes {
+			free = capacity - peakLoad(windows, start, start+length)
+		}
+		if free < 0 {
+			free = 0
+		}
+
+		slots = append(slots, AvailableSlot{
+			Time:      fmt.Sprintf("%02d:%02d", start/60, start%60),
+			Available: free > 0,
+			Duration:  length,
+			FreeCrews: free,
+		})
+	}
+
+	return slots, nil
+}
+
+// CheckAvailability verifies that a job of the given length can start at
+// startTime on date. excludeID leaves a booking out of the check so it can be
+// moved without colliding with itself.
+func (s *SchedulingService) CheckAvailability(date time.Time, startTime string, durationHours float64, excludeID int) error {
+	start, err := parseClock(startTime)
+	if err != nil {
+		return err
+	}
+	end := start + int(math.Ceil(durationHours*60))
+
+	opens, closes, isOpen, err := s.OpeningHours(date)
+	if err != nil {
+		return err
+	}
+	if !isOpen {
+		return errors.New("closed on this date")
+	}
+	if start < opens || end > closes {
+		return errors.New("outside business hours")
+	}
+
+	windows, err := s.calendarRepo.GetBookingWindows(date, excludeID)
+	if err != nil {
+		return err
+	}
+
+	capacity, err := s.Capacity()
+	if err != nil {
+		return err
+	}
+
+	if peakLoad(windows, start, end) >= capacity {
+		return errors.New("time slot unavailable")
+	}
+
+	return nil
+}
+
+// OpeningHours returns when the business opens and closes on a date, in
+// minutes after midnight. A schedule exception for the date takes precedence
+// over the weekly hours.
+func (s *SchedulingService) OpeningHours(date time.Time) (opens, closes int, isOpen bool, err error) {
+	exception, err := s.scheduleRepo.GetScheduleExceptionForDate(date)
+	if err == nil {
+		if exception.IsClosed {
+			return 0, 0, false, nil
+		}
+		return clockRange(exception.OpensAt, exception.ClosesAt)
+	}
+	if err != sql.ErrNoRows {
+		return 0, 0, false, err
+	}
+
+	day, err := s.scheduleRepo.GetBusinessHoursForWeekday(int(date.Weekday()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, false, nil
+		}
+		return 0, 0, false, err
+	}
+	if !day.IsOpen {
+		return 0, 0, false, nil
+	}
+	return clockRange(day.OpensAt, day.ClosesAt)
+}
+
+func clockRange(opensAt, closesAt string) (int, int, bool, error) {
+	opens, err := parseClock(opensAt)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	closes, err := parseClock(closesAt)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return opens, closes, true, nil
+}
+
+func (s *SchedulingService) GetBusinessHours() ([]models.BusinessHours, error) {
+	return s.scheduleRepo.GetBusinessHours()
+}
+
+// UpdateBusinessHours saves the weekly hours for the weekdays given
+func (s *SchedulingService) UpdateBusinessHours(hours []models.BusinessHours) ([]models.BusinessHours, error) {
+	seen := make(map[int]bool)
+	for _, day := range hours {
+		if day.Weekday < 0 || day.Weekday > 6 {
+			return nil, errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		if seen[day.Weekday] {
+			return nil, errors.New("each weekday can only be given once")
+		}
+		seen[day.Weekday] = true
+
+		if err := validateHours(day.OpensAt, day.ClosesAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.scheduleRepo.SaveBusinessHours(hours); err != nil {
+		return nil, errors.New("failed to save business hours")
+	}
+
+	return s.scheduleRepo.GetBusinessHours()
+}
+
+func (s *SchedulingService) GetScheduleExceptions(from, to time.Time) ([]models.ScheduleException, error) {
+	return s.scheduleRepo.GetScheduleExceptions(from, to)
+}
+
+func (s *SchedulingService) CreateScheduleException(req *models.ScheduleExceptionRequest) (*models.ScheduleException, error) {
+	exception, err := scheduleExceptionFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.CreateScheduleException(exception); err != nil {
+		return nil, errors.New("failed to create schedule exception")
+	}
+
+	return exception, nil
+}
+
+func (s *SchedulingService) UpdateScheduleException(id int, req *models.ScheduleExceptionRequest) (*models.ScheduleException, error) {
+	existing, err := s.scheduleRepo.GetScheduleExceptionByID(id)
+	if err != nil {
+		return nil, errors.New("schedule exception not found")
+	}
+
+	exception, err := scheduleExceptionFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	exception.ID = id
+	exception.CreatedAt = existing.CreatedAt
+
+	if err := s.scheduleRepo.UpdateScheduleException(exception); err != nil {
+		return nil, errors.New("failed to update schedule exception")
+	}
+
+	return exception, nil
+}
+
+func (s *SchedulingService) DeleteScheduleException(id int) error {
+	if _, err := s.scheduleRepo.GetScheduleExceptionByID(id); err != nil {
+		return errors.New("schedule exception not found")
+	}
+	return s.scheduleRepo.DeleteScheduleException(id)
+}
+
+func scheduleExceptionFromRequest(req *models.ScheduleExceptionRequest) (*models.ScheduleException, error) {
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid date format. Use YYYY-MM-DD")
+	}
+
+	endDate := startDate
+	if req.EndDate != "" {
+		endDate, err = time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return nil, errors.New("invalid date format. Use YYYY-MM-DD")
+		}
+	}
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date must not be before start date")
+	}
+
+	exception := &models.ScheduleException{
+		StartDate: startDate,
+		EndDate:   endDate,
+		IsClosed:  req.IsClosed,
+		Reason:    req.Reason,
+	}
+
+	// Open exceptions need their own hours; closed ones ignore them
+	if !req.IsClosed {
+		if err := validateHours(req.OpensAt, req.ClosesAt); err != nil {
+			return nil, err
+		}
+		exception.OpensAt = req.OpensAt
+		exception.ClosesAt = req.ClosesAt
+	}
+
+	return exception, nil
+}
+
+func validateHours(opensAt, closesAt string) error {
+	opens, err := parseClock(opensAt)
+	if err != nil {
+		return err
+	}
+	closes, err := parseClock(closesAt)
+	if err != nil {
+		return err
+	}
+	if opens >= closes {
+		return errors.New("opening time must be before closing time")
+	}
+	return nil
+}
+
+// peakLoad returns the highest number of bookings running at once between
+// from and to (minutes after midnight). The load only rises where a booking
+// starts, so checking from and every start inside the range is enough.
+func peakLoad(windows []repositories.BookingWindow, from, to int) int {
+	points := []int{from}
+	for _, window := range windows {
+		if window.StartMinute > from && window.StartMinute < to {
+			points = append(points, window.StartMinute)
+		}
+	}
+
+	peak := 0
+	for _, point := range points {
+		load := 0
+		for _, window := range windows {
+			if window.StartMinute <= point && window.EndMinute > point {
+				load++
+			}
+		}
+		if load > peak {
+			peak = load
+		}
+	}
+
+	return peak
+}
+
+// parseClock converts "15:04", "15:04:05" or the PostgreSQL
+// "0000-01-01T15:04:05Z" form to minutes after midnight
+func parseClock(value string) (int, error) {
+	if i := strings.Index(value, "T"); i >= 0 {
+		value = strings.TrimSuffix(value[i+1:], "Z")
+	}
+
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Hour()*60 + t.Minute(), nil
+		}
+	}
+
+	return 0, errors.New("invalid time format. Use HH:MM")
+}
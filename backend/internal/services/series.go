@@ -0,0 +1,520 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+)
+
+// Occurrences are generated this many days ahead
+const seriesHorizonDays = 56
+
+// SeriesService manages recurring bookings. Each series generates its
+// occurrences as ordinary bookings up to seriesHorizonDays ahead; the series
+// job and admins extend the horizon through GenerateAll.
+//
+// Methods taking a userID only act on that user's series; 0 means an admin
+// acting on any series.
+type SeriesService struct {
+	repo        *repositories.SeriesRepository
+	serviceRepo *repositories.ServiceRepository
+	userRepo    *repositories.UserRepository
+	bookingRepo *repositories.BookingRepository
+}
+
+func NewSeriesService() *SeriesService {
+	return &SeriesService{
+		repo:        &repositories.SeriesRepository{},
+		serviceRepo: &repositories.ServiceRepository{},
+		userRepo:    &repositories.UserRepository{},
+		bookingRepo: &repositories.BookingRepository{},
+	}
+}
+
+func (s *SeriesService) CreateSeries(req *models.SeriesRequest, userID int) (*models.BookingSeries, error) {
+	if userID == 0 {
+		user, err := s.userRepo.GetUserByID(req.UserID)
+		if err != nil || user.Role != models.RoleClient {
+			return nil, errors.New("client not found")
+		}
+		userID = user.ID
+	}
+
+	if _, err := NewServiceService().GetBookableService(req.ServiceID); err != nil {
+		return nil, err
+	}
+
+	if req.PropertyID != nil {
+		customerID, err := NewCustomerService().ForUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		property, err := NewPropertyService().GetProperty(customerID, *req.PropertyID)
+		if err != nil {
+			return nil, err
+		}
+		req.Address = property.Address
+		if req.SquareMeters == 0 && property.SquareMeters != nil {
+			req.SquareMeters = *property.SquareMeters
+		}
+	}
+
+	series := &models.BookingSeries{
+		UserID:              userID,
+		ServiceID:           req.ServiceID,
+		Frequency:           req.Frequency,
+		ScheduledTime:       req.ScheduledTime,
+		Address:             req.Address,
+		SquareMeters:        req.SquareMeters,
+		SpecialInstructions: req.SpecialInstructions,
+		Status:              "active",
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid date format. Use YYYY-MM-DD")
+	}
+	series.StartDate = startDate
+
+	if req.EndDate != "" {
+		endDate, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return nil, errors.New("invalid date format. Use YYYY-MM-DD")
+		}
+		series.EndDate = &endDate
+	}
+
+	if err := s.prepareSeries(series, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSeries(series); err != nil {
+		return nil, errors.New("failed to create series")
+	}
+
+	if _, err := s.generate(series, horizon()); err != nil {
+		log.Printf("Failed to generate occurrences for series %d: %v", series.ID, err)
+	}
+
+	return s.GetSeries(series.ID, userID)
+}
+
+// prepareSeries validates a new series, sets its discount and checks that its
+// first occurrence can be booked. excludeID is an existing booking to leave
+// out of the availability check.
+func (s *SeriesService) prepareSeries(series *models.BookingSeries, excludeID int) error {
+	discount, ok := frequencyDiscounts[series.Frequency]
+	if !ok {
+		return errors.New("frequency must be weekly, biweekly or monthly")
+	}
+	series.DiscountPercent = discount
+
+	if series.Address == "" {
+		return errors.New("address is required")
+	}
+	if series.SquareMeters <= 0 {
+		return errors.New("square meters must be greater than 0")
+	}
+	if _, err := parseClock(series.ScheduledTime); err != nil {
+		return err
+	}
+	if series.StartDate.Before(today()) {
+		return errors.New("start date must not be in the past")
+	}
+	if series.EndDate != nil && series.EndDate.Before(series.StartDate) {
+		return errors.New("end date must not be before start date")
+	}
+
+	service, err := NewServiceService().GetBookableService(series.ServiceID)
+	if err != nil {
+		return err
+	}
+
+	duration := JobDurationHours(service.Duration, series.SquareMeters)
+	return NewSchedulingService().CheckAvailability(series.StartDate, series.ScheduledTime, duration, excludeID)
+}
+
+func (s *SeriesService) GetSeries(id, userID int) (*models.BookingSeries, error) {
+	series, err := s.getOwnedSeries(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	series.Occurrences, err = s.repo.GetOccurrences(series.ID, today())
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+func (s *SeriesService) ListSeries(userID int) ([]models.BookingSeries, error) {
+	return s.repo.GetSeries(userID)
+}
+
+// UpdateFollowing changes an occurrence and all later ones. The series is
+// split: the original ends the day before fromDate and a new series with the
+// changes takes over from there, so past occurrences keep their history.
+func (s *SeriesService) UpdateFollowing(id, userID int, req *models.SeriesUpdateRequest, actor models.StatusActor) (*models.BookingSeries, error) {
+	current, err := s.getOwnedSeries(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != "active" {
+		return nil, errors.New("series is cancelled")
+	}
+
+	fromDate, err := time.Parse("2006-01-02", req.FromDate)
+	if err != nil {
+		return nil, errors.New("invalid date format. Use YYYY-MM-DD")
+	}
+	if fromDate.Before(today()) {
+		return nil, errors.New("only future occurrences can be changed")
+	}
+	if fromDate.Before(current.StartDate) {
+		fromDate = current.StartDate
+	}
+
+	next := &models.BookingSeries{
+		UserID:              current.UserID,
+		ServiceID:           current.ServiceID,
+		Frequency:           current.Frequency,
+		StartDate:           fromDate,
+		EndDate:             current.EndDate,
+		ScheduledTime:       current.ScheduledTime,
+		Address:             current.Address,
+		SquareMeters:        current.SquareMeters,
+		SpecialInstructions: current.SpecialInstructions,
+		Status:              "active",
+	}
+	if req.StartDate != "" {
+		next.StartDate, err = time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			return nil, errors.New("invalid date format. Use YYYY-MM-DD")
+		}
+	}
+	if req.Frequency != "" {
+		next.Frequency = req.Frequency
+	}
+	if req.ScheduledTime != "" {
+		next.ScheduledTime = req.ScheduledTime
+	}
+	if req.Address != "" {
+		next.Address = req.Address
+	}
+	if req.SquareMeters != nil {
+		next.SquareMeters = *req.SquareMeters
+	}
+	if req.SpecialInstructions != nil {
+		next.SpecialInstructions = *req.SpecialInstructions
+	}
+
+	// The occurrence being replaced must not block its own new slot
+	excludeID := 0
+	if occurrences, err := s.repo.GetOccurrences(current.ID, fromDate); err == nil {
+		for _, occurrence := range occurrences {
+			if occurrence.ScheduledDate.Equal(next.StartDate) {
+				excludeID = occurrence.ID
+				break
+			}
+		}
+	}
+
+	if err := s.prepareSeries(next, excludeID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.cancelOccurrencesFrom(current.ID, fromDate, actor, "replaced by series change"); err != nil {
+		return nil, err
+	}
+
+	if fromDate.After(current.StartDate) {
+		err = s.repo.EndSeries(current.ID, fromDate.AddDate(0, 0, -1), "active")
+	} else {
+		// Nothing of the old series remains
+		err = s.repo.EndSeries(current.ID, current.StartDate, "cancelled")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSeries(next); err != nil {
+		return nil, errors.New("failed to create series")
+	}
+
+	if _, err := s.generate(next, horizon()); err != nil {
+		log.Printf("Failed to generate occurrences for series %d: %v", next.ID, err)
+	}
+
+	return s.GetSeries(next.ID, userID)
+}
+
+// CancelSeries stops a series and cancels all of its future occurrences
+func (s *SeriesService) CancelSeries(id, userID int, actor models.StatusActor) (int, error) {
+	series, err := s.getOwnedSeries(id, userID)
+	if err != nil {
+		return 0, err
+	}
+	if series.Status != "active" {
+		return 0, errors.New("series is cancelled")
+	}
+
+	endDate := today()
+	if endDate.Before(series.StartDate) {
+		endDate = series.StartDate
+	}
+
+	if err := s.repo.EndSeries(series.ID, endDate, "cancelled"); err != nil {
+		return 0, err
+	}
+
+	return s.cancelOccurrencesFrom(series.ID, today(), actor, "series cancelled")
+}
+
+// cancelOccurrencesFrom cancels the open occurrences from a date on and
+// returns how many were cancelled
+func (s *SeriesService) cancelOccurrencesFrom(seriesID int, from time.Time, actor models.StatusActor, reason string) (int, error) {
+	ids, err := s.repo.GetOpenOccurrenceIDs(seriesID, from)
+	if err != nil {
+		return 0, err
+	}
+
+	statusService := NewBookingStatusService()
+	cancelled := 0
+	for _, id := range ids {
+		if err := statusService.ChangeStatus(id, "cancelled", actor, reason); err != nil {
+			// The occurrence may have started in the meantime
+			if err.Error() == "invalid status transition" {
+				continue
+			}
+			return cancelled, err
+		}
+		cancelled++
+	}
+
+	return cancelled, nil
+}
+
+// SkipOccurrence skips a single occurrence; the rest of the series is unchanged
+func (s *SeriesService) SkipOccurrence(id, bookingID, userID int, actor models.StatusActor) error {
+	occurrence, err := s.getChangeableOccurrence(id, bookingID, userID)
+	if err != nil {
+		return err
+	}
+
+	return NewBookingStatusService().ChangeStatus(occurrence.ID, "skipped", actor, "occurrence skipped")
+}
+
+// RescheduleOccurrence moves a single occurrence to another date and time,
+// under the same rules as any other booking
+func (s *SeriesService) RescheduleOccurrence(id, bookingID, userID int, req *models.OccurrenceRescheduleRequest, actor models.StatusActor) error {
+	occurrence, err := s.getChangeableOccurrence(id, bookingID, userID)
+	if err != nil {
+		return err
+	}
+
+	return NewBookingService().reschedule(occurrence, req.ScheduledDate, req.ScheduledTime, actor, "")
+}
+
+// GenerateAll extends every active series up to the horizon. It is safe to
+// run repeatedly; it returns the number of occurrences created.
+func (s *SeriesService) GenerateAll() (int, error) {
+	until := horizon()
+	seriesList, err := s.repo.GetSeriesToGenerate(until)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for i := range seriesList {
+		count, err := s.generate(&seriesList[i], until)
+		if err != nil {
+			log.Printf("Failed to generate occurrences for series %d: %v", seriesList[i].ID, err)
+			continue
+		}
+		created += count
+	}
+
+	return created, nil
+}
+
+// generate creates the missing occurrences of a series up to a date.
+// Occurrences that fall on a closed day or don't fit the schedule are stored
+// as skipped; see createOccurrence. A series of an archived service is paused:
+// nothing is generated until the service is unarchived.
+func (s *SeriesService) generate(series *models.BookingSeries, until time.Time) (int, error) {
+	service, err := s.serviceRepo.GetServiceByID(series.ServiceID)
+	if err != nil {
+		return 0, err
+	}
+	if service.ArchivedAt != nil {
+		return 0, nil
+	}
+
+	if series.EndDate != nil && series.EndDate.Before(until) {
+		until = *series.EndDate
+	}
+
+	duration := JobDurationHours(service.Duration, series.SquareMeters)
+	userID := series.UserID
+	seriesID := series.ID
+	customerID := NewCustomerService().link(&userID, "", "", "")
+	properties := NewPropertyService()
+	propertyID := properties.forAddress(customerID, series.Address, series.SquareMeters)
+	bedrooms, bathrooms := properties.rooms(propertyID)
+	breakdown, err := NewPricingService().Price(models.PriceInput{
+		ServiceID:    series.ServiceID,
+		SquareMeters: series.SquareMeters,
+		Bedrooms:     bedrooms,
+		Bathrooms:    bathrooms,
+		Frequency:    series.Frequency,
+	})
+	if err != nil {
+		return 0, err
+	}
+	first := today()
+	if series.GeneratedUntil != nil && series.GeneratedUntil.After(first) {
+		first = series.GeneratedUntil.AddDate(0, 0, 1)
+	}
+
+	created := 0
+	for n := 0; ; n++ {
+		date := occurrenceDate(series.StartDate, series.Frequency, n)
+		if date.After(until) {
+			break
+		}
+		if date.Before(first) {
+			continue
+		}
+
+		planned := date
+		booking := &models.Booking{
+			UserID:              &userID,
+			ServiceID:           series.ServiceID,
+			ScheduledDate:       date,
+			ScheduledTime:       series.ScheduledTime,
+			Address:             series.Address,
+			SquareMeters:        series.SquareMeters,
+			SpecialInstructions: series.SpecialInstructions,
+			TotalPrice:          breakdown.Total,
+			PriceBreakdown:      breakdown,
+			DurationHours:       duration,
+			Status:              "pending",
+			SeriesID:            &seriesID,
+			OccurrenceDate:      &planned,
+			CustomerID:          customerID,
+			PropertyID:          propertyID,
+		}
+		isNew, err := s.createOccurrence(booking)
+		if err != nil {
+			return created, err
+		}
+		if isNew {
+			created++
+		}
+	}
+
+	if err := s.repo.SetGeneratedUntil(series.ID, until); err != nil {
+		return created, err
+	}
+	series.GeneratedUntil = &until
+
+	return created, nil
+}
+
+// createOccurrence stores an occurrence, holding its day's schedule lock like
+// any other booking. One that falls on a closed day is stored as skipped. One
+// that doesn't fit the schedule is skipped too, with the reason in its status
+// history, so an admin can see it and rebook it elsewhere. It reports whether
+// the occurrence is new.
+func (s *SeriesService) createOccurrence(booking *models.Booking) (bool, error) {
+	unlock, err := s.bookingRepo.LockScheduleDate(booking.ScheduledDate)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	var conflict error
+	err = NewSchedulingService().CheckAvailability(booking.ScheduledDate, booking.ScheduledTime, booking.DurationHours, 0)
+	if err != nil {
+		if err.Error() == "closed on this date" {
+			booking.Status = "skipped"
+		} else {
+			conflict = err
+		}
+	}
+
+	if err := s.repo.CreateOccurrence(booking); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if conflict != nil {
+		log.Printf("Series %d occurrence on %s does not fit the schedule, skipped: %v",
+			*booking.SeriesID, booking.ScheduledDate.Format("2006-01-02"), conflict)
+		actor := models.StatusActor{Role: models.ActorSystem}
+		if err := NewBookingStatusService().ChangeStatus(booking.ID, "skipped", actor, "does not fit the schedule: "+conflict.Error()); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+func (s *SeriesService) getOwnedSeries(id, userID int) (*models.BookingSeries, error) {
+	series, err := s.repo.GetSeriesByID(id)
+	if err != nil || (userID != 0 && series.UserID != userID) {
+		return nil, errors.New("series not found")
+	}
+	return series, nil
+}
+
+func (s *SeriesService) getChangeableOccurrence(id, bookingID, userID int) (*models.Booking, error) {
+	if _, err := s.getOwnedSeries(id, userID); err != nil {
+		return nil, err
+	}
+
+	occurrence, err := s.repo.GetOccurrence(id, bookingID)
+	if err != nil {
+		return nil, errors.New("occurrence not found")
+	}
+	if occurrence.Status != "pending" && occurrence.Status != "confirmed" {
+		return nil, errors.New("occurrence can no longer be changed")
+	}
+
+	return occurrence, nil
+}
+
+// occurrenceDate returns the nth date of a series. Monthly series keep the day
+// of month, falling back to the last day in shorter months.
+func occurrenceDate(start time.Time, frequency string, n int) time.Time {
+	switch frequency {
+	case models.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case models.FrequencyBiweekly:
+		return start.AddDate(0, 0, 14*n)
+	default:
+		firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, start.Location())
+		lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+		day := start.Day()
+		if day > lastDay {
+			day = lastDay
+		}
+		return firstOfMonth.AddDate(0, 0, day-1)
+	}
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func horizon() time.Time {
+	return today().AddDate(0, 0, seriesHorizonDays)
+}
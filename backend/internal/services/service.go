@@ -1,7 +1,10 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
+	"log"
+	"strings"
 	"time"
 
 	"cleaning-app-backend/internal/database"
@@ -54,6 +57,7 @@ func (s *UserService) Register(userReq *models.UserRegisterRequest) (*models.Use
 		LastName:  user.LastName,
 		Phone:     user.Phone,
 		Role:      user.Role,
+		EmailVerified: user.EmailVerified,
 		CreatedAt: user.CreatedAt,
 	}
 
@@ -77,6 +81,7 @@ func (s *UserService) Login(email, password string) (*models.UserResponse, error
 		LastName:  user.LastName,
 		Phone:     user.Phone,
 		Role:      user.Role,
+		EmailVerified: user.EmailVerified,
 		CreatedAt: user.CreatedAt,
 	}
 
@@ -96,6 +101,7 @@ func (s *UserService) GetProfile(userID int) (*models.UserResponse, error) {
 		LastName:  user.LastName,
 		Phone:     user.Phone,
 		Role:      user.Role,
+		EmailVerified: user.EmailVerified,
 		CreatedAt: user.CreatedAt,
 	}
 
@@ -113,15 +119,20 @@ func NewServiceService() *ServiceService {
 }
 
 func (s *ServiceService) CreateService(serviceReq *models.ServiceRequest) (*models.ServiceResponse, error) {
+	category, err := s.requestCategory(serviceReq)
+	if err != nil {
+		return nil, err
+	}
+
 	service := &models.Service{
 		Name:        serviceReq.Name,
 		Description: serviceReq.Description,
 		BasePrice:   serviceReq.BasePrice,
 		Duration:    serviceReq.Duration,
-		ServiceType: serviceReq.ServiceType,
+		CategoryID:  category.ID,
 	}
 
-	err := s.repo.CreateService(service)
+	err = s.repo.CreateService(service)
 	if err != nil {
 		return nil, errors.New("failed to create service")
 	}
@@ -132,37 +143,180 @@ func (s *ServiceService) CreateService(serviceReq *models.ServiceRequest) (*mode
 		Description: service.Description,
 		BasePrice:   service.BasePrice,
 		Duration:    service.Duration,
-		ServiceType: service.ServiceType,
+		CategoryID:  category.ID,
+		Category:    category.Name,
+		ServiceType: category.Slug,
+		AddOns:      []models.AddOn{},
 		CreatedAt:   time.Now(),
 	}
 
 	return serviceResp, nil
 }
 
+// requestCategory finds the category a service request names, by ID or slug
+func (s *ServiceService) requestCategory(serviceReq *models.ServiceRequest) (*models.ServiceCategory, error) {
+	var category *models.ServiceCategory
+	var err error
+	if serviceReq.CategoryID != nil {
+		category, err = s.repo.GetCategory(*serviceReq.CategoryID)
+	} else {
+		category, err = s.repo.GetCategoryBySlug(serviceReq.ServiceType)
+	}
+	if err == sql.ErrNoRows {
+		return nil, errors.New("category not found")
+	}
+	return category, err
+}
+
 func (s *ServiceService) GetServiceByID(id int) (*models.Service, error) {
 	return s.repo.GetServiceByID(id)
 }
 
-func (s *ServiceService) GetAllServices() ([]models.ServiceResponse, error) {
-	return s.repo.GetAllServices()
+// GetBookableService returns a service that new bookings, series and quotes
+// can be made for. Archived services can't.
+func (s *ServiceService) GetBookableService(id int) (*models.Service, error) {
+	service, err := s.repo.GetServiceByID(id)
+	if err != nil || service.ArchivedAt != nil {
+		return nil, errors.New("service not found")
+	}
+	return service, nil
+}
+
+func (s *ServiceService) GetAllServices(includeArchived bool) ([]models.ServiceResponse, error) {
+	return s.repo.GetAllServices(includeArchived)
+}
+
+func (s *ServiceService) UpdateService(id int, serviceReq *models.ServiceRequest) error {
+	category, err := s.requestCategory(serviceReq)
+	if err != nil {
+		return err
+	}
+
+	service := &models.Service{
+		ID:          id,
+		Name:        serviceReq.Name,
+		Description: serviceReq.Description,
+		BasePrice:   serviceReq.BasePrice,
+		Duration:    serviceReq.Duration,
+		CategoryID:  category.ID,
+	}
+	if err := s.repo.UpdateService(service); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("service not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// ArchiveService takes a service out of the catalog. Its bookings, series
+// and invoices stay as they are.
+func (s *ServiceService) ArchiveService(id int) error {
+	return s.setArchived(id, true)
+}
+
+// UnarchiveService puts an archived service back in the catalog
+func (s *ServiceService) UnarchiveService(id int) error {
+	return s.setArchived(id, false)
+}
+
+func (s *ServiceService) setArchived(id int, archived bool) error {
+	err := s.repo.SetArchived(id, archived)
+	if err == sql.ErrNoRows {
+		return errors.New("service not found")
+	}
+	return err
+}
+
+func (s *ServiceService) GetServiceAddOns(id int) ([]models.AddOn, error) {
+	if _, err := s.repo.GetServiceByID(id); err != nil {
+		return nil, errors.New("service not found")
+	}
+	return s.repo.GetServiceAddOns(id)
+}
+
+// SetServiceAddOns replaces the add-ons that can be booked with a service
+func (s *ServiceService) SetServiceAddOns(id int, addOnIDs []int) ([]models.AddOn, error) {
+	if _, err := s.repo.GetServiceByID(id); err != nil {
+		return nil, errors.New("service not found")
+	}
+
+	pricingRepo := &repositories.PricingRepository{}
+	for _, addOnID := range addOnIDs {
+		if _, err := pricingRepo.GetAddOn(addOnID); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.New("add-on not found")
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.repo.SetServiceAddOns(id, addOnIDs); err != nil {
+		return nil, errors.New("failed to update service add-ons")
+	}
+	return s.repo.GetServiceAddOns(id)
 }
 
-func (s *ServiceService) UpdateService(serviceReq *models.Service, id int) error {
-	serviceReq.ID = id
-	return s.repo.UpdateService(serviceReq)
+func (s *ServiceService) GetCategories() ([]models.ServiceCategory, error) {
+	return s.repo.GetCategories()
 }
 
-func (s *ServiceService) DeleteService(id int) error {
-	return s.repo.DeleteService(id)
+func (s *ServiceService) CreateCategory(req *models.ServiceCategoryRequest) (*models.ServiceCategory, error) {
+	category := &models.ServiceCategory{}
+	if err := s.applyCategoryRequest(category, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateCategory(category); err != nil {
+		return nil, errors.New("failed to create category")
+	}
+	return category, nil
+}
+
+func (s *ServiceService) UpdateCategory(id int, req *models.ServiceCategoryRequest) (*models.ServiceCategory, error) {
+	category, err := s.repo.GetCategory(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+	if err := s.applyCategoryRequest(category, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateCategory(category); err != nil {
+		return nil, errors.New("failed to update category")
+	}
+	return category, nil
+}
+
+// applyCategoryRequest copies the request onto a category. Slugs are what
+// service requests name categories by, so they must stay unique.
+func (s *ServiceService) applyCategoryRequest(category *models.ServiceCategory, req *models.ServiceCategoryRequest) error {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	existing, err := s.repo.GetCategoryBySlug(slug)
+	if err == nil && existing.ID != category.ID {
+		return errors.New("category slug already in use")
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	category.Slug = slug
+	category.Name = req.Name
+	category.Description = req.Description
+	category.SortOrder = req.SortOrder
+	return nil
 }
 
 type BookingService struct {
-	repo *repositories.BookingRepository
+	repo           *repositories.BookingRepository
+	rescheduleRepo *repositories.BookingRescheduleRepository
 }
 
 func NewBookingService() *BookingService {
 	return &BookingService{
-		repo: &repositories.BookingRepository{},
+		repo:           &repositories.BookingRepository{},
+		rescheduleRepo: &repositories.BookingRescheduleRepository{},
 	}
 }
 
@@ -170,9 +324,9 @@ func (s *BookingService) CreateBooking(bookingReq *models.BookingRequest) (*mode
 	// Calculate total price based on service base price and square meters
 	// This would require getting service details first
 	serviceService := NewServiceService()
-	service, err := serviceService.GetServiceByID(bookingReq.ServiceID)
+	service, err := serviceService.GetBookableService(bookingReq.ServiceID)
 	if err != nil {
-		return nil, errors.New("service not found")
+		return nil, err
 	}
 
 	// Parse date
@@ -181,24 +335,76 @@ func (s *BookingService) CreateBooking(bookingReq *models.BookingRequest) (*mode
 		return nil, errors.New("invalid date format. Use YYYY-MM-DD")
 	}
 
-	// Calculate total price based on service base price and square meters
-	totalPrice := service.BasePrice * bookingReq.SquareMeters / 50 // Adjust pricing algorithm as needed
+	// A saved property fills in the address and size
+	customerID := NewCustomerService().link(bookingReq.UserID, "", "", "")
+	address, squareMeters := bookingReq.Address, bookingReq.SquareMeters
+	properties := NewPropertyService()
+	var propertyID *int
+	if bookingReq.PropertyID != nil {
+		if customerID == nil {
+			return nil, errors.New("property not found")
+		}
+		property, err := properties.GetProperty(*customerID, *bookingReq.PropertyID)
+		if err != nil {
+			return nil, err
+		}
+		propertyID, address = &property.ID, property.Address
+		if squareMeters == 0 && property.SquareMeters != nil {
+			squareMeters = *property.SquareMeters
+		}
+		if squareMeters <= 0 {
+			return nil, errors.New("square meters required")
+		}
+	} else {
+		propertyID = properties.forAddress(customerID, address, squareMeters)
+	}
+
+	// Rooms not given are priced as on file for the property
+	bedrooms, bathrooms := properties.rooms(propertyID)
+	if bookingReq.Bedrooms != nil {
+		bedrooms = *bookingReq.Bedrooms
+	}
+	if bookingReq.Bathrooms != nil {
+		bathrooms = *bookingReq.Bathrooms
+	}
+	breakdown, err := NewPricingService().Price(models.PriceInput{
+		ServiceID:    bookingReq.ServiceID,
+		SquareMeters: squareMeters,
+		Bedrooms:     bedrooms,
+		Bathrooms:    bathrooms,
+		AddOns:       bookingReq.AddOns,
+		Condition:    bookingReq.Condition,
+	})
+	if err != nil {
+		return nil, err
+	}
+	promos := NewPromoService()
+	promo, err := promos.Apply(bookingReq.PromoCode, bookingReq.ServiceID, customerID, breakdown)
+	if err != nil {
+		return nil, err
+	}
 
 	booking := &models.Booking{
 		UserID:              bookingReq.UserID,
 		ServiceID:           bookingReq.ServiceID,
 		ScheduledDate:       scheduledDate,
 		ScheduledTime:       bookingReq.ScheduledTime,
-		Address:             bookingReq.Address,
-		SquareMeters:        bookingReq.SquareMeters,
+		Address:             address,
+		SquareMeters:        squareMeters,
 		SpecialInstructions: bookingReq.SpecialInstructions,
-		TotalPrice:          totalPrice,
+		TotalPrice:          breakdown.Total,
+		DurationHours:       JobDurationHours(service.Duration, squareMeters) + breakdown.AddOnHours,
 		Status:              "pending",
+		CustomerID:          customerID,
+		PropertyID:          propertyID,
+		PriceBreakdown:      breakdown,
 	}
 
-	err = s.repo.CreateBooking(booking)
+	err = promos.redeem(promo, booking, func() error {
+		return s.createInSlot(booking, s.repo.CreateBooking)
+	})
 	if err != nil {
-		return nil, errors.New("failed to create booking")
+		return nil, err
 	}
 
 	// Get the created booking with service name
@@ -218,7 +424,7 @@ func (s *BookingService) GetBookingByID(id int, userID int) (*models.BookingResp
 	var booking models.BookingResponse
 	err := database.DB.QueryRow(
 		`SELECT b.id, b.user_id, b.service_id, s.name, b.scheduled_date, b.scheduled_time, 
-		         b.address, b.square_meters, b.special_instructions, b.total_price, b.status, b.created_at 
+		         b.address, b.square_meters, b.special_instructions, b.total_price, b.duration_hours, b.status, b.property_id, b.price_breakdown, b.created_at 
 		 FROM bookings b 
 		 JOIN services s ON b.service_id = s.id 
 		 WHERE b.id = $1 AND b.user_id = $2`,
@@ -227,7 +433,7 @@ func (s *BookingService) GetBookingByID(id int, userID int) (*models.BookingResp
 		&booking.ID, &booking.UserID, &booking.ServiceID, &booking.ServiceName,
 		&booking.ScheduledDate, &booking.ScheduledTime, &booking.Address,
 		&booking.SquareMeters, &booking.SpecialInstructions, &booking.TotalPrice,
-		&booking.Status, &booking.CreatedAt,
+		&booking.DurationHours, &booking.Status, &booking.PropertyID, &booking.PriceBreakdown, &booking.CreatedAt,
 	)
 
 	return &booking, err
@@ -237,72 +443,99 @@ func (s *BookingService) GetBookingsByUserID(userID int) ([]models.BookingRespon
 	return s.repo.GetBookingsByUserID(userID)
 }
 
-func (s *BookingService) UpdateBooking(bookingReq *models.Booking, id int, userID int) error {
-	// First verify the booking exists and belongs to the user
-	// For admin users (userID <= 0), skip ownership check
-	var bookingFromDB models.Booking
-	var err error
-	
-	if userID > 0 {
-		// Regular user - check ownership
-		err = database.DB.QueryRow(
-			"SELECT id, user_id FROM bookings WHERE id = $1 AND user_id = $2",
-			id, userID,
-		).Scan(&bookingFromDB.ID, &bookingFromDB.UserID)
-	} else {
-		// Admin user - just check if booking exists
-		err = database.DB.QueryRow(
-			"SELECT id, user_id FROM bookings WHERE id = $1",
-			id,
-		).Scan(&bookingFromDB.ID, &bookingFromDB.UserID)
-	}
-	
+// CancelBooking cancels a booking on behalf of its owner and charges the late
+// cancellation fee if the policy calls for one. Admins may cancel any booking
+// and waive the fee.
+func (s *BookingService) CancelBooking(id int, actor models.StatusActor, reason string, waiveFee bool) (*models.CancellationFee, error) {
+	booking, err := s.repo.GetBookingByID(id)
 	if err != nil {
-		return errors.New("booking not found")
+		return nil, errors.New("booking not found")
 	}
-	
-	bookingReq.ID = id
-	if userID > 0 {
-		bookingReq.UserID = &userID // Ensure we're updating the correct user's booking
-	} else {
-		bookingReq.UserID = bookingFromDB.UserID // Use the actual booking's user ID for admin updates
+
+	if actor.Role != models.RoleAdmin {
+		if actor.UserID == nil || booking.UserID == nil || *booking.UserID != *actor.UserID {
+			return nil, errors.New("booking not found")
+		}
+		waiveFee = false
 	}
-	return s.repo.UpdateBooking(bookingReq)
+
+	return s.cancel(booking, actor, reason, waiveFee)
 }
 
-func (s *BookingService) AdminUpdateBooking(id int, req *models.AdminBookingUpdateRequest) error {
+// cancel cancels a booking and bills the late cancellation fee unless it is
+// waived
+func (s *BookingService) cancel(booking *models.Booking, actor models.StatusActor, reason string, waiveFee bool) (*models.CancellationFee, error) {
+	cancellationService := NewCancellationService()
+	var percent float64
+	if !waiveFee {
+		var err error
+		percent, err = cancellationService.LateCancellationPercent(booking, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := NewBookingStatusService().ChangeStatus(booking.ID, "cancelled", actor, reason); err != nil {
+		return nil, err
+	}
+
+	// The booking is cancelled either way; a failed invoice update is
+	// fixed by hand rather than undoing the cancellation
+	fee, err := cancellationService.ChargeLateCancellation(booking, percent)
+	if err != nil {
+		log.Printf("Failed to bill cancellation fee for booking %d: %v", booking.ID, err)
+	}
+
+	return fee, nil
+}
+
+// AdminUpdateBooking changes any field of a booking. A new date or time moves
+// it like any reschedule: the slot is checked and the move recorded along with
+// the other changes. A larger size that makes an upcoming job longer is
+// checked against its slot too. Marking it as a no-show charges the no-show
+// fee, which is returned.
+func (s *BookingService) AdminUpdateBooking(id int, req *models.AdminBookingUpdateRequest, actor models.StatusActor) (*models.CancellationFee, error) {
 	// Get the existing booking first
 	existingBooking, err := s.repo.GetBookingByID(id)
 	if err != nil {
-		return errors.New("booking not found")
+		return nil, errors.New("booking not found")
 	}
 
 	// Fix the scheduled_time format if it's in PostgreSQL timestamp format
 	existingBooking.ScheduledTime = s.normalizeTimeFormat(existingBooking.ScheduledTime)
 
-	// Only update the fields that are provided
-	if req.Status != "" {
-		existingBooking.Status = req.Status
+	// Status changes go through the state machine after the other fields are
+	// saved, but are checked first so an invalid request changes nothing
+	statusChange := req.Status != "" && req.Status != existingBooking.Status
+	if statusChange && !CanTransition(existingBooking.Status, req.Status) {
+		return nil, errors.New("invalid status transition")
 	}
 	
+	date, clock := existingBooking.ScheduledDate.Format("2006-01-02"), existingBooking.ScheduledTime
 	if req.ScheduledDate != nil && *req.ScheduledDate != "" {
-		scheduledDate, err := time.Parse("2006-01-02", *req.ScheduledDate)
-		if err != nil {
-			return errors.New("invalid date format. Use YYYY-MM-DD")
-		}
-		existingBooking.ScheduledDate = scheduledDate
+		date = *req.ScheduledDate
 	}
-	
 	if req.ScheduledTime != nil && *req.ScheduledTime != "" {
-		existingBooking.ScheduledTime = s.normalizeTimeFormat(*req.ScheduledTime)
+		clock = s.normalizeTimeFormat(*req.ScheduledTime)
 	}
-	
+	moved := date != existingBooking.ScheduledDate.Format("2006-01-02") || clock != existingBooking.ScheduledTime
+	previousDuration := existingBooking.DurationHours
+
 	if req.Address != nil {
 		existingBooking.Address = *req.Address
 	}
 	
 	if req.SquareMeters != nil {
 		existingBooking.SquareMeters = *req.SquareMeters
+
+		service, err := NewServiceService().GetServiceByID(existingBooking.ServiceID)
+		if err != nil {
+			return nil, errors.New("service not found")
+		}
+		existingBooking.DurationHours = JobDurationHours(service.Duration, existingBooking.SquareMeters)
+		if existingBooking.PriceBreakdown != nil {
+			existingBooking.DurationHours += existingBooking.PriceBreakdown.AddOnHours
+		}
 	}
 	
 	if req.SpecialInstructions != nil {
@@ -313,7 +546,58 @@ func (s *BookingService) AdminUpdateBooking(id int, req *models.AdminBookingUpda
 		existingBooking.TotalPrice = *req.TotalPrice
 	}
 
-	return s.repo.UpdateBooking(existingBooking)
+	// Moves and longer jobs are checked against the booking's new duration
+	switch {
+	case moved:
+		err = s.move(existingBooking, date, clock, actor, "", true)
+	case existingBooking.DurationHours > previousDuration && reschedulableStatuses[existingBooking.Status]:
+		err = s.saveLonger(existingBooking)
+	default:
+		err = s.repo.UpdateBooking(existingBooking)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !statusChange {
+		return nil, nil
+	}
+
+	if err := NewBookingStatusService().ChangeStatus(id, req.Status, actor, req.StatusReason); err != nil {
+		return nil, err
+	}
+
+	if req.Status == "no_show" {
+		fee, err := NewCancellationService().ChargeNoShow(existingBooking)
+		if err != nil {
+			log.Printf("Failed to bill no-show fee for booking %d: %v", id, err)
+		}
+		return fee, nil
+	}
+
+	return nil, nil
+}
+
+// createInSlot stores a new booking if its time slot is still free. The check
+// and the insert run under the day's schedule lock so two customers can't
+// both take the last free crew.
+func (s *BookingService) createInSlot(booking *models.Booking, create func(*models.Booking) error) error {
+	unlock, err := s.repo.LockScheduleDate(booking.ScheduledDate)
+	if err != nil {
+		return errors.New("failed to create booking")
+	}
+	defer unlock()
+
+	err = NewSchedulingService().CheckAvailability(booking.ScheduledDate, booking.ScheduledTime, booking.DurationHours, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := create(booking); err != nil {
+		return errors.New("failed to create booking")
+	}
+
+	return nil
 }
 
 // Helper function to normalize time format for database storage
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"cleaning-app-backend/internal/config"
+	"cleaning-app-backend/internal/models"
+	"cleaning-app-backend/internal/repositories"
+	"cleaning-app-backend/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// TokenService issues access/refresh token pairs and implements refresh token
+// rotation. Every login starts a new token family; each refresh revokes the
+// presented token and issues a successor in the same family. Presenting a
+// token that was already rotated is treated as theft and revokes the family.
+type TokenService struct {
+	repo     *repositories.RefreshTokenRepository
+	userRepo *repositories.UserRepository
+	config   config.Config
+}
+
+func NewTokenService(cfg config.Config) *TokenService {
+	return &TokenService{
+		repo:     &repositories.RefreshTokenRepository{},
+		userRepo: &repositories.UserRepository{},
+		config:   cfg,
+	}
+}
+
+// IssueTokens creates an access token and a refresh token in a new family.
+func (s *TokenService) IssueTokens(userID int, role string) (*models.TokenPair, error) {
+	accessToken, err := utils.GenerateToken(userID, role, s.config)
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	refreshToken, err := s.newRefreshToken(userID, uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateRefreshToken(refreshToken.record); err != nil {
+		return nil, errors.New("failed to store refresh token")
+	}
+
+	return &models.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken.value}, nil
+}
+
+// RefreshTokens exchanges a valid refresh token for a new token pair.
+func (s *TokenService) RefreshTokens(refreshToken string) (*models.TokenPair, error) {
+	current, err := s.repo.GetRefreshTokenByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		s.repo.RevokeTokenFamily(current.FamilyID)
+		return nil, errors.New("refresh token reuse detected")
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, errors.New("refresh token expired")
+	}
+
+	user, err := s.userRepo.GetUserByID(current.UserID)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	next, err := s.newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RotateRefreshToken(current.ID, next.record); err != nil {
+		if err.Error() == "refresh token already used" {
+			s.repo.RevokeTokenFamily(current.FamilyID)
+			return nil, errors.New("refresh token reuse detected")
+		}
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateToken(user.ID, user.Role, s.config)
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	return &models.TokenPair{AccessToken: accessToken, RefreshToken: next.value}, nil
+}
+
+// RevokeFamily revokes every token in the family of the given refresh token.
+// Unknown tokens are ignored so that logout is idempotent.
+func (s *TokenService) RevokeFamily(refreshToken string) error {
+	token, err := s.repo.GetRefreshTokenByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	return s.repo.RevokeTokenFamily(token.FamilyID)
+}
+
+// RevokeAllForUser revokes every outstanding refresh token of a user.
+func (s *TokenService) RevokeAllForUser(userID int) error {
+	return s.repo.RevokeUserTokens(userID)
+}
+
+type issuedRefreshToken struct {
+	value  string
+	record *models.RefreshToken
+}
+
+func (s *TokenService) newRefreshToken(userID int, familyID string) (*issuedRefreshToken, error) {
+	expiry, err := time.ParseDuration(s.config.RefreshTokenExpiry)
+	if err != nil {
+		return nil, errors.New("invalid refresh token expiry")
+	}
+
+	value, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.New("failed to generate refresh token")
+	}
+
+	return &issuedRefreshToken{
+		value: value,
+		record: &models.RefreshToken{
+			UserID:    userID,
+			TokenHash: utils.HashToken(value),
+			FamilyID:  familyID,
+			ExpiresAt: time.Now().Add(expiry),
+		},
+	}, nil
+}
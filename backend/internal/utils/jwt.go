@@ -53,17 +53,8 @@ func ValidateToken(tokenString string, config config.Config) (*Claims, error) {
 	return claims, nil
 }
 
-func GenerateRefreshToken(userID int, config config.Config) (string, error) {
-	// Refresh token has a longer expiry time
-	expirationTime := time.Now().Add(7 * 24 * time.Hour) // 7 days
-	claims := &Claims{
-		UserID: userID,
-		Role:   "", // We don't need role in refresh token, we'll get it from DB
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.JWTSecret))
+// GenerateRefreshToken returns an opaque refresh token. Refresh tokens are
+// tracked server-side (see services.TokenService), so they carry no claims.
+func GenerateRefreshToken() (string, error) {
+	return GenerateSecureToken(32)
 }
\ No newline at end of file
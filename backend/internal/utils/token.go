@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GenerateSecureToken returns a URL-safe random token built from n random bytes.
+func GenerateSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of a token, which is what gets
+// stored in the database instead of the token itself.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignGuestToken returns a token that grants access to one guest booking until
+// expiresAt. The signature also covers the email the booking was made with, so
+// changing that email invalidates every link issued before.
+func SignGuestToken(bookingID int, email string, expiresAt time.Time, secret string) string {
+	payload := fmt.Sprintf("%d.%d", bookingID, expiresAt.Unix())
+	return payload + "." + guestTokenSignature(payload, email, secret)
+}
+
+// ParseGuestToken returns the booking a guest token was issued for and when it
+// expires. It does not check the signature, which needs the booking's email;
+// use VerifyGuestToken once the booking is loaded.
+func ParseGuestToken(token string) (int, time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, time.Time{}, errors.New("malformed token")
+	}
+
+	bookingID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, time.Time{}, errors.New("malformed token")
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, errors.New("malformed token")
+	}
+
+	return bookingID, time.Unix(expires, 0), nil
+}
+
+// VerifyGuestToken reports whether a guest token was signed for the given email
+func VerifyGuestToken(token, email, secret string) bool {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return false
+	}
+	expected := guestTokenSignature(token[:i], email, secret)
+	return hmac.Equal([]byte(token[i+1:]), []byte(expected))
+}
+
+// guestTokenSignature prefixes the signed data so a guest token signature can
+// never be mistaken for one made with the same secret for another purpose
+func guestTokenSignature(payload, email, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("guest-booking." + payload + "." + strings.ToLower(email)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,198 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes why a single request field was rejected. Field is the
+// JSON path of the field, e.g. "items[0].quantity".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+const dateLayout = "2006-01-02"
+
+// Register makes gin enforce the `validate` struct tags used by the request
+// models and adds the domain checks on top of the built-in rules:
+//
+//	date     a YYYY-MM-DD date
+//	notpast  a YYYY-MM-DD date that is today or later (combine with date)
+//	clock    a time of day as HH:MM or HH:MM:SS
+//
+// It must be called once before the router starts serving.
+func Register() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+
+	v.SetTagName("validate")
+	v.RegisterTagNameFunc(jsonName)
+
+	validations := map[string]validator.Func{
+		"date":    isDate,
+		"notpast": isNotPast,
+		"clock":   isClock,
+	}
+	for tag, fn := range validations {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Errors turns a binding error into per-field errors. It returns nil when the
+// error is not about specific fields, such as malformed JSON.
+func Errors(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]FieldError, len(validationErrors))
+		for i, fe := range validationErrors {
+			fields[i] = FieldError{Field: fieldPath(fe), Message: message(fe)}
+		}
+		return fields
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		return []FieldError{{Field: typeError.Field, Message: "must be " + typeName(typeError.Type)}}
+	}
+
+	return nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// fieldPath drops the request type from the namespace, which is meaningless
+// to API clients
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return "is required when " + snakeCase(fe.Param()) + " is not given"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	case "min", "max":
+		bound := "at least"
+		if fe.Tag() == "max" {
+			bound = "at most"
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be %s %s characters long", bound, fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have %s %s items", bound, fe.Param())
+		}
+		return fmt.Sprintf("must be %s %s", bound, fe.Param())
+	case "date":
+		return "must be a date in YYYY-MM-DD format"
+	case "notpast":
+		return "must not be in the past"
+	case "clock":
+		return "must be a time in HH:MM format"
+	case "hexcolor":
+		return "must be a hex color such as #4CAF50"
+	}
+	return "is invalid"
+}
+
+// snakeCase turns the Go field name a rule refers to, e.g. "PropertyID", into
+// the JSON name clients know it by, "property_id"
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && i > 0 {
+			prevLower := runes[i-1] >= 'a' && runes[i-1] <= 'z'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteString(strings.ToLower(string(r)))
+	}
+	return b.String()
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	}
+	return "an object"
+}
+
+func isDate(fl validator.FieldLevel) bool {
+	_, err := time.Parse(dateLayout, fl.Field().String())
+	return err == nil
+}
+
+// isNotPast leaves malformed dates to the date rule
+func isNotPast(fl validator.FieldLevel) bool {
+	date, err := time.ParseInLocation(dateLayout, fl.Field().String(), time.Local)
+	if err != nil {
+		return true
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	return !date.Before(today)
+}
+
+func isClock(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+-- Migration: Add cleaner (field staff) role and booking assignments
+-- Date: 2026-10-16
+-- Description: Users with role 'cleaner' get their own API surface and only see
+-- bookings they are assigned to through booking_assignments.
+
+CREATE TABLE booking_assignments (
+    id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    assigned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (booking_id, user_id)
+);
+
+CREATE INDEX idx_booking_assignments_user_id ON booking_assignments(user_id);
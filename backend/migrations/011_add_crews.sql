@@ -0,0 +1,24 @@
+-- Migration: Add crews and crew-based booking assignment
+-- Date: 2026-10-16
+-- Description: Cleaners can be grouped into crews. Assigning a crew to a
+-- booking assigns each of its members; crew_id records where an assignment
+-- came from so the calendar can show per-crew lanes.
+
+CREATE TABLE crews (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#2196F3',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE crew_members (
+    crew_id INTEGER NOT NULL REFERENCES crews(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (crew_id, user_id)
+);
+
+CREATE INDEX idx_crew_members_user_id ON crew_members(user_id);
+
+ALTER TABLE booking_assignments ADD COLUMN crew_id INTEGER REFERENCES crews(id) ON DELETE SET NULL;
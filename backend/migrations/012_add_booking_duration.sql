@@ -0,0 +1,17 @@
+-- Migration: Store the expected duration of each booking
+-- Date: 2026-10-16
+-- Description: A job's length is the service's duration_hours for up to
+-- 100 square meters, scaled linearly above that and rounded up to the next
+-- half hour. Storing it lets slot computation and the calendar use the real
+-- length of every job instead of guessing from the service name.
+
+ALTER TABLE bookings ADD COLUMN duration_hours DECIMAL(5, 2);
+
+UPDATE bookings b
+SET duration_hours = CEIL(s.duration_hours * GREATEST(b.square_meters / 100.0, 1) * 2) / 2
+FROM services s
+WHERE b.service_id = s.id;
+
+ALTER TABLE bookings ALTER COLUMN duration_hours SET NOT NULL;
+
+CREATE INDEX idx_bookings_scheduled_date ON bookings(scheduled_date);
@@ -0,0 +1,41 @@
+-- Migration: Admin-managed business hours and schedule exceptions
+-- Date: 2026-10-16
+-- Description: Weekly opening hours per weekday (0 = Sunday) replace the
+-- hardcoded 9-18 window. schedule_exceptions override the weekly hours for a
+-- date range: closed for holidays and blackout dates, or different hours for
+-- one-off extended days. When ranges overlap the shortest one wins.
+
+CREATE TABLE business_hours (
+    weekday SMALLINT PRIMARY KEY CHECK (weekday BETWEEN 0 AND 6),
+    is_open BOOLEAN NOT NULL DEFAULT TRUE,
+    opens_at TIME NOT NULL DEFAULT '09:00',
+    closes_at TIME NOT NULL DEFAULT '18:00',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (opens_at < closes_at)
+);
+
+INSERT INTO business_hours (weekday, is_open, opens_at, closes_at) VALUES
+(0, FALSE, '09:00', '18:00'),
+(1, TRUE, '09:00', '18:00'),
+(2, TRUE, '09:00', '18:00'),
+(3, TRUE, '09:00', '18:00'),
+(4, TRUE, '09:00', '18:00'),
+(5, TRUE, '09:00', '18:00'),
+(6, TRUE, '09:00', '18:00')
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE schedule_exceptions (
+    id SERIAL PRIMARY KEY,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    is_closed BOOLEAN NOT NULL DEFAULT TRUE,
+    opens_at TIME,
+    closes_at TIME,
+    reason VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (start_date <= end_date),
+    CHECK (is_closed OR (opens_at IS NOT NULL AND closes_at IS NOT NULL AND opens_at < closes_at))
+);
+
+CREATE INDEX idx_schedule_exceptions_dates ON schedule_exceptions(start_date, end_date);
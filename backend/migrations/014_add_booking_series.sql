@@ -0,0 +1,37 @@
+-- Migration: Recurring booking series
+-- Date: 2026-10-16
+-- Description: A series describes a repeating cleaning (weekly, biweekly or
+-- monthly) and generates its occurrences as ordinary bookings a few weeks
+-- ahead. occurrence_date is the date the series planned an occurrence for;
+-- it stays fixed when a single occurrence is moved, so regeneration never
+-- creates it twice. Skipped occurrences get the new 'skipped' status.
+
+CREATE TABLE booking_series (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    service_id INTEGER NOT NULL REFERENCES services(id),
+    frequency VARCHAR(20) NOT NULL CHECK (frequency IN ('weekly', 'biweekly', 'monthly')),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    scheduled_time TIME NOT NULL,
+    address TEXT NOT NULL,
+    square_meters DECIMAL(8, 2) NOT NULL,
+    special_instructions TEXT,
+    discount_percent DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'cancelled')),
+    generated_until DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date IS NULL OR end_date >= start_date)
+);
+
+CREATE INDEX idx_booking_series_user_id ON booking_series(user_id);
+
+ALTER TABLE bookings
+    ADD COLUMN series_id INTEGER REFERENCES booking_series(id) ON DELETE SET NULL,
+    ADD COLUMN occurrence_date DATE,
+    ADD CONSTRAINT bookings_series_occurrence_unique UNIQUE (series_id, occurrence_date);
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('pending', 'confirmed', 'in_progress', 'completed', 'cancelled', 'skipped'));
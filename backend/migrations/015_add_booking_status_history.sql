@@ -0,0 +1,17 @@
+-- Migration: Booking status history
+-- Date: 2026-10-16
+-- Description: Every booking status change is recorded with who made it, in
+-- which role and why. changed_by is NULL for system changes and guests.
+
+CREATE TABLE booking_status_history (
+    id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    actor_role VARCHAR(20) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_booking_status_history_booking_id ON booking_status_history(booking_id);
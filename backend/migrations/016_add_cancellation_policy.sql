@@ -0,0 +1,22 @@
+-- Migration: Cancellation policy and no-show status
+-- Date: 2026-10-16
+-- Description: A single-row policy table holds the free cancellation window
+-- and the late cancellation and no-show fees, both as a percentage of the
+-- booking price. Bookings get a no_show status for customers who weren't
+-- there when the crew arrived.
+
+CREATE TABLE cancellation_policy (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    free_cancellation_hours INT NOT NULL DEFAULT 24 CHECK (free_cancellation_hours >= 0),
+    late_cancellation_fee_percent DECIMAL(5,2) NOT NULL DEFAULT 50.00
+        CHECK (late_cancellation_fee_percent BETWEEN 0 AND 100),
+    no_show_fee_percent DECIMAL(5,2) NOT NULL DEFAULT 100.00
+        CHECK (no_show_fee_percent BETWEEN 0 AND 100),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO cancellation_policy (id) VALUES (1) ON CONFLICT DO NOTHING;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('pending', 'confirmed', 'in_progress', 'completed', 'cancelled', 'skipped', 'no_show'));
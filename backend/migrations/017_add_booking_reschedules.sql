@@ -0,0 +1,23 @@
+-- Migration: Customer rescheduling
+-- Date: 2026-10-16
+-- Description: Every reschedule is recorded with the date and time it moved
+-- from, who moved it and why. Customers can only reschedule until the cutoff,
+-- kept with the other cancellation terms (2 hours, as the FAQ promises).
+
+CREATE TABLE booking_reschedules (
+    id SERIAL PRIMARY KEY,
+    booking_id INT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    from_date DATE NOT NULL,
+    from_time TIME NOT NULL,
+    to_date DATE NOT NULL,
+    to_time TIME NOT NULL,
+    changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    actor_role VARCHAR(20) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_booking_reschedules_booking_id ON booking_reschedules(booking_id);
+
+ALTER TABLE cancellation_policy
+    ADD COLUMN reschedule_cutoff_hours INT NOT NULL DEFAULT 2 CHECK (reschedule_cutoff_hours >= 0);
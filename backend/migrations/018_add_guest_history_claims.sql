@@ -0,0 +1,26 @@
+-- Migration: Claim guest history
+-- Date: 2026-10-16
+-- Description: Guest bookings, quotes and invoices made with an email are
+-- linked to the account that verifies that email. Quotes and invoices get an
+-- owner for that; invoices of bookings already follow their booking. Every
+-- claim is recorded, including merges an admin makes for another email.
+
+ALTER TABLE quotes ADD COLUMN user_id INT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE invoices ADD COLUMN user_id INT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_quotes_user_id ON quotes(user_id);
+CREATE INDEX idx_invoices_user_id ON invoices(user_id);
+CREATE INDEX idx_bookings_guest_email ON bookings(LOWER(guest_email)) WHERE user_id IS NULL;
+
+CREATE TABLE guest_claims (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    bookings INT NOT NULL DEFAULT 0,
+    quotes INT NOT NULL DEFAULT 0,
+    invoices INT NOT NULL DEFAULT 0,
+    claimed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_guest_claims_user_id ON guest_claims(user_id);
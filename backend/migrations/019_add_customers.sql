@@ -0,0 +1,126 @@
+-- Migration: Customers
+-- Date: 2026-10-16
+-- Description: One customer record per client, whether they have an account,
+-- booked as a guest, asked for a quote or wrote in. Bookings, quotes, invoices
+-- and contact messages point at it. A customer has extra contacts, service
+-- properties, a billing profile, tags and staff notes. Merged duplicates are
+-- kept with merged_into_id set so old links can be followed.
+
+CREATE TABLE customers (
+    id SERIAL PRIMARY KEY,
+    user_id INT UNIQUE REFERENCES users(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    company VARCHAR(255),
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    billing_address VARCHAR(255),
+    billing_city VARCHAR(100),
+    billing_state VARCHAR(50),
+    billing_zip_code VARCHAR(20),
+    billing_country VARCHAR(100),
+    tax_exempt BOOLEAN NOT NULL DEFAULT FALSE,
+    tax_exempt_reason VARCHAR(255),
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    merged_into_id INT REFERENCES customers(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_customers_email ON customers(LOWER(email));
+CREATE INDEX idx_customers_tags ON customers USING GIN (tags);
+
+-- Other people to reach about the customer's jobs: a partner, a property
+-- manager, an accounts payable address
+CREATE TABLE customer_contacts (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    name VARCHAR(255),
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    label VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_customer_contacts_customer_id ON customer_contacts(customer_id);
+CREATE INDEX idx_customer_contacts_email ON customer_contacts(LOWER(email));
+
+CREATE TABLE customer_properties (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    label VARCHAR(100),
+    address VARCHAR(255) NOT NULL,
+    square_meters DECIMAL(8, 2),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_customer_properties_customer_id ON customer_properties(customer_id);
+
+CREATE TABLE customer_notes (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    author_id INT REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_customer_notes_customer_id ON customer_notes(customer_id);
+
+ALTER TABLE bookings ADD COLUMN customer_id INT REFERENCES customers(id) ON DELETE SET NULL;
+ALTER TABLE quotes ADD COLUMN customer_id INT REFERENCES customers(id) ON DELETE SET NULL;
+ALTER TABLE invoices ADD COLUMN customer_id INT REFERENCES customers(id) ON DELETE SET NULL;
+ALTER TABLE contact_messages ADD COLUMN customer_id INT REFERENCES customers(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_bookings_customer_id ON bookings(customer_id);
+CREATE INDEX idx_quotes_customer_id ON quotes(customer_id);
+CREATE INDEX idx_invoices_customer_id ON invoices(customer_id);
+CREATE INDEX idx_contact_messages_customer_id ON contact_messages(customer_id);
+
+-- Every client account is a customer
+INSERT INTO customers (user_id, name, email, phone, created_at)
+SELECT id, TRIM(first_name || ' ' || last_name), email, phone, created_at
+FROM users WHERE role = 'client';
+
+-- Everyone else becomes a customer per email, named after their first record
+INSERT INTO customers (name, email, phone, created_at)
+SELECT DISTINCT ON (LOWER(email)) name, email, NULLIF(phone, ''), created_at
+FROM (
+    SELECT guest_name AS name, guest_email AS email, guest_phone AS phone, created_at
+    FROM bookings WHERE user_id IS NULL
+    UNION ALL
+    SELECT contact_name, contact_email, contact_phone, created_at FROM quotes WHERE user_id IS NULL
+    UNION ALL
+    SELECT name, email, phone, created_at FROM contact_messages
+    UNION ALL
+    SELECT customer_name, customer_email, customer_phone, created_at FROM invoices WHERE user_id IS NULL
+) people
+WHERE COALESCE(email, '') <> ''
+  AND NOT EXISTS (SELECT 1 FROM customers c WHERE LOWER(c.email) = LOWER(people.email))
+ORDER BY LOWER(email), created_at;
+
+UPDATE bookings b SET customer_id = c.id
+FROM customers c
+WHERE b.customer_id IS NULL AND b.user_id IS NOT NULL AND c.user_id = b.user_id;
+
+UPDATE bookings b SET customer_id = c.id
+FROM customers c
+WHERE b.customer_id IS NULL AND b.user_id IS NULL AND LOWER(c.email) = LOWER(b.guest_email);
+
+UPDATE quotes q SET customer_id = c.id
+FROM customers c
+WHERE q.customer_id IS NULL AND q.user_id IS NOT NULL AND c.user_id = q.user_id;
+
+UPDATE quotes q SET customer_id = c.id
+FROM customers c
+WHERE q.customer_id IS NULL AND LOWER(c.email) = LOWER(q.contact_email);
+
+UPDATE invoices i SET customer_id = b.customer_id
+FROM bookings b
+WHERE i.booking_id = b.id AND b.customer_id IS NOT NULL;
+
+UPDATE invoices i SET customer_id = c.id
+FROM customers c
+WHERE i.customer_id IS NULL AND LOWER(c.email) = LOWER(i.customer_email);
+
+UPDATE contact_messages m SET customer_id = c.id
+FROM customers c
+WHERE LOWER(c.email) = LOWER(m.email);
@@ -0,0 +1,62 @@
+-- Migration: Structured service properties
+-- Date: 2026-10-16
+-- Description: Customer properties get a structured address, access details
+-- for the crew and room counts. Bookings, quotes and invoices point at the
+-- property they are for; their address column keeps the address as it was at
+-- the time. Existing properties keep their one-line address as the street
+-- until someone fills in the rest.
+
+ALTER TABLE customer_properties
+    ADD COLUMN street VARCHAR(255),
+    ADD COLUMN unit VARCHAR(50),
+    ADD COLUMN city VARCHAR(100),
+    ADD COLUMN state VARCHAR(50),
+    ADD COLUMN zip_code VARCHAR(20),
+    ADD COLUMN access_notes TEXT,
+    ADD COLUMN gate_code VARCHAR(100),
+    ADD COLUMN pets TEXT,
+    ADD COLUMN bedrooms INT CHECK (bedrooms >= 0),
+    ADD COLUMN bathrooms DECIMAL(3, 1) CHECK (bathrooms >= 0),
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE customer_properties SET street = address WHERE street IS NULL;
+ALTER TABLE customer_properties ALTER COLUMN street SET NOT NULL;
+
+CREATE INDEX idx_customer_properties_address ON customer_properties(customer_id, LOWER(address));
+
+ALTER TABLE bookings ADD COLUMN property_id INT REFERENCES customer_properties(id) ON DELETE SET NULL;
+ALTER TABLE quotes ADD COLUMN property_id INT REFERENCES customer_properties(id) ON DELETE SET NULL;
+ALTER TABLE invoices ADD COLUMN property_id INT REFERENCES customer_properties(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_bookings_property_id ON bookings(property_id);
+CREATE INDEX idx_quotes_property_id ON quotes(property_id);
+CREATE INDEX idx_invoices_property_id ON invoices(property_id);
+
+-- Every address a customer has booked or asked a quote for becomes one of
+-- their properties
+INSERT INTO customer_properties (customer_id, street, address, square_meters, created_at)
+SELECT DISTINCT ON (customer_id, LOWER(TRIM(address))) customer_id, TRIM(address), TRIM(address), square_meters, created_at
+FROM (
+    SELECT customer_id, address, square_meters, created_at FROM bookings
+    WHERE customer_id IS NOT NULL AND COALESCE(is_custom_invoice, false) = false
+    UNION ALL
+    SELECT customer_id, address, square_meters, created_at FROM quotes WHERE customer_id IS NOT NULL
+) addresses
+WHERE COALESCE(TRIM(address), '') <> ''
+  AND NOT EXISTS (
+      SELECT 1 FROM customer_properties p
+      WHERE p.customer_id = addresses.customer_id AND LOWER(p.address) = LOWER(TRIM(addresses.address))
+  )
+ORDER BY customer_id, LOWER(TRIM(address)), created_at DESC;
+
+UPDATE bookings b SET property_id = p.id
+FROM customer_properties p
+WHERE p.customer_id = b.customer_id AND LOWER(p.address) = LOWER(TRIM(b.address));
+
+UPDATE quotes q SET property_id = p.id
+FROM customer_properties p
+WHERE p.customer_id = q.customer_id AND LOWER(p.address) = LOWER(TRIM(q.address));
+
+UPDATE invoices i SET property_id = b.property_id
+FROM bookings b
+WHERE i.booking_id = b.id AND b.property_id IS NOT NULL;
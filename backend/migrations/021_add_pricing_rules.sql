@@ -0,0 +1,43 @@
+-- Migration: Pricing rules and add-ons
+-- Date: 2026-10-16
+-- Description: Services get optional pricing rules: a price per bedroom and
+-- bathroom, flat prices by size tier and a minimum charge. Services without
+-- rules keep the base price per 50 square meters with the base price as the
+-- minimum. Add-ons are extra tasks priced on top. Bookings and quotes store
+-- the itemized price they were made at.
+
+CREATE TABLE service_pricing (
+    service_id INT PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    price_per_bedroom DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (price_per_bedroom >= 0),
+    price_per_bathroom DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (price_per_bathroom >= 0),
+    minimum_charge DECIMAL(10,2) CHECK (minimum_charge >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE service_size_tiers (
+    id SERIAL PRIMARY KEY,
+    service_id INT NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    up_to_square_meters DECIMAL(8,2) NOT NULL CHECK (up_to_square_meters > 0),
+    price DECIMAL(10,2) NOT NULL CHECK (price >= 0),
+    UNIQUE (service_id, up_to_square_meters)
+);
+
+CREATE TABLE add_ons (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    price DECIMAL(10,2) NOT NULL CHECK (price >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO add_ons (code, name, price) VALUES
+('inside_fridge', 'Inside fridge', 35.00),
+('inside_oven', 'Inside oven', 35.00),
+('windows', 'Interior windows', 50.00),
+('laundry', 'Laundry (wash and fold)', 25.00)
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE bookings ADD COLUMN price_breakdown JSONB;
+ALTER TABLE quotes ADD COLUMN price_breakdown JSONB;
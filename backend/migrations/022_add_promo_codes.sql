@@ -0,0 +1,38 @@
+-- Migration: Promo codes
+-- Date: 2026-10-16
+-- Description: Admin-managed promo codes taking a percentage or a fixed
+-- amount off a booking, with an optional minimum order, validity dates,
+-- usage limits per code and per customer, first-booking-only codes and
+-- service restrictions. Each use is recorded against the booking.
+
+CREATE TABLE promo_codes (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    discount_type VARCHAR(10) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value DECIMAL(10,2) NOT NULL CHECK (discount_value > 0),
+    min_order DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (min_order >= 0),
+    starts_on DATE,
+    ends_on DATE,
+    max_uses INT CHECK (max_uses > 0),
+    max_uses_per_customer INT CHECK (max_uses_per_customer > 0),
+    first_booking_only BOOLEAN NOT NULL DEFAULT FALSE,
+    service_ids INT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (discount_type <> 'percent' OR discount_value <= 100),
+    CHECK (ends_on IS NULL OR starts_on IS NULL OR ends_on >= starts_on)
+);
+
+CREATE TABLE promo_redemptions (
+    id SERIAL PRIMARY KEY,
+    promo_code_id INT NOT NULL REFERENCES promo_codes(id),
+    booking_id INT NOT NULL UNIQUE REFERENCES bookings(id) ON DELETE CASCADE,
+    customer_id INT REFERENCES customers(id),
+    discount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_promo_redemptions_promo_code ON promo_redemptions(promo_code_id);
+CREATE INDEX idx_promo_redemptions_customer ON promo_redemptions(customer_id);
@@ -0,0 +1,46 @@
+-- Migration: Service catalog
+-- Date: 2026-10-17
+-- Description: Services belong to admin-managed categories instead of the
+-- fixed residential/commercial types, so Airbnb and post-renovation cleaning
+-- can be offered. Add-ons take time as well as money, and each service lists
+-- the add-ons that can be booked with it. Services are archived instead of
+-- deleted, so their bookings stay.
+
+CREATE TABLE service_categories (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO service_categories (slug, name, sort_order) VALUES
+('residential', 'Residential', 1),
+('commercial', 'Commercial', 2),
+('airbnb', 'Airbnb', 3),
+('post-renovation', 'Post Renovation', 4)
+ON CONFLICT (slug) DO NOTHING;
+
+ALTER TABLE services ADD COLUMN category_id INT REFERENCES service_categories(id);
+UPDATE services s SET category_id = c.id FROM service_categories c WHERE c.slug = s.service_type;
+ALTER TABLE services ALTER COLUMN category_id SET NOT NULL;
+ALTER TABLE services DROP COLUMN service_type;
+
+ALTER TABLE services ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE add_ons ADD COLUMN duration_hours DECIMAL(4,2) NOT NULL DEFAULT 0 CHECK (duration_hours >= 0);
+UPDATE add_ons SET duration_hours = 0.5 WHERE code IN ('inside_fridge', 'inside_oven');
+UPDATE add_ons SET duration_hours = 1 WHERE code IN ('windows', 'laundry');
+
+CREATE TABLE service_add_ons (
+    service_id INT NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    add_on_id INT NOT NULL REFERENCES add_ons(id) ON DELETE CASCADE,
+    PRIMARY KEY (service_id, add_on_id)
+);
+
+-- Every existing add-on stays bookable with every existing service
+INSERT INTO service_add_ons (service_id, add_on_id)
+SELECT s.id, a.id FROM services s CROSS JOIN add_ons a
+ON CONFLICT DO NOTHING;
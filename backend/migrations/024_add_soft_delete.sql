@@ -0,0 +1,28 @@
+-- Migration: Soft delete
+-- Date: 2026-10-17
+-- Description: Invoices are financial records that must be retained, and
+-- deleting a service used to wipe its bookings and, through them, their
+-- invoices. Invoices and FAQs are archived instead of deleted, and the
+-- foreign keys that cascaded into bookings and invoices now refuse the
+-- delete.
+
+ALTER TABLE invoices ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE faqs ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE bookings DROP CONSTRAINT bookings_service_id_fkey;
+ALTER TABLE bookings ADD CONSTRAINT bookings_service_id_fkey
+    FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE RESTRICT;
+
+ALTER TABLE quotes DROP CONSTRAINT quotes_service_id_fkey;
+ALTER TABLE quotes ADD CONSTRAINT quotes_service_id_fkey
+    FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE RESTRICT;
+
+ALTER TABLE invoices DROP CONSTRAINT invoices_booking_id_fkey;
+ALTER TABLE invoices ADD CONSTRAINT invoices_booking_id_fkey
+    FOREIGN KEY (booking_id) REFERENCES bookings(id) ON DELETE RESTRICT;
+
+ALTER TABLE invoice_items DROP CONSTRAINT invoice_items_invoice_id_fkey;
+ALTER TABLE invoice_items ADD CONSTRAINT invoice_items_invoice_id_fkey
+    FOREIGN KEY (invoice_id) REFERENCES invoices(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_invoices_archived_at ON invoices(archived_at);
@@ -0,0 +1,19 @@
+-- Migration: Quote lifecycle
+-- Date: 2026-10-17
+-- Description: Sending a quote mails the customer a link to it. Only the
+-- SHA-256 hash of the link's token is stored. The customer accepts the quote
+-- by picking a slot, which books the job at the quoted price, or rejects it.
+-- A sent quote nobody answers expires.
+
+ALTER TABLE quotes ADD COLUMN access_token_hash VARCHAR(64) UNIQUE;
+ALTER TABLE quotes ADD COLUMN sent_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE quotes ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE quotes ADD COLUMN responded_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE quotes ADD COLUMN rejection_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE quotes ADD COLUMN booking_id INT REFERENCES bookings(id) ON DELETE RESTRICT;
+
+ALTER TABLE quotes DROP CONSTRAINT quotes_status_check;
+ALTER TABLE quotes ADD CONSTRAINT quotes_status_check
+    CHECK (status IN ('pending', 'sent', 'accepted', 'rejected', 'expired'));
+
+CREATE INDEX idx_quotes_expires_at ON quotes(expires_at) WHERE status = 'sent';
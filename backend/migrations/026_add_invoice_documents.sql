@@ -0,0 +1,29 @@
+-- Migration: Invoice documents
+-- Date: 2026-10-17
+-- Description: Keeps every PDF rendered for an invoice. A new version is
+-- stored only when the invoice changes, and stored versions can't be changed
+-- or deleted, so what the customer was sent can always be produced again.
+
+CREATE TABLE invoice_documents (
+    id SERIAL PRIMARY KEY,
+    invoice_id INT NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    version INT NOT NULL,
+    pdf BYTEA NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    -- The invoice's updated_at when this version was rendered
+    invoice_updated_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (invoice_id, version)
+);
+
+CREATE OR REPLACE FUNCTION prevent_invoice_document_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'invoice documents are immutable';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER invoice_documents_immutable
+    BEFORE UPDATE OR DELETE ON invoice_documents
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_invoice_document_changes();
@@ -0,0 +1,36 @@
+-- Migration: Document numbering
+-- Date: 2026-10-17
+-- Description: Invoices are numbered by a configurable scheme. Numbers are
+-- taken from a counter per period inside the transaction that creates the
+-- invoice, so concurrent invoices wait for each other and a failed one
+-- doesn't leave a gap.
+
+CREATE TABLE numbering_schemes (
+    document_type VARCHAR(30) PRIMARY KEY,
+    format VARCHAR(40) NOT NULL,
+    reset VARCHAR(10) NOT NULL CHECK (reset IN ('yearly', 'monthly', 'never')),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Keep the format the admin invoice screens have been using. It always had
+-- one sequence per year.
+INSERT INTO numbering_schemes (document_type, format, reset)
+VALUES ('invoice', 'PP-INV-{YYYY}-{MM}-{SEQ:3}', 'yearly');
+
+CREATE TABLE document_sequences (
+    document_type VARCHAR(30) NOT NULL REFERENCES numbering_schemes(document_type),
+    -- YYYY for yearly numbering, YYYY-MM for monthly, empty for never
+    period VARCHAR(7) NOT NULL,
+    last_value INT NOT NULL,
+    PRIMARY KEY (document_type, period)
+);
+
+-- Continue each year after the highest number already issued in it.
+-- Numbers from the older PP-YYYY-NNNNN scheme can't collide with the format
+-- and keep their numbers.
+INSERT INTO document_sequences (document_type, period, last_value)
+SELECT 'invoice', SUBSTRING(invoice_number FROM '^PP-INV-([0-9]{4})-'),
+       MAX(CAST(SUBSTRING(invoice_number FROM '([0-9]+)$') AS INTEGER))
+FROM invoices
+WHERE invoice_number ~ '^PP-INV-[0-9]{4}-[0-9]{2}-[0-9]+$'
+GROUP BY 1;
@@ -0,0 +1,48 @@
+-- Migration: Payments
+-- Date: 2026-10-17
+-- Description: An invoice can be paid in several payments. Its balance is
+-- what the payments leave owing, and it becomes partially_paid or paid as
+-- they come in. Paying more than the balance turns the excess into credit
+-- for the customer, which can pay later invoices.
+
+ALTER TABLE invoices DROP CONSTRAINT invoices_status_check;
+ALTER TABLE invoices ADD CONSTRAINT invoices_status_check
+    CHECK (status IN ('pending', 'partially_paid', 'paid', 'overdue', 'cancelled'));
+
+CREATE TABLE payments (
+    id SERIAL PRIMARY KEY,
+    invoice_id INT NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    -- What was received; credited is the part of it that went to the
+    -- customer's credit rather than the invoice
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    credited DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (credited >= 0 AND credited <= amount),
+    method VARCHAR(50) NOT NULL DEFAULT '',
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    paid_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    notes TEXT NOT NULL DEFAULT '',
+    recorded_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payments_invoice_id ON payments(invoice_id);
+CREATE INDEX idx_payments_paid_at ON payments(paid_at);
+
+-- Credit earned from overpayments (positive) and spent on invoices
+-- (negative). A customer's credit is the sum.
+CREATE TABLE customer_credits (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id) ON DELETE RESTRICT,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount <> 0),
+    payment_id INT REFERENCES payments(id) ON DELETE RESTRICT,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_customer_credits_customer_id ON customer_credits(customer_id);
+
+-- Invoices paid so far were paid in full, in one payment
+INSERT INTO payments (invoice_id, amount, method, reference, paid_at)
+SELECT id, total_amount, COALESCE(payment_method, ''), COALESCE(payment_reference, ''),
+       COALESCE(payment_date, updated_at)
+FROM invoices
+WHERE status = 'paid' AND total_amount > 0;
@@ -0,0 +1,157 @@
+-- Migration: Credit notes, refunds and voiding
+-- Date: 2026-10-17
+-- Description: An issued invoice can no longer be edited or deleted. It is
+-- corrected by credit notes, which have their own numbers and PDFs, and an
+-- invoice that shouldn't have been issued is voided, keeping the record.
+-- Money given back is recorded as a refund of the payment it came from.
+-- Payments, refunds and credit notes can't be changed once recorded either.
+
+ALTER TABLE invoices DROP CONSTRAINT invoices_status_check;
+ALTER TABLE invoices ADD CONSTRAINT invoices_status_check
+    CHECK (status IN ('pending', 'partially_paid', 'paid', 'overdue', 'cancelled', 'credited', 'void'));
+
+ALTER TABLE invoices ADD COLUMN voided_at TIMESTAMP;
+ALTER TABLE invoices ADD COLUMN void_reason TEXT;
+
+CREATE TABLE credit_notes (
+    id SERIAL PRIMARY KEY,
+    credit_note_number VARCHAR(50) UNIQUE NOT NULL,
+    invoice_id INT NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    issue_date DATE NOT NULL,
+    reason TEXT NOT NULL,
+    subtotal DECIMAL(10,2) NOT NULL,
+    tax_rate DECIMAL(5,4) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(10,2) NOT NULL CHECK (total_amount > 0),
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_credit_notes_invoice_id ON credit_notes(invoice_id);
+
+CREATE TABLE credit_note_items (
+    id SERIAL PRIMARY KEY,
+    credit_note_id INT NOT NULL REFERENCES credit_notes(id) ON DELETE RESTRICT,
+    description TEXT NOT NULL,
+    quantity DECIMAL(10,2) NOT NULL DEFAULT 1,
+    unit_price DECIMAL(10,2) NOT NULL,
+    total_price DECIMAL(10,2) NOT NULL,
+    taxable BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE INDEX idx_credit_note_items_credit_note_id ON credit_note_items(credit_note_id);
+
+-- The PDF of a credit note, rendered once when it is first downloaded
+CREATE TABLE credit_note_documents (
+    credit_note_id INT PRIMARY KEY REFERENCES credit_notes(id) ON DELETE RESTRICT,
+    pdf BYTEA NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE refunds (
+    id SERIAL PRIMARY KEY,
+    payment_id INT NOT NULL REFERENCES payments(id) ON DELETE RESTRICT,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    method VARCHAR(50) NOT NULL DEFAULT '',
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    refunded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    recorded_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refunds_payment_id ON refunds(payment_id);
+CREATE INDEX idx_refunds_refunded_at ON refunds(refunded_at);
+
+-- What each invoice has been paid, net of refunds, and credited, and what
+-- that leaves owing. A negative balance is owed back to the customer.
+CREATE VIEW invoice_balances AS
+SELECT i.id AS invoice_id,
+       COALESCE(p.paid, 0) - COALESCE(r.refunded, 0) AS amount_paid,
+       COALESCE(c.credited, 0) AS amount_credited,
+       i.total_amount - COALESCE(p.paid, 0) + COALESCE(r.refunded, 0) - COALESCE(c.credited, 0) AS balance_due
+FROM invoices i
+LEFT JOIN (
+    SELECT invoice_id, SUM(amount - credited) AS paid FROM payments GROUP BY invoice_id
+) p ON p.invoice_id = i.id
+LEFT JOIN (
+    SELECT pm.invoice_id, SUM(rf.amount) AS refunded
+    FROM refunds rf JOIN payments pm ON rf.payment_id = pm.id
+    GROUP BY pm.invoice_id
+) r ON r.invoice_id = i.id
+LEFT JOIN (
+    SELECT invoice_id, SUM(total_amount) AS credited FROM credit_notes GROUP BY invoice_id
+) c ON c.invoice_id = i.id;
+
+INSERT INTO numbering_schemes (document_type, format, reset)
+VALUES ('credit_note', 'PP-CN-{YYYY}-{SEQ:3}', 'yearly');
+
+-- Immutability. What an issued invoice says can't change; its status,
+-- payment details, owner and archiving still can.
+CREATE OR REPLACE FUNCTION prevent_issued_invoice_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'invoices can''t be deleted';
+    END IF;
+    IF ROW(NEW.invoice_number, NEW.booking_id, NEW.issue_date, NEW.due_date,
+           NEW.customer_name, NEW.customer_email, NEW.customer_phone,
+           NEW.billing_address, NEW.billing_city, NEW.billing_state, NEW.billing_zip_code, NEW.billing_country,
+           NEW.service_address, NEW.service_city, NEW.service_state, NEW.service_zip_code,
+           NEW.subtotal, NEW.tax_rate, NEW.tax_amount, NEW.total_amount,
+           NEW.florida_tax_id, NEW.tax_exempt, NEW.tax_exempt_reason, NEW.notes, NEW.terms)
+       IS DISTINCT FROM
+       ROW(OLD.invoice_number, OLD.booking_id, OLD.issue_date, OLD.due_date,
+           OLD.customer_name, OLD.customer_email, OLD.customer_phone,
+           OLD.billing_address, OLD.billing_city, OLD.billing_state, OLD.billing_zip_code, OLD.billing_country,
+           OLD.service_address, OLD.service_city, OLD.service_state, OLD.service_zip_code,
+           OLD.subtotal, OLD.tax_rate, OLD.tax_amount, OLD.total_amount,
+           OLD.florida_tax_id, OLD.tax_exempt, OLD.tax_exempt_reason, OLD.notes, OLD.terms) THEN
+        RAISE EXCEPTION 'issued invoices are immutable';
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER invoices_immutable
+    BEFORE UPDATE OR DELETE ON invoices
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_issued_invoice_changes();
+
+CREATE OR REPLACE FUNCTION prevent_ledger_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% are immutable', TG_TABLE_NAME;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER invoice_items_immutable
+    BEFORE UPDATE OR DELETE ON invoice_items
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_ledger_changes();
+
+CREATE TRIGGER payments_immutable
+    BEFORE UPDATE OR DELETE ON payments
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_ledger_changes();
+
+CREATE TRIGGER refunds_immutable
+    BEFORE UPDATE OR DELETE ON refunds
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_ledger_changes();
+
+CREATE TRIGGER credit_notes_immutable
+    BEFORE UPDATE OR DELETE ON credit_notes
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_ledger_changes();
+
+CREATE TRIGGER credit_note_items_immutable
+    BEFORE UPDATE OR DELETE ON credit_note_items
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_ledger_changes();
+
+CREATE TRIGGER credit_note_documents_immutable
+    BEFORE UPDATE OR DELETE ON credit_note_documents
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_ledger_changes();
@@ -0,0 +1,58 @@
+-- Migration: Late fees
+-- Date: 2026-10-17
+-- Description: Invoices still owed after their due date are marked overdue
+-- every day, and, when a late fee rate is configured, billed a late fee for
+-- every full month past due. Issued invoices can't be changed, so the fees
+-- are billed on an invoice of their own. Each month of an invoice is billed
+-- once, however often the job runs and on however many instances.
+
+CREATE TABLE late_fees (
+    id SERIAL PRIMARY KEY,
+    invoice_id INT NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    period INT NOT NULL CHECK (period > 0),
+    fee_invoice_id INT NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    rate DECIMAL(6,4) NOT NULL,
+    balance DECIMAL(10,2) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (invoice_id, period)
+);
+
+CREATE INDEX idx_late_fees_fee_invoice_id ON late_fees(fee_invoice_id);
+
+CREATE TRIGGER late_fees_immutable
+    BEFORE UPDATE OR DELETE ON late_fees
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_ledger_changes();
+
+-- Age every invoice still owed, not only pending ones
+CREATE OR REPLACE VIEW invoice_summary AS
+SELECT 
+    i.id,
+    i.invoice_number,
+    i.issue_date,
+    i.due_date,
+    i.customer_name,
+    i.customer_email,
+    i.status,
+    i.subtotal,
+    i.tax_amount,
+    i.total_amount,
+    i.payment_method,
+    i.payment_date,
+    b.scheduled_date as service_date,
+    s.name as service_name,
+    CASE 
+        WHEN i.status IN ('paid', 'credited') THEN 'Current'
+        WHEN i.due_date < CURRENT_DATE AND i.status IN ('pending', 'partially_paid', 'overdue') THEN 'Overdue'
+        WHEN i.status IN ('cancelled', 'void') THEN 'Void'
+        ELSE 'Pending'
+    END as aging_status,
+    CASE 
+        WHEN i.due_date < CURRENT_DATE AND i.status IN ('pending', 'partially_paid', 'overdue') THEN 
+            CURRENT_DATE - i.due_date::date
+        ELSE 0
+    END as days_overdue
+FROM invoices i
+JOIN bookings b ON i.booking_id = b.id
+JOIN services s ON b.service_id = s.id;
@@ -0,0 +1,47 @@
+-- Migration: Invoice kinds
+-- Date: 2026-10-17
+-- Description: A booking can have more than one invoice: its own, and the
+-- cancellation or no-show fee and late fees billed on invoices of their own.
+-- Each invoice now says which it is, so the booking's own invoice is found by
+-- its kind rather than by being the oldest. Late-fee invoices also point at
+-- the invoice they charge for.
+
+ALTER TABLE invoices ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'booking'
+    CHECK (kind IN ('booking', 'fee', 'late_fee'));
+ALTER TABLE invoices ADD COLUMN parent_invoice_id INT REFERENCES invoices(id) ON DELETE RESTRICT;
+
+UPDATE invoices i SET kind = 'late_fee', parent_invoice_id = lf.invoice_id
+FROM (SELECT DISTINCT fee_invoice_id, invoice_id FROM late_fees) lf
+WHERE lf.fee_invoice_id = i.id;
+
+UPDATE invoices SET kind = 'fee'
+WHERE kind = 'booking' AND (notes LIKE 'Late cancellation fee (%' OR notes LIKE 'No-show fee (%');
+
+CREATE INDEX idx_invoices_booking_id_kind ON invoices(booking_id, kind);
+CREATE INDEX idx_invoices_parent_invoice_id ON invoices(parent_invoice_id);
+
+-- What an invoice is billed for can't change either
+CREATE OR REPLACE FUNCTION prevent_issued_invoice_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'invoices can''t be deleted';
+    END IF;
+    IF ROW(NEW.invoice_number, NEW.booking_id, NEW.kind, NEW.parent_invoice_id, NEW.issue_date, NEW.due_date,
+           NEW.customer_name, NEW.customer_email, NEW.customer_phone,
+           NEW.billing_address, NEW.billing_city, NEW.billing_state, NEW.billing_zip_code, NEW.billing_country,
+           NEW.service_address, NEW.service_city, NEW.service_state, NEW.service_zip_code,
+           NEW.subtotal, NEW.tax_rate, NEW.tax_amount, NEW.total_amount,
+           NEW.florida_tax_id, NEW.tax_exempt, NEW.tax_exempt_reason, NEW.notes, NEW.terms)
+       IS DISTINCT FROM
+       ROW(OLD.invoice_number, OLD.booking_id, OLD.kind, OLD.parent_invoice_id, OLD.issue_date, OLD.due_date,
+           OLD.customer_name, OLD.customer_email, OLD.customer_phone,
+           OLD.billing_address, OLD.billing_city, OLD.billing_state, OLD.billing_zip_code, OLD.billing_country,
+           OLD.service_address, OLD.service_city, OLD.service_state, OLD.service_zip_code,
+           OLD.subtotal, OLD.tax_rate, OLD.tax_amount, OLD.total_amount,
+           OLD.florida_tax_id, OLD.tax_exempt, OLD.tax_exempt_reason, OLD.notes, OLD.terms) THEN
+        RAISE EXCEPTION 'issued invoices are immutable';
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
@@ -102,7 +102,7 @@ Write-Host ""
 Write-Host "  Services: $serviceCount"
 if ([int]$serviceCount -gt 0) {
     Write-Host "  ✓ Services exist" -ForegroundColor Green
-    & psql -h $Host -U $User -d $Database -c "SELECT id, name, service_type FROM services ORDER BY id;"
+    & psql -h $Host -U $User -d $Database -c "SELECT s.id, s.name, c.slug AS category FROM services s JOIN service_categories c ON s.category_id = c.id ORDER BY s.id;"
 } else {
     Write-Host "  ✗ No services found" -ForegroundColor Red
 }
@@ -94,7 +94,7 @@ echo ""
 echo -e "  Services: $SERVICE_COUNT"
 if [ "$SERVICE_COUNT" -gt 0 ]; then
     echo -e "${GREEN}  ✓ Services exist${NC}"
-    psql -h $DB_HOST -U $DB_USER -d $DB_NAME -c "SELECT id, name, service_type FROM services ORDER BY id;"
+    psql -h $DB_HOST -U $DB_USER -d $DB_NAME -c "SELECT s.id, s.name, c.slug AS category FROM services s JOIN service_categories c ON s.category_id = c.id ORDER BY s.id;"
 else
     echo -e "${RED}  ✗ No services found${NC}"
 fi